@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"time"
 )
 
 type ChecksOptions struct {
@@ -32,6 +33,10 @@ type ChecksOptions struct {
 	// the results if this field is nil or empty slice, or if one of the
 	// values in the slice is equal to the check's name.
 	Names []string
+
+	// History, if true, requests the results of each check's most recent
+	// runs, in CheckInfo.History.
+	History bool
 }
 
 type ChecksActionOptions struct {
@@ -109,6 +114,62 @@ type CheckInfo struct {
 	// The change will be of kind "perform-check" if the check is up, or
 	// "recover-check" if it's down.
 	ChangeID string `json:"change-id"`
+
+	// History holds the results of the check's most recent runs, oldest
+	// first. It's only set if ChecksOptions.History was true.
+	History []CheckRun `json:"history,omitempty"`
+}
+
+// CheckResult is the result of a single run of a health check.
+type CheckResult string
+
+const (
+	CheckResultSuccess CheckResult = "success"
+	CheckResultFailure CheckResult = "failure"
+)
+
+// CheckRun holds the result of a single run of a health check.
+type CheckRun struct {
+	// Time is when the check started running.
+	Time time.Time `json:"time"`
+
+	// Duration is how long the check took to run.
+	Duration time.Duration `json:"duration"`
+
+	// Result is "success" or "failure".
+	Result CheckResult `json:"result"`
+
+	// Error is the error message if the check failed, and Details is more
+	// information about the failure (if any), such as the output of the
+	// command or the response body.
+	Error   string `json:"error,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+func (r *CheckRun) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Time     time.Time   `json:"time"`
+		Duration string      `json:"duration"`
+		Result   CheckResult `json:"result"`
+		Error    string      `json:"error"`
+		Details  string      `json:"details"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(v.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid check run duration %q", v.Duration)
+	}
+	*r = CheckRun{
+		Time:     v.Time,
+		Duration: duration,
+		Result:   v.Result,
+		Error:    v.Error,
+		Details:  v.Details,
+	}
+	return nil
 }
 
 // Checks fetches information about specific health checks (or all of them),
@@ -121,6 +182,9 @@ func (client *Client) Checks(opts *ChecksOptions) ([]*CheckInfo, error) {
 	if len(opts.Names) > 0 {
 		query["names"] = opts.Names
 	}
+	if opts.History {
+		query.Set("history", "true")
+	}
 	var checks []*CheckInfo
 	resp, err := client.Requester().Do(context.Background(), &RequestOptions{
 		Type:   SyncRequest,
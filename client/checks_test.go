@@ -17,6 +17,7 @@ package client_test
 import (
 	"encoding/json"
 	"net/url"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -61,6 +62,48 @@ func (cs *clientSuite) TestChecksGet(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestChecksGetHistory(c *check.C) {
+	cs.rsp = `{
+		"result": [
+			{"name": "chk1", "status": "up", "history": [
+				{"time": "2026-10-16T14:00:00Z", "duration": "12.5ms", "result": "success"},
+				{"time": "2026-10-16T14:00:10Z", "duration": "3s", "result": "failure",
+				 "error": "check timed out after 3s", "details": "partial output"}
+			]}
+		],
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	opts := client.ChecksOptions{
+		Names:   []string{"chk1"},
+		History: true,
+	}
+	checks, err := cs.cli.Checks(&opts)
+	c.Assert(err, check.IsNil)
+	c.Assert(checks, check.DeepEquals,
+		[]*client.CheckInfo{{
+			Name:   "chk1",
+			Status: client.CheckStatusUp,
+			History: []client.CheckRun{{
+				Time:     time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC),
+				Duration: 12500 * time.Microsecond,
+				Result:   client.CheckResultSuccess,
+			}, {
+				Time:     time.Date(2026, 10, 16, 14, 0, 10, 0, time.UTC),
+				Duration: 3 * time.Second,
+				Result:   client.CheckResultFailure,
+				Error:    "check timed out after 3s",
+				Details:  "partial output",
+			}},
+		}})
+	c.Assert(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"names":   {"chk1"},
+		"history": {"true"},
+	})
+}
+
 func (cs *clientSuite) TestStartChecks(c *check.C) {
 	cs.rsp = `{
 		"result": {"changed": ["chk1", "chk2"]},
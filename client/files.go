@@ -44,6 +44,20 @@ type ListFilesOptions struct {
 	// Itself, when set, will force directory entries not to be listed, but
 	// instead have their information returned as if they were regular files.
 	Itself bool
+
+	// Recursive, when set, lists the contents of subdirectories too, after
+	// each subdirectory. Symbolic links to directories aren't followed.
+	// Pattern, if set, is matched against the name of each entry.
+	Recursive bool
+
+	// MaxDepth limits how many levels of subdirectories are listed when
+	// Recursive is set, where 1 lists only the directory itself. Zero means
+	// no limit.
+	MaxDepth int
+
+	// Digest, when set, includes the SHA-256 digest of each regular file's
+	// contents (see FileInfo.SHA256).
+	Digest bool
 }
 
 type FileInfo struct {
@@ -56,6 +70,7 @@ type FileInfo struct {
 	groupID *int
 	user    string
 	group   string
+	sha256  string
 }
 
 // Name returns the base name of the file.
@@ -114,6 +129,12 @@ func (fi *FileInfo) Group() string {
 	return fi.group
 }
 
+// SHA256 is the hex-encoded SHA-256 digest of a regular file's contents, if
+// requested (empty if not requested or the file couldn't be read).
+func (fi *FileInfo) SHA256() string {
+	return fi.sha256
+}
+
 // ListFiles obtains the contents of a directory or glob, or information about a file.
 func (client *Client) ListFiles(opts *ListFilesOptions) ([]*FileInfo, error) {
 	q := make(url.Values)
@@ -125,6 +146,15 @@ func (client *Client) ListFiles(opts *ListFilesOptions) ([]*FileInfo, error) {
 	if opts.Itself {
 		q.Set("itself", "true")
 	}
+	if opts.Recursive {
+		q.Set("recursive", "true")
+	}
+	if opts.MaxDepth > 0 {
+		q.Set("max-depth", strconv.Itoa(opts.MaxDepth))
+	}
+	if opts.Digest {
+		q.Set("digest", "sha256")
+	}
 
 	var results []fileInfoResult
 	resp, err := client.Requester().Do(context.Background(), &RequestOptions{
@@ -163,6 +193,7 @@ type fileInfoResult struct {
 	User         string `json:"user"`
 	GroupID      *int   `json:"group-id"`
 	Group        string `json:"group"`
+	SHA256       string `json:"sha256,omitempty"`
 }
 
 func calculateFileMode(fileType string, permissions string) (mode os.FileMode, err error) {
@@ -215,10 +246,79 @@ func resultToFileInfo(result fileInfoResult) (*FileInfo, error) {
 	fi.mode = mode
 	fi.user = result.User
 	fi.group = result.Group
+	fi.sha256 = result.SHA256
 
 	return fi, nil
 }
 
+// StatFilesOptions holds the options for a call to StatFiles.
+type StatFilesOptions struct {
+	// Paths are the absolute paths of the files to get information about.
+	// Symbolic links are followed.
+	Paths []string
+
+	// Digest, when set, includes the SHA-256 digest of each regular file's
+	// contents (see FileInfo.SHA256).
+	Digest bool
+}
+
+// FileStat holds the result of StatFiles for a single path.
+type FileStat struct {
+	Path string
+
+	// Info holds information about the file, or is nil if Error is set.
+	Info *FileInfo
+
+	// Error is set if there was an OS-level error getting information about
+	// the file, with the Kind field set to the specific error kind, for
+	// example "not-found".
+	Error *Error
+}
+
+type statFileResult struct {
+	fileInfoResult
+	Error *Error `json:"error,omitempty"`
+}
+
+// StatFiles obtains information about each of the given paths, in order.
+func (client *Client) StatFiles(opts *StatFilesOptions) ([]*FileStat, error) {
+	q := make(url.Values)
+	q.Set("action", "stat")
+	q["path"] = opts.Paths
+	if opts.Digest {
+		q.Set("digest", "sha256")
+	}
+
+	var results []statFileResult
+	resp, err := client.Requester().Do(context.Background(), &RequestOptions{
+		Type:   SyncRequest,
+		Method: "GET",
+		Path:   "/v1/files",
+		Query:  q,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = resp.DecodeResult(&results)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*FileStat, len(results))
+	for i, result := range results {
+		stats[i] = &FileStat{Path: result.Path}
+		if result.Error != nil {
+			stats[i].Error = result.Error
+			continue
+		}
+		stats[i].Info, err = resultToFileInfo(result.fileInfoResult)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
 // MakeDirOptions holds the options for a call to MakeDir.
 type MakeDirOptions struct {
 	// Path is the absolute path of the directory to be created (required).
@@ -434,8 +534,9 @@ type PushOptions struct {
 }
 
 type writeFilesPayload struct {
-	Action string           `json:"action"`
-	Files  []writeFilesItem `json:"files"`
+	Action      string           `json:"action"`
+	Files       []writeFilesItem `json:"files"`
+	Transaction bool             `json:"transaction,omitempty"`
 }
 
 type writeFilesItem struct {
@@ -456,14 +557,66 @@ func escapeQuotes(s string) string {
 
 // Push writes content to a path on the remote system.
 func (client *Client) Push(opts *PushOptions) error {
-	var permissions string
-	if opts.Permissions != 0 {
-		permissions = fmt.Sprintf("%03o", opts.Permissions)
+	result, err := client.writeFiles([]*PushOptions{opts}, false)
+	if err != nil {
+		return err
 	}
+	if len(result) != 1 {
+		return fmt.Errorf("expected exactly one result from API, got %d", len(result))
+	}
+	if result[0].Error != nil {
+		return &Error{
+			Kind:    result[0].Error.Kind,
+			Value:   result[0].Error.Value,
+			Message: result[0].Error.Message,
+		}
+	}
+
+	return nil
+}
 
+type PushFilesOptions struct {
+	// Files are the files to write (at least one is required), each
+	// described as for Push.
+	Files []*PushOptions
+}
+
+// PushFiles writes several files to the remote system in a single
+// transaction: either all the files are written, or none of them are.
+// The error returned is a *Error if the request went through successfully
+// but a file couldn't be written, with the Kind field set to the specific
+// error kind, for example "permission-denied", and the Value field set to
+// the path of the file.
+//
+// Note that directories created for files with MakeDirs set are not
+// removed if the transaction fails.
+func (client *Client) PushFiles(opts *PushFilesOptions) error {
+	if len(opts.Files) == 0 {
+		return fmt.Errorf("cannot push files: no files specified")
+	}
+	result, err := client.writeFiles(opts.Files, true)
+	if err != nil {
+		return err
+	}
+	if len(result) != len(opts.Files) {
+		return fmt.Errorf("expected %d results from API, got %d", len(opts.Files), len(result))
+	}
+	return nil
+}
+
+// writeFiles sends a multipart "write" request for the given files,
+// streaming the content of each file from its source.
+func (client *Client) writeFiles(files []*PushOptions, transaction bool) ([]fileResult, error) {
 	payload := writeFilesPayload{
-		Action: "write",
-		Files: []writeFilesItem{{
+		Action:      "write",
+		Transaction: transaction,
+	}
+	for _, opts := range files {
+		var permissions string
+		if opts.Permissions != 0 {
+			permissions = fmt.Sprintf("%03o", opts.Permissions)
+		}
+		payload.Files = append(payload.Files, writeFilesItem{
 			Path:        opts.Path,
 			MakeDirs:    opts.MakeDirs,
 			Permissions: permissions,
@@ -471,7 +624,7 @@ func (client *Client) Push(opts *PushOptions) error {
 			User:        opts.User,
 			GroupID:     opts.GroupID,
 			Group:       opts.Group,
-		}},
+		})
 	}
 
 	var body bytes.Buffer
@@ -483,58 +636,49 @@ func (client *Client) Push(opts *PushOptions) error {
 		"Content-Disposition": {`form-data; name="request"`},
 	})
 	if err != nil {
-		return fmt.Errorf("cannot encode metadata in request payload: %w", err)
+		return nil, fmt.Errorf("cannot encode metadata in request payload: %w", err)
 	}
 
 	// Buffer for multipart header/footer
 	if err := json.NewEncoder(part).Encode(&payload); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Encode file part of the header
-	escapedPath := escapeQuotes(opts.Path)
-	_, err = mw.CreatePart(textproto.MIMEHeader{
-		"Content-Type":        {"application/octet-stream"},
-		"Content-Disposition": {fmt.Sprintf(`form-data; name="files"; filename="%s"`, escapedPath)},
-	})
-	if err != nil {
-		return fmt.Errorf("cannot encode file in request payload: %w", err)
+	// Encode the header of each file part, followed by the file's content.
+	var readers []io.Reader
+	for _, opts := range files {
+		escapedPath := escapeQuotes(opts.Path)
+		_, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":        {"application/octet-stream"},
+			"Content-Disposition": {fmt.Sprintf(`form-data; name="files"; filename="%s"`, escapedPath)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode file in request payload: %w", err)
+		}
+		readers = append(readers, strings.NewReader(body.String()), opts.Source)
+		body.Reset()
 	}
 
-	header := body.String()
-
 	// Encode multipart footer
-	body.Reset()
 	mw.Close()
-	footer := body.String()
+	readers = append(readers, strings.NewReader(body.String()))
 
 	resp, err := client.Requester().Do(context.Background(), &RequestOptions{
 		Type:    SyncRequest,
 		Method:  "POST",
 		Path:    "/v1/files",
 		Headers: map[string]string{"Content-Type": mw.FormDataContentType()},
-		Body:    io.MultiReader(strings.NewReader(header), opts.Source, strings.NewReader(footer)),
+		Body:    io.MultiReader(readers...),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var result []fileResult
 	if err = resp.DecodeResult(&result); err != nil {
-		return err
-	}
-	if len(result) != 1 {
-		return fmt.Errorf("expected exactly one result from API, got %d", len(result))
-	}
-	if result[0].Error != nil {
-		return &Error{
-			Kind:    result[0].Error.Kind,
-			Value:   result[0].Error.Value,
-			Message: result[0].Error.Message,
-		}
+		return nil, err
 	}
-
-	return nil
+	return result, nil
 }
 
 type PullOptions struct {
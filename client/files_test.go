@@ -23,6 +23,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"os"
 	"path"
 	"strings"
@@ -220,6 +221,99 @@ func (cs *clientSuite) TestListFilesFailsWithInvalidPermissions(c *C) {
 	c.Assert(err, ErrorMatches, `remote file "irreg" has invalid permission bits: "not a number"`)
 }
 
+func (cs *clientSuite) TestListFilesRecursive(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"status": "OK",
+		"result": [{
+			"path": "/etc/app",
+			"name": "app",
+			"type": "directory",
+			"permissions": "755",
+			"last-modified": "2022-04-21T03:02:51Z"
+		}, {
+			"path": "/etc/app/app.conf",
+			"name": "app.conf",
+			"type": "file",
+			"size": 1,
+			"permissions": "644",
+			"last-modified": "2022-04-21T03:02:51Z",
+			"sha256": "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+		}]
+	}`
+	result, err := cs.cli.ListFiles(&client.ListFilesOptions{
+		Path:      "/etc",
+		Recursive: true,
+		MaxDepth:  2,
+		Digest:    true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"action":    {"list"},
+		"path":      {"/etc"},
+		"recursive": {"true"},
+		"max-depth": {"2"},
+		"digest":    {"sha256"},
+	})
+	c.Assert(result, HasLen, 2)
+	c.Check(result[0].Path(), Equals, "/etc/app")
+	c.Check(result[0].SHA256(), Equals, "")
+	c.Check(result[1].Path(), Equals, "/etc/app/app.conf")
+	c.Check(result[1].SHA256(), Equals, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb")
+}
+
+func (cs *clientSuite) TestStatFiles(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"status": "OK",
+		"result": [{
+			"path": "/etc/app.conf",
+			"name": "app.conf",
+			"type": "file",
+			"size": 1,
+			"permissions": "644",
+			"last-modified": "2022-04-21T03:02:51Z",
+			"user-id": 1000,
+			"user": "toor",
+			"group-id": 1000,
+			"group": "toor",
+			"sha256": "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+		}, {
+			"path": "/etc/missing",
+			"error": {
+				"kind": "not-found",
+				"message": "stat /etc/missing: no such file or directory"
+			}
+		}]
+	}`
+	result, err := cs.cli.StatFiles(&client.StatFilesOptions{
+		Paths:  []string{"/etc/app.conf", "/etc/missing"},
+		Digest: true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"action": {"stat"},
+		"path":   {"/etc/app.conf", "/etc/missing"},
+		"digest": {"sha256"},
+	})
+	c.Assert(result, HasLen, 2)
+	c.Check(result[0].Path, Equals, "/etc/app.conf")
+	c.Check(result[0].Error, IsNil)
+	c.Check(result[0].Info.Name(), Equals, "app.conf")
+	c.Check(result[0].Info.Size(), Equals, int64(1))
+	c.Check(result[0].Info.Mode(), Equals, os.FileMode(0o644))
+	c.Check(result[0].Info.User(), Equals, "toor")
+	c.Check(result[0].Info.SHA256(), Equals, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb")
+	c.Check(result[1].Path, Equals, "/etc/missing")
+	c.Check(result[1].Info, IsNil)
+	c.Check(result[1].Error, DeepEquals, &client.Error{
+		Kind:    "not-found",
+		Message: "stat /etc/missing: no such file or directory",
+	})
+}
+
 func (cs *clientSuite) TestCalculateFileMode(c *C) {
 	expectedResults := []struct {
 		fileType, permissions string
@@ -585,8 +679,9 @@ func (cs *clientSuite) TestRemovePathFailsWithMultipleAPIResults(c *C) {
 }
 
 type writeFilesPayload struct {
-	Action string           `json:"action"`
-	Files  []writeFilesItem `json:"files"`
+	Action      string           `json:"action"`
+	Files       []writeFilesItem `json:"files"`
+	Transaction bool             `json:"transaction"`
 }
 
 type writeFilesItem struct {
@@ -711,6 +806,91 @@ func (cs *clientSuite) TestPushFailsWithMultipleAPIResults(c *C) {
 	c.Assert(err, ErrorMatches, "expected exactly one result from API, got 2")
 }
 
+func (cs *clientSuite) TestPushFiles(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{"path": "/foo.dat"}, {"path": "/bar/baz.dat"}]}`
+
+	err := cs.cli.PushFiles(&client.PushFilesOptions{
+		Files: []*client.PushOptions{{
+			Path:   "/foo.dat",
+			Source: strings.NewReader("Hello, world!"),
+		}, {
+			Path:        "/bar/baz.dat",
+			Source:      strings.NewReader("Bye"),
+			MakeDirs:    true,
+			Permissions: 0o600,
+		}},
+	})
+	c.Assert(err, IsNil)
+	mr, err := cs.req.MultipartReader()
+	c.Assert(err, IsNil)
+
+	// Check metadata part
+	metadata, err := mr.NextPart()
+	c.Assert(err, IsNil)
+	c.Assert(metadata.FormName(), Equals, "request")
+	var payload writeFilesPayload
+	err = json.NewDecoder(metadata).Decode(&payload)
+	c.Assert(err, IsNil)
+	c.Assert(payload, DeepEquals, writeFilesPayload{
+		Action: "write",
+		Files: []writeFilesItem{{
+			Path: "/foo.dat",
+		}, {
+			Path:        "/bar/baz.dat",
+			MakeDirs:    true,
+			Permissions: "600",
+		}},
+		Transaction: true,
+	})
+
+	// Check file parts
+	for _, expected := range []struct{ path, content string }{
+		{"/foo.dat", "Hello, world!"},
+		{"/bar/baz.dat", "Bye"},
+	} {
+		file, err := mr.NextPart()
+		c.Assert(err, IsNil)
+		c.Assert(file.FormName(), Equals, "files")
+		c.Assert(file.Header.Get("Content-Disposition"), Equals,
+			fmt.Sprintf(`form-data; name="files"; filename="%s"`, expected.path))
+		content, err := io.ReadAll(file)
+		c.Assert(err, IsNil)
+		c.Assert(string(content), Equals, expected.content)
+	}
+	_, err = mr.NextPart()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (cs *clientSuite) TestPushFilesFails(c *C) {
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 403,
+		"result": {
+			"message": "cannot write \"/bar.dat\": permission denied",
+			"kind": "permission-denied",
+			"value": "/bar.dat"
+		}
+	}`
+
+	err := cs.cli.PushFiles(&client.PushFilesOptions{
+		Files: []*client.PushOptions{{
+			Path:   "/foo.dat",
+			Source: strings.NewReader("foo"),
+		}, {
+			Path:   "/bar.dat",
+			Source: strings.NewReader("bar"),
+		}},
+	})
+	clientErr, ok := err.(*client.Error)
+	c.Assert(ok, Equals, true)
+	c.Assert(clientErr.Message, Equals, `cannot write "/bar.dat": permission denied`)
+	c.Assert(clientErr.Kind, Equals, "permission-denied")
+	c.Assert(clientErr.Value, Equals, "/bar.dat")
+
+	err = cs.cli.PushFiles(&client.PushFilesOptions{})
+	c.Assert(err, ErrorMatches, "cannot push files: no files specified")
+}
+
 func (cs *clientSuite) TestPull(c *C) {
 	// Craft multipart response
 	var srcBuf bytes.Buffer
@@ -23,7 +23,8 @@ import (
 type HealthOptions struct {
 	// Level may be set to CheckAlive, to query whether alive checks are up, and
 	// CheckReady, to query whether both alive and ready checks are up.
-	// Defaults to CheckReady if unset.
+	// It may also be set to a custom level defined in the plan's
+	// health-levels section. Defaults to CheckReady if unset.
 	Level CheckLevel
 
 	// Names defines which checks should be considered for the query. Defaults to all.
@@ -44,6 +44,19 @@ type LogsOptions struct {
 	// mode, the default is zero, in non-follow mode it's server-defined
 	// (currently 30). Set to -1 to return the entire buffer.
 	N int
+
+	// Since, if set, only returns logs written at or after this time,
+	// including logs from a service's log files if it has them. If N is
+	// zero, all such logs are returned.
+	Since time.Time
+
+	// Until, if set, only returns logs written at or before this time. When
+	// following, the logs end once a later log is written.
+	Until time.Time
+
+	// Match, if set, is a regular expression (RE2 syntax) that log messages
+	// must match.
+	Match string
 }
 
 // LogEntry is the struct passed to the WriteLog function.
@@ -51,6 +64,11 @@ type LogEntry struct {
 	Time    time.Time `json:"time"`
 	Service string    `json:"service"`
 	Message string    `json:"message"`
+
+	// Level and Attributes are only set for services with a structured
+	// log-format, such as "json" or "logfmt".
+	Level      string            `json:"level,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // Logs fetches previously-written logs from the given services.
@@ -72,6 +90,15 @@ func (client *Client) logs(ctx context.Context, opts *LogsOptions, follow bool)
 	if opts.N != 0 {
 		query.Set("n", strconv.Itoa(opts.N))
 	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339Nano))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339Nano))
+	}
+	if opts.Match != "" {
+		query.Set("match", opts.Match)
+	}
 	if follow {
 		query.Set("follow", "true")
 	}
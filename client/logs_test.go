@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -112,6 +113,48 @@ func (cs *clientSuite) TestLogsAll(c *check.C) {
 `[1:])
 }
 
+func (cs *clientSuite) TestLogsSince(c *check.C) {
+	cs.rsp = `
+{"time":"2021-05-03T03:55:49.654334232Z","service":"snappass","message":"log two\n"}
+`[1:]
+	out, writeLog := makeLogWriter()
+	err := cs.cli.Logs(&client.LogsOptions{
+		WriteLog: writeLog,
+		Since:    time.Date(2021, 5, 3, 3, 55, 49, 500_000_000, time.UTC),
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v1/logs")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"since": []string{"2021-05-03T03:55:49.5Z"},
+	})
+	c.Check(out.String(), check.Equals, `
+2021-05-03T03:55:49.654Z [snappass] log two
+`[1:])
+}
+
+func (cs *clientSuite) TestLogsUntilMatch(c *check.C) {
+	cs.rsp = `
+{"time":"2021-05-03T03:55:49.654334232Z","service":"snappass","message":"log two\n"}
+`[1:]
+	out, writeLog := makeLogWriter()
+	err := cs.cli.Logs(&client.LogsOptions{
+		WriteLog: writeLog,
+		N:        10,
+		Until:    time.Date(2021, 5, 3, 4, 0, 0, 0, time.UTC),
+		Match:    "two$",
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"n":     []string{"10"},
+		"until": []string{"2021-05-03T04:00:00Z"},
+		"match": []string{"two$"},
+	})
+	c.Check(out.String(), check.Equals, `
+2021-05-03T03:55:49.654Z [snappass] log two
+`[1:])
+}
+
 func (cs *clientSuite) TestLogsLong(c *check.C) {
 	const maxMessageSize = 4 * 1024
 	shortLog1 := `{"time":"2021-05-03T03:55:49.360994155Z","service":"thing","message":"log 1\n"}`
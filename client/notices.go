@@ -143,6 +143,10 @@ const (
 	// Warnings are a subset of notices where the key is a human-readable
 	// warning message.
 	WarningNotice NoticeType = "warning"
+
+	// Recorded whenever a health check's status changes between "up", "down"
+	// and "inactive". The key for check-status notices is the check name.
+	CheckStatusNotice NoticeType = "check-status"
 )
 
 type jsonNotice struct {
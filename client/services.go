@@ -92,16 +92,68 @@ type ServicesOptions struct {
 	// Names is the list of service names to query for. If slice is nil or
 	// empty, fetch information for all services.
 	Names []string
+
+	// Usage requests the resource usage of each running service.
+	Usage bool
 }
 
 // ServiceInfo holds status information for a single service.
 type ServiceInfo struct {
-	Name         string         `json:"name"`
-	Startup      ServiceStartup `json:"startup"`
-	Current      ServiceStatus  `json:"current"`
-	CurrentSince time.Time      `json:"current-since"`
+	Name         string           `json:"name"`
+	Startup      ServiceStartup   `json:"startup"`
+	Current      ServiceStatus    `json:"current"`
+	CurrentSince time.Time        `json:"current-since"`
+	Schedule     *ServiceSchedule `json:"schedule,omitempty"`
+	Usage        *ServiceUsage    `json:"usage,omitempty"`
 }
 
+// ServiceUsage holds the resources used by a running service's processes.
+type ServiceUsage struct {
+	// CPUSeconds is the user and system CPU time used by the service's
+	// running processes.
+	CPUSeconds float64 `json:"cpu-seconds"`
+
+	// RSSBytes is the resident set size of the service's processes.
+	RSSBytes int64 `json:"rss-bytes"`
+
+	// OpenFDs is the number of file descriptors open in the service's
+	// processes.
+	OpenFDs int `json:"open-fds"`
+
+	// Threads is the number of threads in the service's processes.
+	Threads int `json:"threads"`
+}
+
+// ServiceSchedule holds status information for a scheduled service.
+type ServiceSchedule struct {
+	// Schedule is the service's schedule, as specified in the plan.
+	Schedule string `json:"schedule"`
+
+	// Next is the time the service is next due to run.
+	Next time.Time `json:"next"`
+
+	// LastRun is the time the most recent run started (or was due to start,
+	// if it was skipped).
+	LastRun time.Time `json:"last-run"`
+
+	// LastResult is the outcome of the most recent run.
+	LastResult RunResult `json:"last-result"`
+
+	// Queued is true if a run is waiting for the current run to finish.
+	Queued bool `json:"queued"`
+}
+
+// RunResult defines the outcomes of a scheduled service's run.
+type RunResult string
+
+const (
+	RunSucceeded RunResult = "succeeded"
+	RunFailed    RunResult = "failed"
+	RunSkipped   RunResult = "skipped"
+	RunMisfired  RunResult = "misfired"
+	RunKilled    RunResult = "killed"
+)
+
 // ServiceStartup defines the different startup modes for a service.
 type ServiceStartup string
 
@@ -118,6 +170,7 @@ const (
 	StatusBackoff  ServiceStatus = "backoff"
 	StatusError    ServiceStatus = "error"
 	StatusInactive ServiceStatus = "inactive"
+	StatusExitedOK ServiceStatus = "exited-ok"
 )
 
 // Services fetches information about specific services (or all of them),
@@ -126,6 +179,9 @@ func (client *Client) Services(opts *ServicesOptions) ([]*ServiceInfo, error) {
 	query := url.Values{
 		"names": []string{strings.Join(opts.Names, ",")},
 	}
+	if opts.Usage {
+		query.Set("usage", "true")
+	}
 	var services []*ServiceInfo
 	resp, err := client.Requester().Do(context.Background(), &RequestOptions{
 		Type:   SyncRequest,
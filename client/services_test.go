@@ -88,7 +88,10 @@ func (cs *clientSuite) TestServicesGet(c *check.C) {
 	cs.rsp = `{
 		"result": [
 			{"name": "svc1", "startup": "enabled", "current": "inactive"},
-			{"name": "svc2", "startup": "disabled", "current": "active", "current-since": "2022-04-28T17:05:23Z"}
+			{"name": "svc2", "startup": "disabled", "current": "active", "current-since": "2022-04-28T17:05:23Z"},
+			{"name": "svc3", "startup": "disabled", "current": "inactive", "schedule": {
+				"schedule": "02:00", "next": "2022-04-29T02:00:00Z",
+				"last-run": "2022-04-28T02:00:00Z", "last-result": "failed"}}
 		],
 		"status": "OK",
 		"status-code": 200,
@@ -103,6 +106,12 @@ func (cs *clientSuite) TestServicesGet(c *check.C) {
 	c.Assert(services, check.DeepEquals, []*client.ServiceInfo{
 		{Name: "svc1", Startup: client.StartupEnabled, Current: client.StatusInactive},
 		{Name: "svc2", Startup: client.StartupDisabled, Current: client.StatusActive, CurrentSince: time.Date(2022, 4, 28, 17, 5, 23, 0, time.UTC)},
+		{Name: "svc3", Startup: client.StartupDisabled, Current: client.StatusInactive, Schedule: &client.ServiceSchedule{
+			Schedule:   "02:00",
+			Next:       time.Date(2022, 4, 29, 2, 0, 0, 0, time.UTC),
+			LastRun:    time.Date(2022, 4, 28, 2, 0, 0, 0, time.UTC),
+			LastResult: client.RunFailed,
+		}},
 	})
 	c.Assert(cs.req.Method, check.Equals, "GET")
 	c.Assert(cs.req.URL.Path, check.Equals, "/v1/services")
@@ -111,6 +120,36 @@ func (cs *clientSuite) TestServicesGet(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestServicesGetUsage(c *check.C) {
+	cs.rsp = `{
+		"result": [
+			{"name": "svc1", "startup": "enabled", "current": "active", "current-since": "2022-04-28T17:05:23Z",
+				"usage": {"cpu-seconds": 1.5, "rss-bytes": 1048576, "open-fds": 7, "threads": 3}},
+			{"name": "svc2", "startup": "disabled", "current": "inactive"}
+		],
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	opts := client.ServicesOptions{Usage: true}
+	services, err := cs.cli.Services(&opts)
+	c.Assert(err, check.IsNil)
+	c.Assert(services, check.DeepEquals, []*client.ServiceInfo{
+		{Name: "svc1", Startup: client.StartupEnabled, Current: client.StatusActive, CurrentSince: time.Date(2022, 4, 28, 17, 5, 23, 0, time.UTC), Usage: &client.ServiceUsage{
+			CPUSeconds: 1.5,
+			RSSBytes:   1048576,
+			OpenFDs:    7,
+			Threads:    3,
+		}},
+		{Name: "svc2", Startup: client.StartupDisabled, Current: client.StatusInactive},
+	})
+	c.Assert(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"names": {""},
+		"usage": {"true"},
+	})
+}
+
 func (cs *clientSuite) TestRestart(c *check.C) {
 	cs.rsp = `{
 		"result": {},
@@ -0,0 +1,367 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package cgroup provides access to cgroup v2 groups, used to apply resource
+// limits to services and measure their usage.
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	mountPoint     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
+// FakePaths changes the cgroup v2 mount point and the file used to find the
+// current process's cgroup, for testing purposes.
+func FakePaths(mount, selfCgroup string) (restore func()) {
+	oldMount, oldSelf := mountPoint, procSelfCgroup
+	mountPoint, procSelfCgroup = mount, selfCgroup
+	return func() {
+		mountPoint, procSelfCgroup = oldMount, oldSelf
+	}
+}
+
+// FakeCloneInto changes whether processes can be created directly in a
+// group, for testing purposes.
+func FakeCloneInto(supported bool) (restore func()) {
+	old := cloneIntoSupported
+	cloneIntoSupported = func() bool { return supported }
+	return func() {
+		cloneIntoSupported = old
+	}
+}
+
+// ErrUnavailable is returned by Self when cgroup v2 isn't mounted, for
+// example on systems using cgroup v1.
+var ErrUnavailable = errors.New("cgroup v2 not available")
+
+// cpuPeriod is the period in microseconds used when limiting CPU time.
+const cpuPeriod = 100000
+
+// Group is a cgroup v2 group.
+type Group struct {
+	path string
+}
+
+// Self returns the cgroup of the current process.
+func Self() (*Group, error) {
+	if _, err := os.Stat(filepath.Join(mountPoint, "cgroup.controllers")); err != nil {
+		return nil, ErrUnavailable
+	}
+	data, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return nil, err
+	}
+	// The cgroup v2 entry has hierarchy ID 0 and no controllers, for
+	// example "0::/system.slice/pebble.service".
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		rel, ok := strings.CutPrefix(scanner.Text(), "0::")
+		if ok {
+			return &Group{path: filepath.Join(mountPoint, rel)}, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+// Path returns the group's path in the cgroup filesystem.
+func (g *Group) Path() string {
+	return g.path
+}
+
+// Child returns the named child group, creating it if it doesn't exist.
+func (g *Group) Child(name string) (*Group, error) {
+	path := filepath.Join(g.path, name)
+	err := os.Mkdir(path, 0o755)
+	if err != nil && !errors.Is(err, os.ErrExist) {
+		return nil, err
+	}
+	return &Group{path: path}, nil
+}
+
+// Controllers returns the controllers available in the group.
+func (g *Group) Controllers() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(g.path, "cgroup.controllers"))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// EnableControllers enables the given controllers for the group's children.
+func (g *Group) EnableControllers(controllers []string) error {
+	if len(controllers) == 0 {
+		return nil
+	}
+	return g.write("cgroup.subtree_control", "+"+strings.Join(controllers, " +"))
+}
+
+// Processes returns the IDs of the processes in the group.
+func (g *Group) Processes() ([]int, error) {
+	data, err := os.ReadFile(filepath.Join(g.path, "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(data)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process ID %q in %s", field, g.path)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// Open opens the group's directory, for use as the CgroupFD of a process
+// created in the group (see CanCloneInto).
+func (g *Group) Open() (*os.File, error) {
+	return os.Open(g.path)
+}
+
+// CanCloneInto reports whether the kernel supports creating processes
+// directly in a group, using clone3 with CLONE_INTO_CGROUP (Linux 5.7).
+func CanCloneInto() bool {
+	return cloneIntoSupported()
+}
+
+var cloneIntoSupported = sync.OnceValue(probeCloneInto)
+
+// cloneArgs is the argument of the clone3 system call.
+type cloneArgs struct {
+	flags      uint64
+	pidfd      uint64
+	childTID   uint64
+	parentTID  uint64
+	exitSignal uint64
+	stack      uint64
+	stackSize  uint64
+	tls        uint64
+	setTID     uint64
+	setTIDSize uint64
+	cgroup     uint64
+}
+
+// probeCloneInto calls clone3 with a cgroup file descriptor that can't be
+// open, so it never creates a process. Kernels that support
+// CLONE_INTO_CGROUP fail with EBADF; others fail with ENOSYS, EINVAL or
+// E2BIG (or EPERM, if clone3 is blocked by a seccomp filter).
+func probeCloneInto() bool {
+	args := cloneArgs{flags: unix.CLONE_INTO_CGROUP, cgroup: math.MaxInt32}
+	_, _, errno := unix.RawSyscall(unix.SYS_CLONE3, uintptr(unsafe.Pointer(&args)), unsafe.Sizeof(args), 0)
+	return errno == unix.EBADF
+}
+
+// AddProcess moves the given process into the group.
+func (g *Group) AddProcess(pid int) error {
+	return g.write("cgroup.procs", strconv.Itoa(pid))
+}
+
+// Signal sends the signal to every process in the group and its
+// descendants. SIGKILL is sent using cgroup.kill where the kernel supports
+// it, which reaches processes that fork while the group is being killed.
+func (g *Group) Signal(sig syscall.Signal) error {
+	if sig == syscall.SIGKILL && g.exists("cgroup.kill") {
+		return g.write("cgroup.kill", "1")
+	}
+	groups := []*Group{g}
+	for len(groups) > 0 {
+		group := groups[0]
+		groups = groups[1:]
+		pids, err := group.Processes()
+		if err != nil {
+			return err
+		}
+		for _, pid := range pids {
+			err := syscall.Kill(pid, sig)
+			if err != nil && err != syscall.ESRCH {
+				return err
+			}
+		}
+		entries, err := os.ReadDir(group.path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				groups = append(groups, &Group{path: filepath.Join(group.path, entry.Name())})
+			}
+		}
+	}
+	return nil
+}
+
+// Populated reports whether the group or any of its descendants contain
+// processes.
+func (g *Group) Populated() (bool, error) {
+	data, err := os.ReadFile(filepath.Join(g.path, "cgroup.events"))
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "populated "); ok {
+				return value == "1", nil
+			}
+		}
+	}
+	// Fall back to checking the group itself, ignoring sub-groups.
+	pids, err := g.Processes()
+	if err != nil {
+		return false, err
+	}
+	return len(pids) > 0, nil
+}
+
+// Limits holds resource limits for a group. Zero values mean no limit.
+type Limits struct {
+	// MemoryMax is the memory limit in bytes.
+	MemoryMax int64
+
+	// CPUMax is how many CPUs' worth of time the group may use.
+	CPUMax float64
+
+	// PidsMax is the maximum number of processes and threads.
+	PidsMax int
+
+	// IOWeight is the relative IO weight, from 1 to 10000.
+	IOWeight int
+}
+
+// SetLimits applies the given resource limits to the group. Limits that are
+// not set are reset to their defaults.
+func (g *Group) SetLimits(limits Limits) error {
+	memoryMax := "max"
+	if limits.MemoryMax > 0 {
+		memoryMax = strconv.FormatInt(limits.MemoryMax, 10)
+	}
+	cpuMax := fmt.Sprintf("max %d", cpuPeriod)
+	if limits.CPUMax > 0 {
+		quota := max(int64(limits.CPUMax*cpuPeriod), 1000) // kernel minimum is 1ms
+		cpuMax = fmt.Sprintf("%d %d", quota, cpuPeriod)
+	}
+	pidsMax := "max"
+	if limits.PidsMax > 0 {
+		pidsMax = strconv.Itoa(limits.PidsMax)
+	}
+	ioWeight := "100"
+	if limits.IOWeight > 0 {
+		ioWeight = strconv.Itoa(limits.IOWeight)
+	}
+
+	for _, setting := range []struct {
+		file  string
+		value string
+		set   bool
+	}{
+		{"memory.max", memoryMax, limits.MemoryMax > 0},
+		{"cpu.max", cpuMax, limits.CPUMax > 0},
+		{"pids.max", pidsMax, limits.PidsMax > 0},
+		{"io.weight", ioWeight, limits.IOWeight > 0},
+	} {
+		if !setting.set && !g.exists(setting.file) {
+			// Controller not enabled, so there's nothing to reset.
+			continue
+		}
+		if err := g.write(setting.file, setting.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats holds resource usage statistics for a group. Statistics whose
+// controller isn't enabled are zero.
+type Stats struct {
+	// MemoryCurrent is the memory currently used, in bytes.
+	MemoryCurrent int64
+
+	// CPUUsage is the total CPU time used.
+	CPUUsage time.Duration
+
+	// PidsCurrent is the number of processes and threads.
+	PidsCurrent int64
+}
+
+// Stats returns the group's resource usage statistics.
+func (g *Group) Stats() (*Stats, error) {
+	stats := &Stats{}
+	var err error
+	stats.MemoryCurrent, err = g.readInt("memory.current")
+	if err != nil {
+		return nil, err
+	}
+	stats.PidsCurrent, err = g.readInt("pids.current")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(g.path, "cpu.stat"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "usage_usec ")
+		if !ok {
+			continue
+		}
+		usec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU usage %q in %s", value, g.path)
+		}
+		stats.CPUUsage = time.Duration(usec) * time.Microsecond
+	}
+	return stats, nil
+}
+
+// readInt reads an integer from the given file, returning 0 if the file
+// doesn't exist.
+func (g *Group) readInt(file string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join(g.path, file))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s", value, filepath.Join(g.path, file))
+	}
+	return n, nil
+}
+
+func (g *Group) exists(file string) bool {
+	_, err := os.Stat(filepath.Join(g.path, file))
+	return err == nil
+}
+
+func (g *Group) write(file, value string) error {
+	return os.WriteFile(filepath.Join(g.path, file), []byte(value), 0o644)
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cgroup_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/cgroup"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type cgroupSuite struct {
+	mount   string
+	self    string
+	restore func()
+}
+
+var _ = Suite(&cgroupSuite{})
+
+func (s *cgroupSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+	s.mount = filepath.Join(dir, "cgroup")
+	s.self = filepath.Join(dir, "self-cgroup")
+	c.Assert(os.MkdirAll(filepath.Join(s.mount, "system.slice", "pebble.service"), 0o755), IsNil)
+	writeFile(c, filepath.Join(s.mount, "cgroup.controllers"), "cpu io memory pids\n")
+	writeFile(c, s.self, "0::/system.slice/pebble.service\n")
+	s.restore = cgroup.FakePaths(s.mount, s.self)
+}
+
+func (s *cgroupSuite) TearDownTest(c *C) {
+	s.restore()
+}
+
+func writeFile(c *C, path, content string) {
+	c.Assert(os.WriteFile(path, []byte(content), 0o644), IsNil)
+}
+
+func readFile(c *C, path string) string {
+	data, err := os.ReadFile(path)
+	c.Assert(err, IsNil)
+	return string(data)
+}
+
+func (s *cgroupSuite) TestSelf(c *C) {
+	group, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	c.Check(group.Path(), Equals, filepath.Join(s.mount, "system.slice", "pebble.service"))
+}
+
+func (s *cgroupSuite) TestSelfHybrid(c *C) {
+	// On a cgroup v1 or hybrid system, there's no cgroup v2 at the mount
+	// point even though /proc/self/cgroup has a v2 entry.
+	c.Assert(os.Remove(filepath.Join(s.mount, "cgroup.controllers")), IsNil)
+	_, err := cgroup.Self()
+	c.Check(err, Equals, cgroup.ErrUnavailable)
+}
+
+func (s *cgroupSuite) TestSelfNoV2Entry(c *C) {
+	writeFile(c, s.self, "12:pids:/foo\n1:name=systemd:/foo\n")
+	_, err := cgroup.Self()
+	c.Check(err, Equals, cgroup.ErrUnavailable)
+}
+
+func (s *cgroupSuite) TestChildAndProcesses(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	writeFile(c, filepath.Join(self.Path(), "cgroup.procs"), "1\n42\n")
+	pids, err := self.Processes()
+	c.Assert(err, IsNil)
+	c.Check(pids, DeepEquals, []int{1, 42})
+
+	child, err := self.Child("services")
+	c.Assert(err, IsNil)
+	c.Check(child.Path(), Equals, filepath.Join(self.Path(), "services"))
+	_, err = os.Stat(child.Path())
+	c.Check(err, IsNil)
+
+	// Getting an existing child is fine.
+	_, err = self.Child("services")
+	c.Assert(err, IsNil)
+
+	err = child.AddProcess(42)
+	c.Assert(err, IsNil)
+	c.Check(readFile(c, filepath.Join(child.Path(), "cgroup.procs")), Equals, "42")
+}
+
+func (s *cgroupSuite) TestControllers(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	writeFile(c, filepath.Join(self.Path(), "cgroup.controllers"), "cpu memory pids\n")
+	controllers, err := self.Controllers()
+	c.Assert(err, IsNil)
+	c.Check(controllers, DeepEquals, []string{"cpu", "memory", "pids"})
+
+	err = self.EnableControllers([]string{"cpu", "memory"})
+	c.Assert(err, IsNil)
+	c.Check(readFile(c, filepath.Join(self.Path(), "cgroup.subtree_control")), Equals, "+cpu +memory")
+}
+
+func (s *cgroupSuite) TestSetLimits(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+
+	err = group.SetLimits(cgroup.Limits{
+		MemoryMax: 64 << 20,
+		CPUMax:    0.5,
+		PidsMax:   100,
+		IOWeight:  200,
+	})
+	c.Assert(err, IsNil)
+	c.Check(readFile(c, filepath.Join(group.Path(), "memory.max")), Equals, "67108864")
+	c.Check(readFile(c, filepath.Join(group.Path(), "cpu.max")), Equals, "50000 100000")
+	c.Check(readFile(c, filepath.Join(group.Path(), "pids.max")), Equals, "100")
+	c.Check(readFile(c, filepath.Join(group.Path(), "io.weight")), Equals, "200")
+
+	// Limits that are no longer set are reset.
+	err = group.SetLimits(cgroup.Limits{PidsMax: 10})
+	c.Assert(err, IsNil)
+	c.Check(readFile(c, filepath.Join(group.Path(), "memory.max")), Equals, "max")
+	c.Check(readFile(c, filepath.Join(group.Path(), "cpu.max")), Equals, "max 100000")
+	c.Check(readFile(c, filepath.Join(group.Path(), "pids.max")), Equals, "10")
+	c.Check(readFile(c, filepath.Join(group.Path(), "io.weight")), Equals, "100")
+}
+
+func (s *cgroupSuite) TestSetLimitsOnlyEnabledControllers(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+
+	// Files of controllers that aren't enabled are left alone when the
+	// corresponding limit isn't set.
+	err = group.SetLimits(cgroup.Limits{})
+	c.Assert(err, IsNil)
+	entries, err := os.ReadDir(group.Path())
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+}
+
+func (s *cgroupSuite) TestStats(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+
+	stats, err := group.Stats()
+	c.Assert(err, IsNil)
+	c.Check(*stats, Equals, cgroup.Stats{})
+
+	writeFile(c, filepath.Join(group.Path(), "memory.current"), "1048576\n")
+	writeFile(c, filepath.Join(group.Path(), "pids.current"), "3\n")
+	writeFile(c, filepath.Join(group.Path(), "cpu.stat"), "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\n")
+	stats, err = group.Stats()
+	c.Assert(err, IsNil)
+	c.Check(*stats, Equals, cgroup.Stats{
+		MemoryCurrent: 1048576,
+		CPUUsage:      1500 * time.Millisecond,
+		PidsCurrent:   3,
+	})
+
+	writeFile(c, filepath.Join(group.Path(), "pids.current"), "bad\n")
+	_, err = group.Stats()
+	c.Check(err, ErrorMatches, `invalid value "bad" in .*/svc/pids.current`)
+}
+
+func (s *cgroupSuite) TestSignal(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+	child, err := group.Child("sub")
+	c.Assert(err, IsNil)
+
+	// Processes in sub-groups are signalled too.
+	var cmds []*exec.Cmd
+	for _, g := range []*cgroup.Group{group, child} {
+		cmd := exec.Command("sleep", "10")
+		c.Assert(cmd.Start(), IsNil)
+		cmds = append(cmds, cmd)
+		writeFile(c, filepath.Join(g.Path(), "cgroup.procs"), strconv.Itoa(cmd.Process.Pid)+"\n")
+	}
+
+	err = group.Signal(syscall.SIGTERM)
+	c.Assert(err, IsNil)
+	for _, cmd := range cmds {
+		err := cmd.Wait()
+		c.Check(err, ErrorMatches, "signal: terminated")
+	}
+}
+
+func (s *cgroupSuite) TestSignalKill(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+	writeFile(c, filepath.Join(group.Path(), "cgroup.kill"), "")
+
+	err = group.Signal(syscall.SIGKILL)
+	c.Assert(err, IsNil)
+	c.Check(readFile(c, filepath.Join(group.Path(), "cgroup.kill")), Equals, "1")
+}
+
+func (s *cgroupSuite) TestPopulated(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	group, err := self.Child("svc")
+	c.Assert(err, IsNil)
+
+	writeFile(c, filepath.Join(group.Path(), "cgroup.procs"), "")
+	populated, err := group.Populated()
+	c.Assert(err, IsNil)
+	c.Check(populated, Equals, false)
+
+	writeFile(c, filepath.Join(group.Path(), "cgroup.events"), "populated 1\nfrozen 0\n")
+	populated, err = group.Populated()
+	c.Assert(err, IsNil)
+	c.Check(populated, Equals, true)
+
+	writeFile(c, filepath.Join(group.Path(), "cgroup.events"), "populated 0\nfrozen 0\n")
+	populated, err = group.Populated()
+	c.Assert(err, IsNil)
+	c.Check(populated, Equals, false)
+}
+
+func (s *cgroupSuite) TestOpen(c *C) {
+	self, err := cgroup.Self()
+	c.Assert(err, IsNil)
+	f, err := self.Open()
+	c.Assert(err, IsNil)
+	defer f.Close()
+	info, err := f.Stat()
+	c.Assert(err, IsNil)
+	c.Check(info.IsDir(), Equals, true)
+}
+
+func (s *cgroupSuite) TestFakeCloneInto(c *C) {
+	restore := cgroup.FakeCloneInto(false)
+	c.Check(cgroup.CanCloneInto(), Equals, false)
+	restore()
+	restore = cgroup.FakeCloneInto(true)
+	defer restore()
+	c.Check(cgroup.CanCloneInto(), Equals, true)
+}
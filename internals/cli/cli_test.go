@@ -15,6 +15,8 @@ import (
 
 	"github.com/canonical/pebble/cmd"
 	"github.com/canonical/pebble/internals/cli"
+	"github.com/canonical/pebble/internals/overlord/checkstate"
+	"github.com/canonical/pebble/internals/overlord/metricstate"
 	"github.com/canonical/pebble/internals/overlord/pairingstate"
 	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/testutil"
@@ -79,6 +81,8 @@ func (s *BasePebbleSuite) TearDownTest(c *C) {
 
 	plan.UnregisterSectionExtension(workloads.WorkloadsField)
 	plan.UnregisterSectionExtension(pairingstate.PairingField)
+	plan.UnregisterSectionExtension(metricstate.MetricTargetsField)
+	plan.UnregisterSectionExtension(checkstate.HealthLevelsField)
 
 	s.BaseTest.TearDownTest(c)
 }
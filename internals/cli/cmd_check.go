@@ -17,6 +17,7 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/canonical/go-flags"
 	"gopkg.in/yaml.v3"
@@ -27,12 +28,16 @@ import (
 const cmdCheckSummary = "Query the details of a configured health check"
 const cmdCheckDescription = `
 The check command shows details for a single check in YAML format.
+
+With --history, the output includes the time, duration, and result of the
+check's most recent runs, oldest first.
 `
 
 type cmdCheck struct {
 	client *client.Client
 
 	Refresh bool `long:"refresh"`
+	History bool `long:"history"`
 
 	Positional struct {
 		Check string `positional-arg-name:"<check>" required:"1"`
@@ -50,6 +55,16 @@ type checkInfo struct {
 	ChangeID  string `yaml:"change-id,omitempty"`
 	Error     string `yaml:"error,omitempty"`
 	Logs      string `yaml:"logs,omitempty"`
+
+	History []checkRunInfo `yaml:"history,omitempty"`
+}
+
+type checkRunInfo struct {
+	Time     time.Time `yaml:"time"`
+	Duration string    `yaml:"duration"`
+	Result   string    `yaml:"result"`
+	Error    string    `yaml:"error,omitempty"`
+	Details  string    `yaml:"details,omitempty"`
 }
 
 func init() {
@@ -59,6 +74,7 @@ func init() {
 		Description: cmdCheckDescription,
 		ArgsHelp: map[string]string{
 			"--refresh": "Run the check immediately",
+			"--history": "Show the results of the check's most recent runs",
 		},
 		New: func(opts *CmdOptions) flags.Commander {
 			return &cmdCheck{client: opts.Client}
@@ -76,9 +92,24 @@ func checkInfoFromClient(check client.CheckInfo) checkInfo {
 		Failures:  check.Failures,
 		Threshold: check.Threshold,
 		ChangeID:  check.ChangeID,
+		History:   checkHistoryFromClient(check.History),
 	}
 }
 
+func checkHistoryFromClient(history []client.CheckRun) []checkRunInfo {
+	var runs []checkRunInfo
+	for _, run := range history {
+		runs = append(runs, checkRunInfo{
+			Time:     run.Time,
+			Duration: run.Duration.String(),
+			Result:   string(run.Result),
+			Error:    run.Error,
+			Details:  run.Details,
+		})
+	}
+	return runs
+}
+
 func (cmd *cmdCheck) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
@@ -96,9 +127,11 @@ func (cmd *cmdCheck) Execute(args []string) error {
 
 		info = checkInfoFromClient(res.Info)
 		info.Error = res.Error
-	} else {
+	}
+	if !cmd.Refresh || cmd.History {
 		opts := client.ChecksOptions{
-			Names: []string{cmd.Positional.Check},
+			Names:   []string{cmd.Positional.Check},
+			History: cmd.History,
 		}
 		checks, err := cmd.client.Checks(&opts)
 		if err != nil {
@@ -107,7 +140,13 @@ func (cmd *cmdCheck) Execute(args []string) error {
 		if len(checks) == 0 {
 			return fmt.Errorf("cannot find check %q", cmd.Positional.Check)
 		}
-		info = checkInfoFromClient(*checks[0])
+		if cmd.Refresh {
+			// Keep the result of the refresh, and add the history
+			// including that run.
+			info.History = checkHistoryFromClient(checks[0].History)
+		} else {
+			info = checkInfoFromClient(*checks[0])
+		}
 	}
 
 	if info.Failures > 0 || info.Error != "" {
@@ -51,6 +51,44 @@ change-id: "1"
 	c.Check(s.Stderr(), Equals, "")
 }
 
+func (s *PebbleSuite) TestCheckHistory(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "GET")
+		c.Assert(r.URL.Path, Equals, "/v1/checks")
+		c.Assert(r.URL.Query(), DeepEquals, url.Values{"names": {"chk1"}, "history": {"true"}})
+		fmt.Fprint(w, `
+{
+    "type": "sync",
+    "status-code": 200,
+    "result": [{"name": "chk1", "startup": "enabled", "status": "up", "successes": 1, "threshold": 3, "change-id": "1", "history": [
+        {"time": "2026-10-16T14:00:00Z", "duration": "3s", "result": "failure", "error": "check timed out after 3s"},
+        {"time": "2026-10-16T14:00:10Z", "duration": "12ms", "result": "success"}
+    ]}]
+}`)
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"check", "--history", "chk1"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, `
+name: chk1
+startup: enabled
+status: up
+successes: 1
+failures: 0
+threshold: 3
+change-id: "1"
+history:
+    - time: 2026-10-16T14:00:00Z
+      duration: 3s
+      result: failure
+      error: check timed out after 3s
+    - time: 2026-10-16T14:00:10Z
+      duration: 12ms
+      result: success
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
 func (s *PebbleSuite) TestCheckFailure(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -34,8 +34,7 @@ arguments.
 type cmdChecks struct {
 	client *client.Client
 
-	//lint:ignore SA5008 "choice" tag is intentionally duplicated
-	Level      string `long:"level" choice:"alive" choice:"ready"`
+	Level      string `long:"level"`
 	Positional struct {
 		Checks []string `positional-arg-name:"<check>"`
 	} `positional-args:"yes"`
@@ -47,7 +46,7 @@ func init() {
 		Summary:     cmdChecksSummary,
 		Description: cmdChecksDescription,
 		ArgsHelp: map[string]string{
-			"--level": "Check level to filter for",
+			"--level": "Check level to filter for (alive, ready, or a custom level)",
 		},
 		New: func(opts *CmdOptions) flags.Commander {
 			return &cmdChecks{client: opts.Client}
@@ -33,15 +33,14 @@ an exit code 1 if at least one of the requested checks are unhealthy.
 type cmdHealth struct {
 	client *client.Client
 
-	//lint:ignore SA5008 "choice" tag is intentionally duplicated
-	Level      string `long:"level" choice:"alive" choice:"ready"`
+	Level      string `long:"level"`
 	Positional struct {
 		Checks []string `positional-arg-name:"<check>"`
 	} `positional-args:"yes"`
 }
 
 var cmdHealthArgsHelp = map[string]string{
-	"--level": "Check level to filter for",
+	"--level": "Check level to filter for (alive, ready, or a custom level)",
 }
 
 func init() {
@@ -90,12 +90,43 @@ func (s *PebbleSuite) TestHealthSpecificChecks(c *check.C) {
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *PebbleSuite) TestHealthCustomLevel(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, check.Equals, "GET")
+		c.Assert(r.URL.Path, check.Equals, "/v1/health")
+		c.Assert(r.URL.Query(), check.DeepEquals, url.Values{
+			"level": {"replicas"},
+		})
+		fmt.Fprintf(w, `{
+			"type": "sync",
+			"status-code": 200,
+			"result": {"healthy": true}
+		}`)
+	})
+
+	restore := fakeArgs("pebble", "health", "--level", "replicas")
+	defer restore()
+
+	exitCode := cli.PebbleMain()
+	c.Check(exitCode, check.Equals, 0)
+	c.Check(s.Stdout(), check.Equals, "healthy\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *PebbleSuite) TestHealthBadLevel(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"type": "error",
+			"status-code": 400,
+			"result": {"message": "level must be \"alive\", \"ready\", or a level defined in \"health-levels\""}
+		}`)
+	})
+
 	restore := fakeArgs("pebble", "health", "--level", "foo")
 	defer restore()
 
 	exitCode := cli.PebbleMain()
 	c.Check(exitCode, check.Equals, 1)
 	c.Check(s.Stdout(), check.Equals, "")
-	c.Check(s.Stderr(), check.Matches, "error: Invalid value .* Allowed values are: alive or ready\n")
+	c.Check(s.Stderr(), check.Equals, `error: level must be "alive", "ready", or a level defined in "health-levels"`+"\n")
 }
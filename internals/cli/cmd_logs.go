@@ -21,16 +21,30 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/canonical/go-flags"
 
 	"github.com/canonical/pebble/client"
+	"github.com/canonical/pebble/internals/servicelog"
 )
 
 const cmdLogsSummary = "Fetch service logs"
 const cmdLogsDescription = `
 The logs command fetches buffered logs from the given services (or all services
 if none are specified) and displays them in chronological order.
+
+Services with a log file configured also have logs from before the buffer
+started, including after the daemon restarts. Use --since to show logs
+from rotated log files too.
+
+The --since, --until and --match options filter logs in the daemon, so they
+also apply when following, and -n counts the logs after filtering.
+
+Logs from services with a structured log-format ("json" or "logfmt") are
+shown with their level and attributes. Use --format=json to get these as
+separate fields.
 `
 
 type cmdLogs struct {
@@ -39,6 +53,9 @@ type cmdLogs struct {
 	Follow     bool   `short:"f" long:"follow"`
 	Format     string `long:"format"`
 	N          string `short:"n"`
+	Since      string `long:"since"`
+	Until      string `long:"until"`
+	Match      string `long:"match"`
 	Positional struct {
 		Services []string `positional-arg-name:"<service>"`
 	} `positional-args:"yes"`
@@ -52,7 +69,10 @@ func init() {
 		ArgsHelp: map[string]string{
 			"--follow": "Follow (tail) logs for given services until Ctrl-C is\npressed. If no services are specified, show logs from\nall services running when the command starts.",
 			"--format": "Output format: \"text\" (default) or \"json\" (JSON lines).",
-			"-n":       "Number of logs to show (before following); defaults to 30.\nIf 'all', show all buffered logs.",
+			"-n":       "Number of logs to show (before following); defaults to 30, or all logs with --since. If 'all', show all logs.",
+			"--since":  "Only show logs since this time, given as an RFC 3339\ntimestamp or as a duration before now, such as \"1h\"",
+			"--until":  "Only show logs until this time, in the same format as\n--since. When following, stop at the first later log.",
+			"--match":  "Only show logs whose message matches this regular\nexpression (RE2 syntax)",
 		},
 		New: func(opts *CmdOptions) flags.Commander {
 			return &cmdLogs{client: opts.Client}
@@ -65,10 +85,28 @@ const (
 )
 
 func (cmd *cmdLogs) Execute(args []string) error {
+	var since, until time.Time
+	if cmd.Since != "" {
+		var err error
+		since, err = parseLogTime("since", cmd.Since)
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.Until != "" {
+		var err error
+		until, err = parseLogTime("until", cmd.Until)
+		if err != nil {
+			return err
+		}
+	}
+
 	var n int
 	switch cmd.N {
 	case "":
-		n = 30
+		if since.IsZero() {
+			n = 30
+		}
 	case "all":
 		n = -1
 	default:
@@ -83,8 +121,15 @@ func (cmd *cmdLogs) Execute(args []string) error {
 	switch cmd.Format {
 	case "", "text":
 		writeLog = func(entry client.LogEntry) error {
+			message := entry.Message
+			if entry.Level != "" {
+				message = strings.ToUpper(entry.Level) + " " + message
+			}
+			if len(entry.Attributes) > 0 {
+				message += " " + servicelog.FormatAttributes(entry.Attributes)
+			}
 			_, err := fmt.Fprintf(Stdout, "%s [%s] %s\n",
-				entry.Time.Format(logTimeFormat), entry.Service, entry.Message)
+				entry.Time.Format(logTimeFormat), entry.Service, message)
 			return err
 		}
 
@@ -103,6 +148,9 @@ func (cmd *cmdLogs) Execute(args []string) error {
 		WriteLog: writeLog,
 		Services: cmd.Positional.Services,
 		N:        n,
+		Since:    since,
+		Until:    until,
+		Match:    cmd.Match,
 	}
 	var err error
 	if cmd.Follow {
@@ -115,3 +163,15 @@ func (cmd *cmdLogs) Execute(args []string) error {
 	}
 	return err
 }
+
+// parseLogTime parses an RFC 3339 timestamp, or a duration before now.
+func parseLogTime(name, s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf(`expected %s to be a timestamp or a duration such as "1h", not %q`, name, s)
+	}
+	return time.Now().Add(-d), nil
+}
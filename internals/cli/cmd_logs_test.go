@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -35,6 +36,7 @@ func (s *PebbleSuite) TestLogsText(c *C) {
 {"time":"2021-05-03T03:55:49.360994155Z","service":"thing","message":"log 1"}
 {"time":"2021-05-03T03:55:49.654334232Z","service":"snappass","message":"log two"}
 {"time":"2021-05-03T03:55:50.076800988Z","service":"thing","message":"the third"}
+{"time":"2021-05-03T03:55:51Z","service":"api","message":"request failed","level":"error","attributes":{"path":"/foo bar","status":"500"}}
 `[1:])
 	})
 	rest, err := cli.ParserForTest().ParseArgs([]string{"logs"})
@@ -44,6 +46,7 @@ func (s *PebbleSuite) TestLogsText(c *C) {
 2021-05-03T03:55:49.360Z [thing] log 1
 2021-05-03T03:55:49.654Z [snappass] log two
 2021-05-03T03:55:50.076Z [thing] the third
+2021-05-03T03:55:51.000Z [api] ERROR request failed path="/foo bar" status=500
 `[1:])
 	c.Check(s.Stderr(), Equals, "")
 }
@@ -59,6 +62,7 @@ func (s *PebbleSuite) TestLogsJSON(c *C) {
 {"time":"2021-05-03T03:55:49.360Z","service":"thing","message":"log 1"}
 {"time":"2021-05-03T03:55:49.654Z","service":"snappass","message":"log two"}
 {"time":"2021-05-03T03:55:50.076800988Z","service":"thing","message":"the third"}
+{"time":"2021-05-03T03:55:51Z","service":"api","message":"request failed","level":"error","attributes":{"status":"500"}}
 `[1:])
 	})
 	rest, err := cli.ParserForTest().ParseArgs([]string{"logs", "--format", "json"})
@@ -68,6 +72,7 @@ func (s *PebbleSuite) TestLogsJSON(c *C) {
 {"time":"2021-05-03T03:55:49.36Z","service":"thing","message":"log 1"}
 {"time":"2021-05-03T03:55:49.654Z","service":"snappass","message":"log two"}
 {"time":"2021-05-03T03:55:50.076800988Z","service":"thing","message":"the third"}
+{"time":"2021-05-03T03:55:51Z","service":"api","message":"request failed","level":"error","attributes":{"status":"500"}}
 `[1:])
 	c.Check(s.Stderr(), Equals, "")
 }
@@ -128,6 +133,71 @@ func (s *PebbleSuite) TestLogsInvalidNumber(c *C) {
 	c.Assert(rest, HasLen, 1)
 }
 
+func (s *PebbleSuite) TestLogsSince(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v1/logs")
+		c.Check(r.URL.Query(), DeepEquals, url.Values{
+			"since": []string{"2021-05-03T03:55:49Z"},
+		})
+		fmt.Fprint(w, `
+{"time":"2021-05-03T03:55:49.654334232Z","service":"snappass","message":"log two"}
+`[1:])
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"logs", "--since", "2021-05-03T03:55:49Z"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, `
+2021-05-03T03:55:49.654Z [snappass] log two
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *PebbleSuite) TestLogsSinceDuration(c *C) {
+	start := time.Now()
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("n"), Equals, "5")
+		since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+		c.Assert(err, IsNil)
+		c.Check(since.Before(start.Add(-time.Hour).Add(time.Second)), Equals, true)
+		c.Check(since.After(start.Add(-time.Hour).Add(-time.Second)), Equals, true)
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"logs", "--since", "1h", "-n5"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *PebbleSuite) TestLogsUntilMatch(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query(), DeepEquals, url.Values{
+			"follow": []string{"true"},
+			"n":      []string{"30"},
+			"until":  []string{"2021-05-03T04:00:00Z"},
+			"match":  []string{"error|warning"},
+		})
+		fmt.Fprint(w, `
+{"time":"2021-05-03T03:55:49.654334232Z","service":"snappass","message":"warning: two"}
+`[1:])
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"logs", "-f", "--until", "2021-05-03T04:00:00Z", "--match", "error|warning"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, `
+2021-05-03T03:55:49.654Z [snappass] warning: two
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *PebbleSuite) TestLogsInvalidSince(c *C) {
+	_, err := cli.ParserForTest().ParseArgs([]string{"logs", "--since", "yesterday"})
+	c.Assert(err, ErrorMatches, `expected since to be a timestamp or a duration such as "1h", not "yesterday"`)
+
+	_, err = cli.ParserForTest().ParseArgs([]string{"logs", "--until=-1h"})
+	c.Assert(err, ErrorMatches, `expected until to be a timestamp or a duration such as "1h", not "-1h"`)
+}
+
 func (s *PebbleSuite) TestLogsFollow(c *C) {
 	// NOTE: doesn't test actual following behavior -- that's tested in client
 	// tests. This just ensures ?follow=true is passed through.
@@ -30,6 +30,10 @@ const cmdLsSummary = "List path contents"
 const cmdLsDescription = `
 The ls command lists entries in the filesystem at the specified path. A glob pattern
 may be specified for the last path element.
+
+With -R, the contents of subdirectories are listed too, and entries are shown
+with their path relative to the specified path. A glob pattern then matches the
+names of entries at any depth.
 `
 
 type cmdLs struct {
@@ -38,6 +42,8 @@ type cmdLs struct {
 	timeMixin
 	Directory  bool `short:"d"`
 	LongFormat bool `short:"l"`
+	Recursive  bool `short:"R"`
+	MaxDepth   int  `long:"max-depth"`
 	Positional struct {
 		Path string `positional-arg-name:"<path>"`
 	} `positional-args:"yes" required:"yes"`
@@ -49,8 +55,10 @@ func init() {
 		Summary:     cmdLsSummary,
 		Description: cmdLsDescription,
 		ArgsHelp: merge(timeArgsHelp, map[string]string{
-			"-d": "List matching entries themselves, not directory contents",
-			"-l": "Use a long listing format",
+			"-d":          "List matching entries themselves, not directory contents",
+			"-l":          "Use a long listing format",
+			"-R":          "List subdirectory contents recursively",
+			"--max-depth": "Maximum number of directory levels to list with -R",
 		}),
 		New: func(opts *CmdOptions) flags.Commander {
 			return &cmdLs{client: opts.Client}
@@ -68,18 +76,39 @@ func (cmd *cmdLs) Execute(args []string) error {
 		return err
 	}
 
+	if cmd.MaxDepth != 0 && !cmd.Recursive {
+		return errors.New("cannot use --max-depth without -R")
+	}
+	if cmd.MaxDepth < 0 {
+		return errors.New("--max-depth must be a positive integer")
+	}
+
 	files, err := cmd.client.ListFiles(&client.ListFilesOptions{
-		Path:    path,
-		Pattern: pattern,
-		Itself:  cmd.Directory,
+		Path:      path,
+		Pattern:   pattern,
+		Itself:    cmd.Directory,
+		Recursive: cmd.Recursive,
+		MaxDepth:  cmd.MaxDepth,
 	})
 	if err != nil {
 		return err
 	}
 
+	// When listing recursively, show paths relative to the listed directory.
+	prefix := pathpkg.Clean(path)
+	if prefix != "/" {
+		prefix += "/"
+	}
+
 	w := tabWriter()
 	defer w.Flush()
 	for _, fi := range files {
+		name := fi.Name()
+		if cmd.Recursive {
+			if rel, ok := strings.CutPrefix(fi.Path(), prefix); ok {
+				name = rel
+			}
+		}
 		if cmd.LongFormat {
 			var size string
 			if fi.Mode().IsRegular() {
@@ -87,9 +116,9 @@ func (cmd *cmdLs) Execute(args []string) error {
 			} else {
 				size = "-"
 			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%6s\t%s\t%s\n", fi.Mode().String(), fi.User(), fi.Group(), size, cmd.fmtTime(fi.ModTime()), fi.Name())
+			fmt.Fprintf(w, "%s\t%s\t%s\t%6s\t%s\t%s\n", fi.Mode().String(), fi.User(), fi.Group(), size, cmd.fmtTime(fi.ModTime()), name)
 		} else {
-			fmt.Fprintln(w, fi.Name())
+			fmt.Fprintln(w, name)
 		}
 	}
 
@@ -145,3 +145,50 @@ func (s *PebbleSuite) TestLsInvalidPattern(c *C) {
 	c.Check(s.Stdout(), Equals, "")
 	c.Check(s.Stderr(), Equals, "")
 }
+
+func (s *PebbleSuite) TestLsRecursive(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "GET")
+		c.Assert(r.URL.Path, Equals, "/v1/files")
+		c.Assert(r.URL.Query(), DeepEquals, url.Values{
+			"action":    {"list"},
+			"path":      {"/etc/"},
+			"pattern":   {"*.conf"},
+			"recursive": {"true"},
+			"max-depth": {"2"},
+		})
+		fmt.Fprintln(w, `{
+	"type": "sync",
+	"result": [
+		{
+			"path": "/etc/app.conf",
+			"name": "app.conf",
+			"type": "file",
+			"permissions": "644",
+			"last-modified": "2016-04-21T01:02:03Z"
+		},
+		{
+			"path": "/etc/app/sub.conf",
+			"name": "sub.conf",
+			"type": "file",
+			"permissions": "644",
+			"last-modified": "2016-04-21T01:02:03Z"
+		}
+	]
+}`)
+	})
+
+	rest, err := cli.ParserForTest().ParseArgs([]string{"ls", "-R", "--max-depth", "2", "/etc/*.conf"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, "app.conf\napp/sub.conf\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *PebbleSuite) TestLsMaxDepthWithoutRecursive(c *C) {
+	rest, err := cli.ParserForTest().ParseArgs([]string{"ls", "--max-depth", "2", "/etc"})
+	c.Assert(err, ErrorMatches, "cannot use --max-depth without -R")
+	c.Assert(rest, HasLen, 1)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "")
+}
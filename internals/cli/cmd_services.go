@@ -16,8 +16,11 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/canonical/go-flags"
+	"github.com/canonical/x-go/strutil/quantity"
 
 	"github.com/canonical/pebble/client"
 )
@@ -26,12 +29,16 @@ const cmdServicesSummary = "Query the status of configured services"
 const cmdServicesDescription = `
 The services command lists status information about the services specified, or
 about all services if none are specified.
+
+With --verbose, it also shows the resources used by each running service's
+processes: CPU time, resident memory, open file descriptors, and threads.
 `
 
 type cmdServices struct {
 	client *client.Client
 
 	timeMixin
+	Verbose    bool `long:"verbose"`
 	Positional struct {
 		Services []string `positional-arg-name:"<service>"`
 	} `positional-args:"yes"`
@@ -42,7 +49,9 @@ func init() {
 		Name:        "services",
 		Summary:     cmdServicesSummary,
 		Description: cmdServicesDescription,
-		ArgsHelp:    timeArgsHelp,
+		ArgsHelp: merge(timeArgsHelp, map[string]string{
+			"--verbose": "Show the resource usage of running services",
+		}),
 		New: func(opts *CmdOptions) flags.Commander {
 			return &cmdServices{client: opts.Client}
 		},
@@ -56,6 +65,7 @@ func (cmd *cmdServices) Execute(args []string) error {
 
 	opts := client.ServicesOptions{
 		Names: cmd.Positional.Services,
+		Usage: cmd.Verbose,
 	}
 	services, err := cmd.client.Services(&opts)
 	if err != nil {
@@ -73,14 +83,58 @@ func (cmd *cmdServices) Execute(args []string) error {
 	w := tabWriter()
 	defer w.Flush()
 
-	fmt.Fprintln(w, "Service\tStartup\tCurrent\tSince")
+	scheduled := false
+	for _, svc := range services {
+		if svc.Schedule != nil {
+			scheduled = true
+			break
+		}
+	}
+
+	headers := []string{"Service", "Startup", "Current", "Since"}
+	if scheduled {
+		headers = append(headers, "Next", "Last")
+	}
+	if cmd.Verbose {
+		headers = append(headers, "CPU", "RSS", "FDs", "Threads")
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
 
 	for _, svc := range services {
 		since := "-"
 		if !svc.CurrentSince.IsZero() {
 			since = cmd.fmtTime(svc.CurrentSince)
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", svc.Name, svc.Startup, svc.Current, since)
+		row := []string{svc.Name, string(svc.Startup), string(svc.Current), since}
+		if scheduled {
+			next, last := "-", "-"
+			if svc.Schedule != nil {
+				if !svc.Schedule.Next.IsZero() {
+					next = cmd.fmtTime(svc.Schedule.Next)
+				}
+				if !svc.Schedule.LastRun.IsZero() {
+					last = fmt.Sprintf("%s (%s)", svc.Schedule.LastResult, cmd.fmtTime(svc.Schedule.LastRun))
+				}
+			}
+			row = append(row, next, last)
+		}
+		if cmd.Verbose {
+			row = append(row, usageColumns(svc.Usage)...)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
 	}
 	return nil
 }
+
+// usageColumns formats a service's resource usage for the --verbose columns.
+func usageColumns(usage *client.ServiceUsage) []string {
+	if usage == nil {
+		return []string{"-", "-", "-", "-"}
+	}
+	return []string{
+		quantity.FormatDuration(usage.CPUSeconds),
+		quantity.FormatAmount(uint64(usage.RSSBytes), -1) + "B",
+		strconv.Itoa(usage.OpenFDs),
+		strconv.Itoa(usage.Threads),
+	}
+}
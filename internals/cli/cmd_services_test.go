@@ -51,6 +51,35 @@ svc3     enabled  backoff   -
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *PebbleSuite) TestServicesScheduled(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, check.Equals, "GET")
+		c.Assert(r.URL.Path, check.Equals, "/v1/services")
+		fmt.Fprint(w, `{
+    "type": "sync",
+    "status-code": 200,
+    "result": [
+		{"name": "svc1", "current": "active", "startup": "enabled", "current-since": "2022-04-28T17:05:23+12:00"},
+		{"name": "svc2", "current": "inactive", "startup": "disabled", "schedule": {
+			"schedule": "02:00", "next": "2022-04-29T02:00:00+12:00",
+			"last-run": "2022-04-27T02:00:00+12:00", "last-result": "succeeded"}},
+		{"name": "svc3", "current": "inactive", "startup": "disabled", "schedule": {
+			"schedule": "03:00", "next": "2022-04-29T03:00:00+12:00"}}
+	]
+}`)
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"services", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, `
+Service  Startup   Current   Since                      Next                       Last
+svc1     enabled   active    2022-04-28T17:05:23+12:00  -                          -
+svc2     disabled  inactive  -                          2022-04-29T02:00:00+12:00  succeeded (2022-04-27T02:00:00+12:00)
+svc3     disabled  inactive  -                          2022-04-29T03:00:00+12:00  -
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *PebbleSuite) TestPlanNoServices(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Assert(r.Method, check.Equals, "GET")
@@ -112,6 +141,32 @@ foo      enabled   inactive  -
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *PebbleSuite) TestServicesVerbose(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, check.Equals, "GET")
+		c.Assert(r.URL.Path, check.Equals, "/v1/services")
+		c.Assert(r.URL.Query(), check.DeepEquals, url.Values{"names": {""}, "usage": {"true"}})
+		fmt.Fprint(w, `{
+    "type": "sync",
+    "status-code": 200,
+    "result": [
+		{"name": "bar", "current": "active", "startup": "disabled", "current-since": "2022-04-28T17:05:23+12:00",
+			"usage": {"cpu-seconds": 83.5, "rss-bytes": 12582912, "open-fds": 12, "threads": 3}},
+		{"name": "foo", "current": "inactive", "startup": "enabled"}
+	]
+}`)
+	})
+	rest, err := cli.ParserForTest().ParseArgs([]string{"services", "--verbose", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, `
+Service  Startup   Current   Since                      CPU    RSS     FDs  Threads
+bar      disabled  active    2022-04-28T17:05:23+12:00  1m24s  12.6MB  12   3
+foo      enabled   inactive  -                          -      -       -    -
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *PebbleSuite) TestServicesFail(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Assert(r.Method, check.Equals, "GET")
@@ -131,6 +131,7 @@ var (
 	overlordServiceManager = (*overlord.Overlord).ServiceManager
 	overlordPlanManager    = (*overlord.Overlord).PlanManager
 	overlordCheckManager   = (*overlord.Overlord).CheckManager
+	overlordLogManager     = (*overlord.Overlord).LogManager
 
 	muxVars = mux.Vars
 )
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/canonical/x-go/strutil"
 
@@ -36,19 +37,32 @@ type checkInfo struct {
 	Failures  int    `json:"failures,omitempty"`
 	Threshold int    `json:"threshold"`
 	ChangeID  string `json:"change-id,omitempty"`
+
+	History []checkRun `json:"history,omitempty"`
+}
+
+type checkRun struct {
+	Time     time.Time `json:"time"`
+	Duration string    `json:"duration"`
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+	Details  string    `json:"details,omitempty"`
 }
 
 func v1GetChecks(c *Command, r *http.Request, _ *UserState) Response {
 	query := r.URL.Query()
 	level := plan.CheckLevel(query.Get("level"))
-	switch level {
-	case plan.UnsetLevel, plan.AliveLevel, plan.ReadyLevel:
-	default:
-		return BadRequest(`level must be "alive" or "ready"`)
+	if !checkstate.LevelDefined(getPlan(c.d.overlord), level) {
+		return BadRequest(`level must be "alive", "ready", or a level defined in "health-levels"`)
 	}
 
 	names := strutil.MultiCommaSeparatedList(query["names"])
 
+	historyStr := query.Get("history")
+	if historyStr != "" && historyStr != "true" && historyStr != "false" {
+		return BadRequest(`history must be "true" or "false"`)
+	}
+
 	checkMgr := c.d.overlord.CheckManager()
 	checks, err := checkMgr.Checks()
 	if err != nil {
@@ -61,6 +75,9 @@ func v1GetChecks(c *Command, r *http.Request, _ *UserState) Response {
 		namesMatch := len(names) == 0 || strutil.ListContains(names, check.Name)
 		if levelMatch && namesMatch {
 			info := checkInfoFromInternal(check)
+			if historyStr == "true" {
+				info.History = checkHistoryFromInternal(checkMgr.CheckHistory(check.Name))
+			}
 			infos = append(infos, info)
 		}
 	}
@@ -165,3 +182,20 @@ func checkInfoFromInternal(check *checkstate.CheckInfo) checkInfo {
 		ChangeID:  check.ChangeID,
 	}
 }
+
+func checkHistoryFromInternal(history []checkstate.CheckRun) []checkRun {
+	runs := make([]checkRun, len(history))
+	for i, run := range history {
+		runs[i] = checkRun{
+			Time:     run.Time,
+			Duration: run.Duration.String(),
+			Result:   "success",
+			Error:    run.Error,
+			Details:  run.Details,
+		}
+		if run.Error != "" {
+			runs[i].Result = "failure"
+		}
+	}
+	return runs
+}
@@ -120,7 +120,53 @@ func (s *apiSuite) TestChecksGetInvalidLevel(c *C) {
 	c.Check(rsp.Type, Equals, ResponseTypeError)
 	c.Check(rsp.Result, NotNil)
 	c.Check(body["result"], DeepEquals, map[string]any{
-		"message": `level must be "alive" or "ready"`,
+		"message": `level must be "alive", "ready", or a level defined in "health-levels"`,
+	})
+}
+
+func (s *apiSuite) TestChecksGetHistory(c *C) {
+	writeTestLayer(s.pebbleDir, `
+checks:
+    chk1:
+        override: replace
+        period: 10ms
+        timeout: 5ms
+        threshold: 100
+        exec:
+            command: /bin/sh -c "echo details >&2; exit 1"
+`)
+	s.daemon(c)
+	s.startOverlord()
+
+	var history []any
+	for start := time.Now(); len(history) < 2; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			c.Fatalf("timed out waiting for check history")
+		}
+		rsp, body := s.getChecks(c, "?names=chk1&history=true")
+		c.Assert(rsp.Status, Equals, 200)
+		results := body["result"].([]any)
+		c.Assert(results, HasLen, 1)
+		history, _ = results[0].(map[string]any)["history"].([]any)
+	}
+	run := history[0].(map[string]any)
+	c.Check(run["result"], Equals, "failure")
+	c.Check(run["error"], Equals, "exit status 1")
+	c.Check(run["details"], Equals, "details")
+	_, err := time.Parse(time.RFC3339, run["time"].(string))
+	c.Check(err, IsNil)
+	_, err = time.ParseDuration(run["duration"].(string))
+	c.Check(err, IsNil)
+
+	// History is only included when requested.
+	_, body := s.getChecks(c, "?names=chk1")
+	_, ok := body["result"].([]any)[0].(map[string]any)["history"]
+	c.Check(ok, Equals, false)
+
+	rsp, body := s.getChecks(c, "?history=foo")
+	c.Check(rsp.Status, Equals, 400)
+	c.Check(body["result"], DeepEquals, map[string]any{
+		"message": `history must be "true" or "false"`,
 	})
 }
 
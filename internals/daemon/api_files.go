@@ -15,6 +15,8 @@
 package daemon
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +33,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/canonical/x-go/randutil"
+
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/osutil"
 	"github.com/canonical/pebble/internals/osutil/sys"
@@ -56,12 +60,48 @@ func v1GetFiles(_ *Command, req *http.Request, user *UserState) Response {
 		if path == "" {
 			return BadRequest("must specify path")
 		}
-		pattern := query.Get("pattern")
+		opts := listFilesOptions{
+			pattern: query.Get("pattern"),
+		}
 		itself := query.Get("itself")
 		if itself != "true" && itself != "false" && itself != "" {
 			return BadRequest(`itself parameter must be "true" or "false"`)
 		}
-		return listFilesResponse(path, pattern, itself == "true")
+		opts.itself = itself == "true"
+		recursive := query.Get("recursive")
+		if recursive != "true" && recursive != "false" && recursive != "" {
+			return BadRequest(`recursive parameter must be "true" or "false"`)
+		}
+		opts.recursive = recursive == "true"
+		if opts.itself && opts.recursive {
+			return BadRequest("cannot use itself and recursive together")
+		}
+		if maxDepth := query.Get("max-depth"); maxDepth != "" {
+			if !opts.recursive {
+				return BadRequest("max-depth requires recursive")
+			}
+			n, err := strconv.Atoi(maxDepth)
+			if err != nil || n < 1 {
+				return BadRequest("max-depth must be a positive integer")
+			}
+			opts.maxDepth = n
+		}
+		digest := query.Get("digest")
+		if digest != "sha256" && digest != "" {
+			return BadRequest(`digest parameter must be "sha256"`)
+		}
+		opts.digest = digest != ""
+		return listFilesResponse(path, &opts)
+	case "stat":
+		paths := query["path"]
+		if len(paths) == 0 {
+			return BadRequest("must specify one or more paths")
+		}
+		digest := query.Get("digest")
+		if digest != "sha256" && digest != "" {
+			return BadRequest(`digest parameter must be "sha256"`)
+		}
+		return statFiles(paths, digest != "")
 	default:
 		return BadRequest("invalid action %q", action)
 	}
@@ -208,6 +248,7 @@ type fileInfoResult struct {
 	User         string   `json:"user"`
 	GroupID      *int     `json:"group-id"`
 	Group        string   `json:"group"`
+	SHA256       string   `json:"sha256,omitempty"`
 }
 
 type fileType string
@@ -284,11 +325,19 @@ func fileInfoToResult(fullPath string, info os.FileInfo, userCache, groupCache m
 	return result
 }
 
-func listFilesResponse(path, pattern string, itself bool) Response {
+type listFilesOptions struct {
+	pattern   string
+	itself    bool
+	recursive bool
+	maxDepth  int // zero means no limit
+	digest    bool
+}
+
+func listFilesResponse(path string, opts *listFilesOptions) Response {
 	if !pathpkg.IsAbs(path) {
 		return BadRequest("path must be absolute, got %q", path)
 	}
-	result, err := listFiles(path, pattern, itself)
+	result, err := listFiles(path, opts)
 	if err != nil {
 		return &resp{
 			Type:   ResponseTypeError,
@@ -299,49 +348,143 @@ func listFilesResponse(path, pattern string, itself bool) Response {
 	return SyncResponse(result)
 }
 
-func listFiles(path, pattern string, itself bool) ([]fileInfoResult, error) {
+func listFiles(path string, opts *listFilesOptions) ([]fileInfoResult, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var entries []os.DirEntry
-	var dir string
-	if !info.IsDir() || itself {
+	l := &fileLister{
+		opts:       opts,
+		result:     make([]fileInfoResult, 0), // want "no results" to be [], not nil
+		userCache:  make(map[int]string),
+		groupCache: make(map[int]string),
+	}
+	if !info.IsDir() || opts.itself {
 		// Info about a single file (or directory entry itself).
-		entries = []os.DirEntry{fs.FileInfoToDirEntry(info)}
-		dir = pathpkg.Dir(path)
+		err = l.add(pathpkg.Dir(path), fs.FileInfoToDirEntry(info))
 	} else {
-		// List an entire directory.
-		entries, err = os.ReadDir(path)
-		if err != nil {
-			return nil, err
-		}
-		dir = path
+		// List an entire directory (and its subdirectories, if recursive).
+		err = l.addDir(path, 1)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return l.result, nil
+}
 
-	result := make([]fileInfoResult, 0) // want "no results" to be [], not nil
-	userCache := make(map[int]string)
-	groupCache := make(map[int]string)
+type fileLister struct {
+	opts       *listFilesOptions
+	result     []fileInfoResult
+	userCache  map[int]string
+	groupCache map[int]string
+}
+
+// addDir adds the entries in dir, which is at the given depth below the
+// listed path. When listing recursively, the entries in subdirectories are
+// added after each subdirectory, up to the maximum depth. Symbolic links to
+// directories aren't followed, and subdirectories that can't be read (or
+// were removed while listing) are listed without their entries.
+func (l *fileLister) addDir(dir string, depth int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if depth > 1 {
+			return nil
+		}
+		return err
+	}
 	for _, entry := range entries {
-		name := entry.Name()
-		matched := true
-		if pattern != "" {
-			matched, err = pathpkg.Match(pattern, name)
-			if err != nil {
-				return nil, err
-			}
+		err = l.add(dir, entry)
+		if err != nil {
+			return err
 		}
-		if matched {
-			fullPath := pathpkg.Join(dir, name)
-			info, err = entry.Info()
+		if l.opts.recursive && entry.IsDir() && (l.opts.maxDepth == 0 || depth < l.opts.maxDepth) {
+			err = l.addDir(pathpkg.Join(dir, entry.Name()), depth+1)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			result = append(result, fileInfoToResult(fullPath, info, userCache, groupCache))
 		}
 	}
-	return result, nil
+	return nil
+}
+
+// add adds the entry in dir to the result if its name matches the pattern.
+func (l *fileLister) add(dir string, entry os.DirEntry) error {
+	name := entry.Name()
+	if l.opts.pattern != "" {
+		matched, err := pathpkg.Match(l.opts.pattern, name)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return nil
+		}
+	}
+	fullPath := pathpkg.Join(dir, name)
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+	result := fileInfoToResult(fullPath, info, l.userCache, l.groupCache)
+	if l.opts.digest && info.Mode().IsRegular() {
+		result.SHA256 = fileSHA256(fullPath)
+	}
+	l.result = append(l.result, result)
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of the file's contents,
+// or "" if the file can't be read.
+func fileSHA256(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Getting information about files
+
+type statFileResult struct {
+	*fileInfoResult
+	Path  string       `json:"path"`
+	Error *errorResult `json:"error,omitempty"`
+}
+
+func statFiles(paths []string, digest bool) Response {
+	result := make([]statFileResult, len(paths))
+	userCache := make(map[int]string)
+	groupCache := make(map[int]string)
+	for i, path := range paths {
+		info, err := statFile(path, digest, userCache, groupCache)
+		result[i] = statFileResult{
+			fileInfoResult: info,
+			Path:           path,
+			Error:          fileErrorToResult(err),
+		}
+	}
+	return SyncResponse(result)
+}
+
+func statFile(path string, digest bool, userCache, groupCache map[int]string) (*fileInfoResult, error) {
+	if !pathpkg.IsAbs(path) {
+		return nil, nonAbsolutePathError(path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	result := fileInfoToResult(path, info, userCache, groupCache)
+	if digest && info.Mode().IsRegular() {
+		result.SHA256 = fileSHA256(path)
+	}
+	return &result, nil
 }
 
 func v1PostFiles(_ *Command, req *http.Request, user *UserState) Response {
@@ -408,8 +551,9 @@ func writeFiles(body io.Reader, boundary string, user *UserState) Response {
 
 	// Decode metadata about files to write.
 	var payload struct {
-		Action string           `json:"action"`
-		Files  []writeFilesItem `json:"files"`
+		Action      string           `json:"action"`
+		Files       []writeFilesItem `json:"files"`
+		Transaction bool             `json:"transaction"`
 	}
 	decoder := json.NewDecoder(part)
 	if err := decoder.Decode(&payload); err != nil {
@@ -425,6 +569,9 @@ func writeFiles(body io.Reader, boundary string, user *UserState) Response {
 	for _, file := range payload.Files {
 		infos[file.Path] = file
 	}
+	if payload.Transaction {
+		return writeFilesTransaction(mr, payload.Files, infos, user)
+	}
 
 	errors := make(map[string]error)
 	for i := 0; ; i++ {
@@ -474,33 +621,209 @@ func multipartFilename(part *multipart.Part) string {
 }
 
 func writeFile(item writeFilesItem, source io.Reader) error {
+	perm, sysUid, sysGid, err := prepareWriteFile(item)
+	if err != nil {
+		return err
+	}
+
+	// Atomically write file content to destination.
+	return atomicWriteChown(item.Path, source, perm, osutil.AtomicWriteChmod, sysUid, sysGid)
+}
+
+// prepareWriteFile checks the metadata of a file to write, creating its
+// parent directory if requested, and returns the file's permissions and
+// ownership.
+func prepareWriteFile(item writeFilesItem) (perm os.FileMode, uid sys.UserID, gid sys.GroupID, err error) {
 	if !pathpkg.IsAbs(item.Path) {
-		return nonAbsolutePathError(item.Path)
+		return 0, 0, 0, nonAbsolutePathError(item.Path)
 	}
 
-	uid, gid, err := normalizeUidGid(item.UserID, item.GroupID, item.User, item.Group)
+	userID, groupID, err := normalizeUidGid(item.UserID, item.GroupID, item.User, item.Group)
 	if err != nil {
-		return fmt.Errorf("cannot look up user and group: %w", err)
+		return 0, 0, 0, fmt.Errorf("cannot look up user and group: %w", err)
 	}
 
 	// Create parent directory if needed.
 	if item.MakeDirs {
-		err := mkdirAllUserGroup(pathpkg.Dir(item.Path), 0o755, uid, gid)
+		err := mkdirAllUserGroup(pathpkg.Dir(item.Path), 0o755, userID, groupID)
 		if err != nil {
-			return fmt.Errorf("cannot create directory: %w", err)
+			return 0, 0, 0, fmt.Errorf("cannot create directory: %w", err)
 		}
 	}
 
-	// Atomically write file content to destination.
-	perm, err := parsePermissions(item.Permissions, 0o644)
+	perm, err = parsePermissions(item.Permissions, 0o644)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	uid, gid = sys.UserID(osutil.NoChown), sys.GroupID(osutil.NoChown)
+	if userID != nil && groupID != nil {
+		uid, gid = sys.UserID(*userID), sys.GroupID(*groupID)
+	}
+	return perm, uid, gid, nil
+}
+
+// writeFilesTransaction writes the files in a multipart request all
+// together. Each file is first written to a temporary file next to its
+// target, and the temporary files are only renamed into place once all of
+// them have been written. If any file can't be written, the files already
+// renamed are restored to their previous content, and an error response is
+// returned for the first failure. Directories created for "make-dirs" are
+// left in place.
+func writeFilesTransaction(mr *multipart.Reader, files []writeFilesItem, infos map[string]writeFilesItem, user *UserState) Response {
+	tx := &writeTransaction{staged: make(map[string]*stagedFile)}
+	defer tx.cancel()
+
+	for i := 0; ; i++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BadRequest("cannot read file part %d: %v", i, err)
+		}
+		if part.FormName() != "files" {
+			return BadRequest(`field name must be "files", got %q`, part.FormName())
+		}
+		path := multipartFilename(part)
+		info, ok := infos[path]
+		if !ok {
+			return BadRequest("no metadata for path %q", path)
+		}
+		if tx.staged[path] != nil {
+			return BadRequest("duplicate file content for path %q", path)
+		}
+		logger.SecurityWarn(logger.SecurityAuthzAdmin, userString(user)+",push_file", "Pushing file "+path)
+		err = tx.stage(info, part)
+		part.Close()
+		if err != nil {
+			return writeTransactionError(path, err)
+		}
+	}
+
+	// Ensure we staged all the files in the metadata.
+	for _, file := range files {
+		if tx.staged[file.Path] == nil {
+			return writeTransactionError(file.Path, fmt.Errorf("no file content for path %q", file.Path))
+		}
+	}
+
+	path, err := tx.commit()
+	if err != nil {
+		return writeTransactionError(path, err)
+	}
+	result := make([]fileResult, len(files))
+	for i, file := range files {
+		result[i] = fileResult{Path: file.Path}
+	}
+	return SyncResponse(result)
+}
+
+func writeTransactionError(path string, err error) Response {
+	result := fileErrorToResult(fmt.Errorf("cannot write %q: %w", path, err))
+	result.Value = path
+	return &resp{
+		Type:   ResponseTypeError,
+		Result: result,
+		Status: fileErrorToStatus(err),
+	}
+}
+
+// writeTransaction holds the files staged by writeFilesTransaction.
+type writeTransaction struct {
+	files  []*stagedFile
+	staged map[string]*stagedFile
+}
+
+type stagedFile struct {
+	path    string
+	file    *osutil.AtomicFile
+	backup  string // hard link to the file's previous content, if any
+	renamed bool
+}
+
+// stage writes the content of a file to a temporary file next to it.
+func (tx *writeTransaction) stage(item writeFilesItem, source io.Reader) error {
+	perm, uid, gid, err := prepareWriteFile(item)
 	if err != nil {
 		return err
 	}
-	sysUid, sysGid := sys.UserID(osutil.NoChown), sys.GroupID(osutil.NoChown)
-	if uid != nil && gid != nil {
-		sysUid, sysGid = sys.UserID(*uid), sys.GroupID(*gid)
+	f, err := newAtomicFile(item.Path, perm, osutil.AtomicWriteChmod, uid, gid)
+	if err != nil {
+		return err
+	}
+	staged := &stagedFile{path: item.Path, file: f}
+	tx.files = append(tx.files, staged)
+	tx.staged[item.Path] = staged
+	_, err = io.Copy(f, source)
+	return err
+}
+
+// commit renames the staged files into place, first making a hard link to
+// each existing file so that it can be restored if a later rename fails. On
+// error, it returns the path of the file which couldn't be written.
+func (tx *writeTransaction) commit() (path string, err error) {
+	for _, staged := range tx.files {
+		info, err := os.Lstat(staged.path)
+		if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+			// Nothing to back up (renaming over a directory fails below).
+			continue
+		}
+		backup := staged.path + "." + randutil.RandomString(12) + "~"
+		if err == nil {
+			err = os.Link(staged.path, backup)
+		}
+		if err != nil {
+			tx.rollback()
+			return staged.path, fmt.Errorf("cannot back up file: %w", err)
+		}
+		staged.backup = backup
+	}
+	for _, staged := range tx.files {
+		err = staged.file.Commit()
+		if err != nil {
+			// The commit may fail after the rename (when syncing the
+			// directory), in which case the file also needs restoring.
+			staged.renamed = staged.file.Cancel() == osutil.ErrCannotCancel
+			tx.rollback()
+			return staged.path, err
+		}
+		staged.renamed = true
+	}
+	for _, staged := range tx.files {
+		if staged.backup != "" {
+			_ = os.Remove(staged.backup)
+		}
+	}
+	return "", nil
+}
+
+// rollback restores the files already renamed to their previous content,
+// removing the ones which didn't exist before, and removes the backups.
+func (tx *writeTransaction) rollback() {
+	for i := len(tx.files) - 1; i >= 0; i-- {
+		staged := tx.files[i]
+		var err error
+		switch {
+		case staged.backup != "" && staged.renamed:
+			err = os.Rename(staged.backup, staged.path)
+		case staged.backup != "":
+			err = os.Remove(staged.backup)
+		case staged.renamed:
+			err = os.Remove(staged.path)
+		}
+		if err != nil {
+			logger.Noticef("Cannot roll back write of %q: %v", staged.path, err)
+		}
+	}
+}
+
+// cancel removes the temporary files not renamed into place.
+func (tx *writeTransaction) cancel() {
+	for _, staged := range tx.files {
+		if !staged.renamed {
+			_ = staged.file.Cancel()
+		}
 	}
-	return atomicWriteChown(item.Path, source, perm, osutil.AtomicWriteChmod, sysUid, sysGid)
 }
 
 func mkdirAllUserGroup(path string, perm os.FileMode, uid, gid *int) error {
@@ -598,6 +921,7 @@ func makeDir(dir makeDirsItem) error {
 // Because it's hard to test os.Chown without running the tests as root.
 var (
 	atomicWriteChown = osutil.AtomicWriteChown
+	newAtomicFile    = osutil.NewAtomicFile
 	normalizeUidGid  = osutil.NormalizeUidGid
 	mkdir            = osutil.Mkdir
 )
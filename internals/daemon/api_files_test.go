@@ -180,6 +180,160 @@ func (s *filesSuite) TestListFilesNoResults(c *C) {
 	c.Assert(r.Result, HasLen, 0) // should be empty slice, not nil
 }
 
+func (s *filesSuite) TestListFilesRecursive(c *C) {
+	tmpDir := createTestFiles(c)
+	c.Assert(os.Mkdir(tmpDir+"/sub/deeper", 0o755), IsNil)
+	writeTempFile(c, tmpDir+"/sub", "three.txt", "dee", 0o644)
+	writeTempFile(c, tmpDir+"/sub/deeper", "four.txt", "eee", 0o644)
+	c.Assert(os.Symlink(tmpDir+"/sub", tmpDir+"/sub/link"), IsNil)
+
+	query := url.Values{
+		"action":    []string{"list"},
+		"path":      []string{tmpDir},
+		"recursive": []string{"true"},
+	}
+	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Symbolic links to directories aren't followed.
+	r := decodeResp(c, body, http.StatusOK, ResponseTypeSync)
+	c.Assert(r.Result, HasLen, 8)
+	assertListResult(c, r.Result, 0, "file", tmpDir, "foo", "644", 1)
+	assertListResult(c, r.Result, 1, "file", tmpDir, "one.txt", "600", 2)
+	assertListResult(c, r.Result, 2, "directory", tmpDir, "sub", "755", -1)
+	assertListResult(c, r.Result, 3, "directory", tmpDir+"/sub", "deeper", "755", -1)
+	assertListResult(c, r.Result, 4, "file", tmpDir+"/sub/deeper", "four.txt", "644", 3)
+	assertListResult(c, r.Result, 5, "symlink", tmpDir+"/sub", "link", "777", -1)
+	assertListResult(c, r.Result, 6, "file", tmpDir+"/sub", "three.txt", "644", 3)
+	assertListResult(c, r.Result, 7, "file", tmpDir, "two.txt", "755", 3)
+
+	// The pattern is matched against the name of each entry.
+	query.Set("pattern", "*.txt")
+	query.Set("max-depth", "2")
+	response, body = doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	r = decodeResp(c, body, http.StatusOK, ResponseTypeSync)
+	c.Assert(r.Result, HasLen, 3)
+	assertListResult(c, r.Result, 0, "file", tmpDir, "one.txt", "600", 2)
+	assertListResult(c, r.Result, 1, "file", tmpDir+"/sub", "three.txt", "644", 3)
+	assertListResult(c, r.Result, 2, "file", tmpDir, "two.txt", "755", 3)
+}
+
+func (s *filesSuite) TestListFilesRecursivePermissionDenied(c *C) {
+	if os.Getuid() == 0 {
+		c.Skip("cannot run test as root")
+	}
+	tmpDir := createTestFiles(c)
+	c.Assert(os.Chmod(tmpDir+"/sub", 0), IsNil)
+	defer os.Chmod(tmpDir+"/sub", 0o755)
+
+	query := url.Values{
+		"action":    []string{"list"},
+		"path":      []string{tmpDir},
+		"recursive": []string{"true"},
+	}
+	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// The unreadable subdirectory is listed, but not its entries.
+	r := decodeResp(c, body, http.StatusOK, ResponseTypeSync)
+	c.Assert(r.Result, HasLen, 4)
+	assertListResult(c, r.Result, 0, "file", tmpDir, "foo", "644", 1)
+	assertListResult(c, r.Result, 1, "file", tmpDir, "one.txt", "600", 2)
+	assertListResult(c, r.Result, 2, "directory", tmpDir, "sub", "000", -1)
+	assertListResult(c, r.Result, 3, "file", tmpDir, "two.txt", "755", 3)
+}
+
+func (s *filesSuite) TestListFilesInvalidParams(c *C) {
+	tests := []struct {
+		query url.Values
+		error string
+	}{{
+		query: url.Values{"recursive": []string{"yes"}},
+		error: `recursive parameter must be "true" or "false"`,
+	}, {
+		query: url.Values{"recursive": []string{"true"}, "itself": []string{"true"}},
+		error: `cannot use itself and recursive together`,
+	}, {
+		query: url.Values{"max-depth": []string{"1"}},
+		error: `max-depth requires recursive`,
+	}, {
+		query: url.Values{"recursive": []string{"true"}, "max-depth": []string{"0"}},
+		error: `max-depth must be a positive integer`,
+	}, {
+		query: url.Values{"digest": []string{"md5"}},
+		error: `digest parameter must be "sha256"`,
+	}}
+	for _, test := range tests {
+		test.query.Set("action", "list")
+		test.query.Set("path", "/")
+		response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", test.query, nil, nil)
+		c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+		assertError(c, body, http.StatusBadRequest, "", test.error)
+	}
+}
+
+func (s *filesSuite) TestListFilesDigest(c *C) {
+	tmpDir := createTestFiles(c)
+
+	query := url.Values{
+		"action": []string{"list"},
+		"path":   []string{tmpDir},
+		"digest": []string{"sha256"},
+	}
+	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	r := decodeResp(c, body, http.StatusOK, ResponseTypeSync)
+	results := r.Result.([]any)
+	c.Assert(results, HasLen, 4)
+	c.Check(results[0].(map[string]any)["sha256"], Equals, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb")
+	c.Check(results[1].(map[string]any)["sha256"], Equals, "46599c5bb5c33101f80cea8438e2228085513dbbb19b2f5ce97bd68494d3344d")
+	_, ok := results[2].(map[string]any)["sha256"]
+	c.Check(ok, Equals, false)
+}
+
+func (s *filesSuite) TestStatFiles(c *C) {
+	tmpDir := createTestFiles(c)
+
+	query := url.Values{
+		"action": []string{"stat"},
+		"path":   []string{tmpDir + "/one.txt", tmpDir + "/sub", tmpDir + "/missing", "rel"},
+		"digest": []string{"sha256"},
+	}
+	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	r := decodeResp(c, body, http.StatusOK, ResponseTypeSync)
+	c.Assert(r.Result, HasLen, 4)
+	assertListResult(c, r.Result, 0, "file", tmpDir, "one.txt", "600", 2)
+	assertListResult(c, r.Result, 1, "directory", tmpDir, "sub", "755", -1)
+	results := r.Result.([]any)
+	c.Check(results[0].(map[string]any)["sha256"], Equals, "46599c5bb5c33101f80cea8438e2228085513dbbb19b2f5ce97bd68494d3344d")
+	c.Check(results[0].(map[string]any)["error"], IsNil)
+	c.Check(results[2], DeepEquals, map[string]any{
+		"path": tmpDir + "/missing",
+		"error": map[string]any{
+			"kind":    "not-found",
+			"message": "stat " + tmpDir + "/missing: no such file or directory",
+		},
+	})
+	c.Check(results[3], DeepEquals, map[string]any{
+		"path": "rel",
+		"error": map[string]any{
+			"kind":    "generic-file-error",
+			"message": `paths must be absolute, got "rel"`,
+		},
+	})
+}
+
+func (s *filesSuite) TestStatFilesNoPaths(c *C) {
+	query := url.Values{"action": []string{"stat"}}
+	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+	assertError(c, body, http.StatusBadRequest, "", "must specify one or more paths")
+}
+
 func (s *filesSuite) TestReadNoPaths(c *C) {
 	query := url.Values{"action": []string{"read"}}
 	response, body := doRequest(c, v1GetFiles, "GET", "/v1/files", query, nil, nil)
@@ -1210,6 +1364,183 @@ group not found
 	c.Check(osutil.CanStat(pathPermissionDenied), Equals, false)
 }
 
+func (s *filesSuite) TestWriteTransaction(c *C) {
+	tmpDir := c.MkDir()
+	path0 := tmpDir + "/hello.txt"
+	path1 := tmpDir + "/foo/bar.txt"
+	writeTempFile(c, tmpDir, "hello.txt", "old", 0o644)
+
+	headers := http.Header{
+		"Content-Type": []string{"multipart/form-data; boundary=01234567890123456789012345678901"},
+	}
+	response, body := doRequest(c, v1PostFiles, "POST", "/v1/files", nil, headers,
+		fmt.Appendf(nil, `
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="request"
+
+{
+	"action": "write",
+	"transaction": true,
+	"files": [
+		{"path": "%[1]s", "permissions": "600"},
+		{"path": "%[2]s", "make-dirs": true}
+	]
+}
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[1]s"
+
+Hello
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[2]s"
+
+Foo
+--01234567890123456789012345678901--
+`, path0, path1))
+	c.Check(response.StatusCode, Equals, http.StatusOK)
+
+	var r testFilesResponse
+	c.Assert(json.NewDecoder(body).Decode(&r), IsNil)
+	c.Check(r.Type, Equals, "sync")
+	c.Assert(r.Result, HasLen, 2)
+	checkFileResult(c, r.Result[0], path0, "", "")
+	checkFileResult(c, r.Result[1], path1, "", "")
+
+	assertFile(c, path0, 0o600, "Hello")
+	assertFile(c, path1, 0o644, "Foo")
+	assertDirEntries(c, tmpDir, "foo", "hello.txt")
+}
+
+func (s *filesSuite) TestWriteTransactionStageError(c *C) {
+	tmpDir := c.MkDir()
+	path0 := tmpDir + "/hello.txt"
+	path1 := tmpDir + "/not-found/foo"
+	writeTempFile(c, tmpDir, "hello.txt", "old", 0o644)
+
+	headers := http.Header{
+		"Content-Type": []string{"multipart/form-data; boundary=01234567890123456789012345678901"},
+	}
+	response, body := doRequest(c, v1PostFiles, "POST", "/v1/files", nil, headers,
+		fmt.Appendf(nil, `
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="request"
+
+{
+	"action": "write",
+	"transaction": true,
+	"files": [
+		{"path": "%[1]s"},
+		{"path": "%[2]s"}
+	]
+}
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[1]s"
+
+Hello
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[2]s"
+
+dir not found
+--01234567890123456789012345678901--
+`, path0, path1))
+	c.Check(response.StatusCode, Equals, http.StatusNotFound)
+	assertError(c, body, http.StatusNotFound, "not-found", `cannot write ".*/not-found/foo": .*`)
+
+	// Nothing was written, and the temporary files were removed.
+	assertFile(c, path0, 0o644, "old")
+	assertDirEntries(c, tmpDir, "hello.txt")
+}
+
+func (s *filesSuite) TestWriteTransactionNoContent(c *C) {
+	tmpDir := c.MkDir()
+	path0 := tmpDir + "/hello.txt"
+	path1 := tmpDir + "/no-content"
+
+	headers := http.Header{
+		"Content-Type": []string{"multipart/form-data; boundary=01234567890123456789012345678901"},
+	}
+	response, body := doRequest(c, v1PostFiles, "POST", "/v1/files", nil, headers,
+		fmt.Appendf(nil, `
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="request"
+
+{
+	"action": "write",
+	"transaction": true,
+	"files": [
+		{"path": "%[1]s"},
+		{"path": "%[2]s"}
+	]
+}
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[1]s"
+
+Hello
+--01234567890123456789012345678901--
+`, path0, path1))
+	c.Check(response.StatusCode, Equals, http.StatusBadRequest)
+	assertError(c, body, http.StatusBadRequest, "generic-file-error", `cannot write ".*/no-content": no file content for path .*`)
+	assertDirEntries(c, tmpDir)
+}
+
+func (s *filesSuite) TestWriteTransactionRollback(c *C) {
+	tmpDir := c.MkDir()
+	path0 := tmpDir + "/hello.txt"
+	path1 := tmpDir + "/new.txt"
+	path2 := tmpDir + "/dir"
+	writeTempFile(c, tmpDir, "hello.txt", "old", 0o644)
+	c.Assert(os.Mkdir(path2, 0o755), IsNil)
+
+	// Renaming over the directory fails, which happens after the other
+	// files have been renamed into place.
+	headers := http.Header{
+		"Content-Type": []string{"multipart/form-data; boundary=01234567890123456789012345678901"},
+	}
+	response, body := doRequest(c, v1PostFiles, "POST", "/v1/files", nil, headers,
+		fmt.Appendf(nil, `
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="request"
+
+{
+	"action": "write",
+	"transaction": true,
+	"files": [
+		{"path": "%[1]s"},
+		{"path": "%[2]s"},
+		{"path": "%[3]s"}
+	]
+}
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[1]s"
+
+Hello
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[2]s"
+
+New
+--01234567890123456789012345678901
+Content-Disposition: form-data; name="files"; filename="%[3]s"
+
+Dir
+--01234567890123456789012345678901--
+`, path0, path1, path2))
+	c.Check(response.StatusCode, Equals, http.StatusBadRequest)
+	assertError(c, body, http.StatusBadRequest, "generic-file-error", `cannot write ".*/dir": .*`)
+
+	// The files already written were restored.
+	assertFile(c, path0, 0o644, "old")
+	assertDirEntries(c, tmpDir, "dir", "hello.txt")
+}
+
+func assertDirEntries(c *C, dir string, names ...string) {
+	entries, err := os.ReadDir(dir)
+	c.Assert(err, IsNil)
+	var found []string
+	for _, entry := range entries {
+		found = append(found, entry.Name())
+	}
+	c.Check(found, DeepEquals, names)
+}
+
 func assertFile(c *C, path string, perm os.FileMode, content string) {
 	b, err := os.ReadFile(path)
 	c.Assert(err, IsNil)
@@ -31,10 +31,9 @@ type healthInfo struct {
 func v1Health(c *Command, r *http.Request, _ *UserState) Response {
 	query := r.URL.Query()
 	level := plan.CheckLevel(query.Get("level"))
-	switch level {
-	case plan.UnsetLevel, plan.AliveLevel, plan.ReadyLevel:
-	default:
-		return BadRequest(`level must be "alive" or "ready"`)
+	currentPlan := getPlan(c.d.overlord)
+	if !checkstate.LevelDefined(currentPlan, level) {
+		return BadRequest(`level must be "alive", "ready", or a level defined in "health-levels"`)
 	}
 
 	names := strutil.MultiCommaSeparatedList(query["names"])
@@ -45,17 +44,13 @@ func v1Health(c *Command, r *http.Request, _ *UserState) Response {
 		return InternalError("internal server error")
 	}
 
-	healthy := true
+	healthy, err := checkstate.Healthy(currentPlan, checks, level, names)
+	if err != nil {
+		return InternalError("%v", err)
+	}
 	status := http.StatusOK
-	for _, check := range checks {
-		levelMatch := level == plan.UnsetLevel || level == check.Level ||
-			level == plan.ReadyLevel && check.Level == plan.AliveLevel // ready implies alive
-		namesMatch := len(names) == 0 || strutil.ListContains(names, check.Name)
-		// CheckStatusUp is healthy, and CheckStatusInactive is ignored.
-		if levelMatch && namesMatch && check.Status == checkstate.CheckStatusDown {
-			healthy = false
-			status = http.StatusBadGateway
-		}
+	if !healthy {
+		status = http.StatusBadGateway
 	}
 
 	return SyncResponse(&resp{
@@ -33,7 +33,19 @@ import (
 
 var _ = Suite(&healthSuite{})
 
-type healthSuite struct{}
+type healthSuite struct {
+	restoreGetPlan func()
+}
+
+func (s *healthSuite) SetUpTest(c *C) {
+	s.restoreGetPlan = FakeGetPlan(func(o *overlord.Overlord) *plan.Plan {
+		return plan.NewPlan()
+	})
+}
+
+func (s *healthSuite) TearDownTest(c *C) {
+	s.restoreGetPlan()
+}
 
 func (s *healthSuite) TestNoChecks(c *C) {
 	restore := FakeGetChecks(func(o *overlord.Overlord) ([]*checkstate.CheckInfo, error) {
@@ -215,7 +227,59 @@ func (s *healthSuite) TestBadLevel(c *C) {
 
 	c.Assert(status, Equals, 400)
 	c.Assert(response, DeepEquals, map[string]any{
-		"message": `level must be "alive" or "ready"`,
+		"message": `level must be "alive", "ready", or a level defined in "health-levels"`,
+	})
+}
+
+func (s *healthSuite) TestCustomLevel(c *C) {
+	restore := FakeGetPlan(func(o *overlord.Overlord) *plan.Plan {
+		return &plan.Plan{Sections: map[string]plan.Section{
+			checkstate.HealthLevelsField: &checkstate.HealthLevelsSection{
+				Entries: map[string]*checkstate.HealthLevel{
+					"replicas": {
+						Name:     "replicas",
+						Override: plan.MergeOverride,
+						Includes: []plan.CheckLevel{plan.AliveLevel},
+						Quorum:   2,
+					},
+				},
+			},
+		}}
+	})
+	defer restore()
+	checks := []*checkstate.CheckInfo{
+		{Name: "chk1", Level: plan.AliveLevel, Status: checkstate.CheckStatusUp},
+		{Name: "chk2", Level: "replicas", Status: checkstate.CheckStatusUp},
+		{Name: "chk3", Level: "replicas", Status: checkstate.CheckStatusDown},
+		{Name: "chk4", Level: "replicas", Status: checkstate.CheckStatusUp},
+	}
+	restore = FakeGetChecks(func(o *overlord.Overlord) ([]*checkstate.CheckInfo, error) {
+		return checks, nil
+	})
+	defer restore()
+
+	// Two of three replicas are up, which meets the quorum.
+	status, response := serveHealth(c, "GET", "/v1/health?level=replicas", nil)
+	c.Assert(status, Equals, 200)
+	c.Assert(response, DeepEquals, map[string]any{
+		"healthy": true,
+	})
+
+	// Only one replica is up.
+	checks[3].Status = checkstate.CheckStatusDown
+	status, response = serveHealth(c, "GET", "/v1/health?level=replicas", nil)
+	c.Assert(status, Equals, 502)
+	c.Assert(response, DeepEquals, map[string]any{
+		"healthy": false,
+	})
+
+	// The replicas have quorum again, but the included alive level is down.
+	checks[3].Status = checkstate.CheckStatusUp
+	checks[0].Status = checkstate.CheckStatusDown
+	status, response = serveHealth(c, "GET", "/v1/health?level=replicas", nil)
+	c.Assert(status, Equals, 502)
+	c.Assert(response, DeepEquals, map[string]any{
+		"healthy": false,
 	})
 }
 
@@ -17,9 +17,12 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -29,6 +32,7 @@ import (
 
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/overlord/servstate"
+	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/servicelog"
 )
 
@@ -39,19 +43,39 @@ const (
 
 type serviceManager interface {
 	Services(names []string) ([]*servstate.ServiceInfo, error)
-	ServiceLogs(services []string, last int) (map[string]servicelog.Iterator, error)
+	ServiceLogs(services []string, last int, since time.Time) (map[string]servicelog.Iterator, error)
 }
 
 func v1GetLogs(c *Command, _ *http.Request, _ *UserState) Response {
 	return logsResponse{
-		svcMgr: overlordServiceManager(c.d.overlord),
+		svcMgr:  overlordServiceManager(c.d.overlord),
+		formats: logFormats(getPlan(c.d.overlord)),
 	}
 }
 
+// logFormats returns the log format of each service in the plan that has a
+// structured log-format.
+func logFormats(p *plan.Plan) map[string]servicelog.Format {
+	if p == nil {
+		return nil
+	}
+	formats := make(map[string]servicelog.Format)
+	for name, service := range p.Services {
+		if service.LogFormat != plan.LogFormatUnset {
+			formats[name] = servicelog.Format(service.LogFormat)
+		}
+	}
+	return formats
+}
+
 // logsResponse is a Response implementation to serve the logs in a custom
 // JSON Lines format.
 type logsResponse struct {
 	svcMgr serviceManager
+
+	// Log format of each service, used to parse its logs into a level,
+	// message and attributes.
+	formats map[string]servicelog.Format
 }
 
 func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -67,6 +91,13 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 	follow := followStr == "true"
 
+	filter, err := parseLogFilter(query)
+	if err != nil {
+		response := BadRequest("%v", err)
+		response.ServeHTTP(w, req)
+		return
+	}
+
 	var numLogs int
 	nStr := query.Get("n")
 	if nStr != "" {
@@ -77,6 +108,8 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 		numLogs = n
+	} else if !filter.since.IsZero() {
+		numLogs = -1
 	} else if follow {
 		numLogs = 0
 	} else {
@@ -97,7 +130,13 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	itsByName, err := r.svcMgr.ServiceLogs(services, numLogs)
+	// If filtering by time or content, the last numLogs are counted after
+	// filtering, so read all the logs.
+	last := numLogs
+	if numLogs > 0 && (!filter.until.IsZero() || filter.match != nil) {
+		last = -1
+	}
+	itsByName, err := r.svcMgr.ServiceLogs(services, last, filter.since)
 	if err != nil {
 		response := InternalError("cannot fetch log iterators: %v", err)
 		response.ServeHTTP(w, req)
@@ -123,7 +162,7 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 		var err error
 		for len(fifo) > 0 && err == nil {
-			err = encoder.Encode(newJSONLog(<-fifo))
+			err = encoder.Encode(r.newJSONLog(<-fifo))
 		}
 		if err != nil {
 			logger.Noticef("Cannot write logs: %v", err)
@@ -164,6 +203,15 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				return
 			}
 
+			if follow && !filter.until.IsZero() && log.Time.After(filter.until) {
+				// Later logs are newer, so none of them can match.
+				_ = flushFifo()
+				return
+			}
+			if !filter.matches(log) {
+				continue
+			}
+
 			// Logs are coming faster than we can send them (probably a slow
 			// client), so stop now.
 			if !follow && log.Time.After(requestStarted) {
@@ -184,7 +232,7 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			}
 
 			// Otherwise encode and output log directly.
-			err := encoder.Encode(newJSONLog(log))
+			err := encoder.Encode(r.newJSONLog(log))
 			if err != nil {
 				logger.Noticef("Cannot write logs: %v", err)
 				return
@@ -203,6 +251,55 @@ func (r logsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// logFilter selects the logs to return, based on the "since", "until" and
+// "match" query parameters.
+type logFilter struct {
+	since time.Time
+	until time.Time
+	match *regexp.Regexp
+}
+
+func parseLogFilter(query url.Values) (*logFilter, error) {
+	var filter logFilter
+	var err error
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		filter.since, err = time.Parse(time.RFC3339Nano, sinceStr)
+		if err != nil {
+			return nil, errors.New("since must be a time in RFC 3339 format")
+		}
+	}
+	if untilStr := query.Get("until"); untilStr != "" {
+		filter.until, err = time.Parse(time.RFC3339Nano, untilStr)
+		if err != nil {
+			return nil, errors.New("until must be a time in RFC 3339 format")
+		}
+	}
+	if !filter.until.IsZero() && filter.until.Before(filter.since) {
+		return nil, errors.New("until must not be before since")
+	}
+	if matchStr := query.Get("match"); matchStr != "" {
+		filter.match, err = regexp.Compile(matchStr)
+		if err != nil {
+			return nil, fmt.Errorf("match must be a valid regular expression: %v", err)
+		}
+	}
+	return &filter, nil
+}
+
+// matches reports whether the log entry passes the filter.
+func (f *logFilter) matches(entry servicelog.Entry) bool {
+	if entry.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && entry.Time.After(f.until) {
+		return false
+	}
+	if f.match != nil && !f.match.MatchString(strings.TrimSuffix(entry.Message, "\n")) {
+		return false
+	}
+	return true
+}
+
 // streamLogs reads and parses logs from the given services, merging the
 // log streams and ordering by timestamp. It sends the parsed logs to the
 // logs channel, and returns when the done channel is closed.
@@ -300,18 +397,26 @@ func streamLogs(itsByName map[string]servicelog.Iterator, logs chan<- servicelog
 //
 // {"time":"2021-04-23T01:28:52.660Z","service":"redis","message":"redis started up"}
 // {"time":"2021-04-23T01:28:52.798Z","service":"thing","message":"did something"}
+// {"time":"2021-04-23T01:28:53.012Z","service":"api","message":"request","level":"info","attributes":{"path":"/"}}
 type jsonLog struct {
-	Time    time.Time `json:"time"`
-	Service string    `json:"service"`
-	Message string    `json:"message"`
+	Time       time.Time         `json:"time"`
+	Service    string            `json:"service"`
+	Message    string            `json:"message"`
+	Level      string            `json:"level,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
-func newJSONLog(entry servicelog.Entry) *jsonLog {
+// newJSONLog returns the JSON log for an entry, parsing it according to
+// the service's log format.
+func (r logsResponse) newJSONLog(entry servicelog.Entry) *jsonLog {
+	entry = servicelog.ParseStructured(entry, r.formats[entry.Service])
 	message := strings.TrimSuffix(entry.Message, "\n")
 	return &jsonLog{
-		Time:    entry.Time,
-		Service: entry.Service,
-		Message: message,
+		Time:       entry.Time,
+		Service:    entry.Service,
+		Message:    message,
+		Level:      entry.Level,
+		Attributes: entry.Attributes,
 	}
 }
 
@@ -39,9 +39,11 @@ var _ = Suite(&logsSuite{})
 type logsSuite struct{}
 
 type testLogEntry struct {
-	Time    time.Time
-	Service string
-	Message string
+	Time       time.Time
+	Service    string
+	Message    string
+	Level      string
+	Attributes map[string]string
 }
 
 type testServiceManager struct {
@@ -67,14 +69,14 @@ func (m testServiceManager) Services(names []string) ([]*servstate.ServiceInfo,
 	return infos, nil
 }
 
-func (m testServiceManager) ServiceLogs(services []string, last int) (map[string]servicelog.Iterator, error) {
+func (m testServiceManager) ServiceLogs(services []string, last int, since time.Time) (map[string]servicelog.Iterator, error) {
 	if m.serviceLogsErr != nil {
 		return nil, m.serviceLogsErr
 	}
 	its := make(map[string]servicelog.Iterator)
 	for name, wb := range m.buffers {
 		if slices.Contains(services, name) {
-			if last >= 0 {
+			if last >= 0 && since.IsZero() {
 				its[name] = wb.HeadIterator(last)
 			} else {
 				its[name] = wb.TailIterator()
@@ -100,6 +102,28 @@ func (s *logsSuite) TestInvalidN(c *C) {
 	checkError(c, rec.Body.Bytes(), http.StatusBadRequest, `n must be -1, 0, or a positive integer`)
 }
 
+func (s *logsSuite) TestInvalidSince(c *C) {
+	rec := s.recordResponse(c, "/v1/logs?since=yesterday", nil)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	checkError(c, rec.Body.Bytes(), http.StatusBadRequest, `since must be a time in RFC 3339 format`)
+}
+
+func (s *logsSuite) TestInvalidUntil(c *C) {
+	rec := s.recordResponse(c, "/v1/logs?until=tomorrow", nil)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	checkError(c, rec.Body.Bytes(), http.StatusBadRequest, `until must be a time in RFC 3339 format`)
+
+	rec = s.recordResponse(c, "/v1/logs?since=2021-05-20T16:55:00Z&until=2021-05-20T16:54:00Z", nil)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	checkError(c, rec.Body.Bytes(), http.StatusBadRequest, `until must not be before since`)
+}
+
+func (s *logsSuite) TestInvalidMatch(c *C) {
+	rec := s.recordResponse(c, "/v1/logs?match=%28foo", nil)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	checkError(c, rec.Body.Bytes(), http.StatusBadRequest, `match must be a valid regular expression: .*missing closing \).*`)
+}
+
 func (s *logsSuite) TestServicesError(c *C) {
 	svcMgr := testServiceManager{
 		servicesErr: fmt.Errorf("Services error!"),
@@ -189,6 +213,128 @@ func (s *logsSuite) TestOneServiceAllLogs(c *C) {
 	}
 }
 
+func (s *logsSuite) TestSince(c *C) {
+	rb := servicelog.NewRingBuffer(4096)
+	for i := range 40 {
+		fmt.Fprintf(rb, "2021-05-20T16:55:%02d.000Z [nginx] message %d\n", i, i)
+	}
+
+	svcMgr := testServiceManager{
+		buffers: map[string]*servicelog.RingBuffer{
+			"nginx": rb,
+		},
+	}
+	// All logs since the given time are returned by default.
+	rec := s.recordResponse(c, "/v1/logs?since=2021-05-20T16:55:05Z", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs := decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 35)
+	for i := range 35 {
+		checkLog(c, logs[i], "nginx", fmt.Sprintf("message %d", i+5))
+	}
+
+	// The number of logs applies after filtering by time.
+	rec = s.recordResponse(c, "/v1/logs?n=2&since=2021-05-20T18:55:04%2B02:00", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs = decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 2)
+	for i := range 2 {
+		checkLog(c, logs[i], "nginx", fmt.Sprintf("message %d", i+38))
+	}
+
+	rec = s.recordResponse(c, "/v1/logs?n=5&since=2021-05-20T16:55:37Z", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs = decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 3)
+	for i := range 3 {
+		checkLog(c, logs[i], "nginx", fmt.Sprintf("message %d", i+37))
+	}
+}
+
+func (s *logsSuite) TestUntilAndMatch(c *C) {
+	rb := servicelog.NewRingBuffer(4096)
+	for i := range 40 {
+		fmt.Fprintf(rb, "2021-05-20T16:55:%02d.000Z [nginx] message %d\n", i, i)
+	}
+
+	svcMgr := testServiceManager{
+		buffers: map[string]*servicelog.RingBuffer{
+			"nginx": rb,
+		},
+	}
+	rec := s.recordResponse(c, "/v1/logs?n=-1&until=2021-05-20T16:55:02Z", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs := decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 3)
+	for i := range 3 {
+		checkLog(c, logs[i], "nginx", fmt.Sprintf("message %d", i))
+	}
+
+	rec = s.recordResponse(c, "/v1/logs?n=-1&match=message+%5B23%5D5%24", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs = decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 2)
+	checkLog(c, logs[0], "nginx", "message 25")
+	checkLog(c, logs[1], "nginx", "message 35")
+
+	// The number of logs applies after filtering.
+	rec = s.recordResponse(c, "/v1/logs?n=3&since=2021-05-20T16:55:05Z&until=2021-05-20T16:55:20Z&match=1%5Cd", svcMgr)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs = decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 3)
+	for i := range 3 {
+		checkLog(c, logs[i], "nginx", fmt.Sprintf("message %d", i+17))
+	}
+}
+
+func (s *logsSuite) TestFollowUntil(c *C) {
+	rb := servicelog.NewRingBuffer(4096)
+	fmt.Fprintf(rb, "2021-05-20T16:55:00.000Z [nginx] message 0\n")
+
+	svcMgr := testServiceManager{
+		buffers: map[string]*servicelog.RingBuffer{
+			"nginx": rb,
+		},
+	}
+	req, err := http.NewRequest("GET", "/v1/logs?follow=true&n=-1&until=2021-05-20T16:55:05Z&match=even", nil)
+	c.Assert(err, IsNil)
+	rsp := logsResponse{svcMgr: svcMgr}
+	logChan := make(chan string, 10)
+	rec := &followRecorder{logChan: logChan}
+	done := make(chan struct{})
+	go func() {
+		rsp.ServeHTTP(rec, req)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	fmt.Fprintf(rb, "2021-05-20T16:55:01.000Z [nginx] odd 1\n")
+	fmt.Fprintf(rb, "2021-05-20T16:55:02.000Z [nginx] even 2\n")
+	select {
+	case logsStr := <-logChan:
+		logs := decodeLogs(c, strings.NewReader(logsStr))
+		c.Assert(logs, HasLen, 1)
+		checkLog(c, logs[0], "nginx", "even 2")
+	case <-time.After(time.Second):
+		c.Fatalf("timed out waiting for log")
+	}
+
+	// The response ends at the first log after until.
+	fmt.Fprintf(rb, "2021-05-20T16:55:06.000Z [nginx] even 6\n")
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		c.Fatalf("timed out waiting for request to be finished")
+	}
+	c.Check(logChan, HasLen, 0)
+}
+
 func (s *logsSuite) TestOneServiceOutOfTwo(c *C) {
 	rb := servicelog.NewRingBuffer(4096)
 	lw := servicelog.NewFormatWriter(rb, "nginx")
@@ -212,6 +358,49 @@ func (s *logsSuite) TestOneServiceOutOfTwo(c *C) {
 	}
 }
 
+func (s *logsSuite) TestStructured(c *C) {
+	rb := servicelog.NewRingBuffer(4096)
+	lw := servicelog.NewFormatWriter(rb, "api")
+	fmt.Fprintf(lw, "level=info msg=started port=8080\n")
+	fmt.Fprintf(lw, "not logfmt \"\n")
+	fmt.Fprintf(lw, "level=error msg=\"request failed\" path=/foo\n")
+
+	svcMgr := testServiceManager{
+		buffers: map[string]*servicelog.RingBuffer{
+			"api": rb,
+		},
+	}
+	req, err := http.NewRequest("GET", "/v1/logs?match=path=/foo|started", nil)
+	c.Assert(err, IsNil)
+	rsp := logsResponse{
+		svcMgr:  svcMgr,
+		formats: map[string]servicelog.Format{"api": servicelog.FormatLogfmt},
+	}
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	logs := decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 2)
+	checkLog(c, logs[0], "api", "started")
+	c.Check(logs[0].Level, Equals, "info")
+	c.Check(logs[0].Attributes, DeepEquals, map[string]string{"port": "8080"})
+	checkLog(c, logs[1], "api", "request failed")
+	c.Check(logs[1].Level, Equals, "error")
+	c.Check(logs[1].Attributes, DeepEquals, map[string]string{"path": "/foo"})
+
+	// Logs that can't be parsed are returned unchanged.
+	req, err = http.NewRequest("GET", "/v1/logs?match=not", nil)
+	c.Assert(err, IsNil)
+	rec = httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	logs = decodeLogs(c, rec.Body)
+	c.Assert(logs, HasLen, 1)
+	checkLog(c, logs[0], "api", `not logfmt "`)
+	c.Check(logs[0].Level, Equals, "")
+	c.Check(logs[0].Attributes, IsNil)
+}
+
 func (s *logsSuite) TestNoLogs(c *C) {
 	svcMgr := testServiceManager{
 		buffers: map[string]*servicelog.RingBuffer{
@@ -29,6 +29,7 @@ func v1GetMetrics(c *Command, r *http.Request, _ *UserState) Response {
 
 		svcMgr := overlordServiceManager(c.d.overlord)
 		chkMgr := overlordCheckManager(c.d.overlord)
+		logMgr := overlordLogManager(c.d.overlord)
 
 		err := svcMgr.WriteMetrics(metricsWriter)
 		if err != nil {
@@ -44,6 +45,13 @@ func v1GetMetrics(c *Command, r *http.Request, _ *UserState) Response {
 			return
 		}
 
+		err = logMgr.WriteMetrics(metricsWriter)
+		if err != nil {
+			logger.Noticef("Cannot write log target metrics: %v", err)
+			http.Error(w, "# internal server error", http.StatusInternalServerError)
+			return
+		}
+
 		_, err = buf.WriteTo(w)
 		if err != nil {
 			logger.Noticef("Cannot write to HTTP response: %v", err)
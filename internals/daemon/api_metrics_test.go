@@ -67,7 +67,7 @@ services:
 	metricsRsp.ServeHTTP(metricsRec, metricsReq)
 	c.Check(metricsRec.Code, Equals, 200)
 	expected := `
-# HELP pebble_service_active Whether the service is currently active (1) or not (0)
+# HELP pebble_service_active Whether the service is currently active \(1\) or not \(0\)
 # TYPE pebble_service_active gauge
 pebble_service_active{service="test1"} 1
 
@@ -75,6 +75,41 @@ pebble_service_active{service="test1"} 1
 # TYPE pebble_service_start_count counter
 pebble_service_start_count{service="test1"} 1
 
+# HELP pebble_service_start_duration_seconds Time taken for the service to start, from starting its process until it is running
+# TYPE pebble_service_start_duration_seconds histogram
+pebble_service_start_duration_seconds_bucket{service="test1",le="0.1"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="0.5"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="1"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="1.5"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="2"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="5"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="10"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="30"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="60"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="120"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="300"} [01]
+pebble_service_start_duration_seconds_bucket{service="test1",le="\+Inf"} [01]
+pebble_service_start_duration_seconds_sum{service="test1"} [0-9.]+
+pebble_service_start_duration_seconds_count{service="test1"} [01]
+
+# HELP pebble_service_cpu_seconds CPU time used by the service's running processes
+# TYPE pebble_service_cpu_seconds gauge
+pebble_service_cpu_seconds{service="test1"} [0-9.e+-]+
+
+# HELP pebble_service_rss_bytes Resident set size of the service's processes
+# TYPE pebble_service_rss_bytes gauge
+pebble_service_rss_bytes{service="test1"} [0-9]+
+
+# HELP pebble_service_open_fds Number of file descriptors open in the service's processes
+# TYPE pebble_service_open_fds gauge
+pebble_service_open_fds{service="test1"} [0-9]+
+
+# HELP pebble_service_threads Number of threads in the service's processes
+# TYPE pebble_service_threads gauge
+pebble_service_threads{service="test1"} [0-9]+
+
 `[1:]
-	c.Assert(metricsRec.Body.String(), Equals, expected)
+	// The service may still be waiting for the okay delay, in which case its
+	// start duration hasn't been recorded yet.
+	c.Assert(metricsRec.Body.String(), Matches, expected)
 }
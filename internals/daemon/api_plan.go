@@ -32,7 +32,8 @@ func v1GetPlan(c *Command, r *http.Request, _ *UserState) Response {
 	}
 
 	planMgr := overlordPlanManager(c.d.overlord)
-	plan := planMgr.Plan()
+	// Don't expose secrets such as log target passwords.
+	plan := planMgr.Plan().Redacted()
 	planYAML, err := yaml.Marshal(plan)
 	if err != nil {
 		return InternalError("cannot serialize plan: %v", err)
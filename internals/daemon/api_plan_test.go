@@ -84,6 +84,41 @@ services:
 	c.Assert(s.planYAML(c), Equals, expectedYAML)
 }
 
+func (s *apiSuite) TestGetPlanRedacted(c *C) {
+	writeTestLayer(s.pebbleDir, `
+log-targets:
+    tgt1:
+        override: replace
+        type: http-json
+        location: https://collector.example.com/ingest
+        headers:
+            X-Api-Key: secret
+        basic-auth:
+            username: user
+            password: pass
+`)
+	_ = s.daemon(c)
+	planCmd := apiCmd("/v1/plan")
+
+	req, err := http.NewRequest("GET", "/v1/plan?format=yaml", nil)
+	c.Assert(err, IsNil)
+	rsp := v1GetPlan(planCmd, req, nil).(*resp)
+	c.Assert(rsp.Status, Equals, 200)
+	c.Assert(rsp.Result.(string), Equals, `
+log-targets:
+    tgt1:
+        type: http-json
+        location: https://collector.example.com/ingest
+        services: []
+        override: replace
+        headers:
+            X-Api-Key: '*****'
+        basic-auth:
+            username: user
+            password: '*****'
+`[1:])
+}
+
 func (s *apiSuite) planYAML(c *C) string {
 	manager := s.d.overlord.PlanManager()
 	plan := manager.Plan()
@@ -29,20 +29,49 @@ import (
 )
 
 type serviceInfo struct {
-	Name         string     `json:"name"`
-	Startup      string     `json:"startup"`
-	Current      string     `json:"current"`
-	CurrentSince *time.Time `json:"current-since,omitempty"` // pointer as omitempty doesn't work with time.Time directly
+	Name         string               `json:"name"`
+	Startup      string               `json:"startup"`
+	Current      string               `json:"current"`
+	CurrentSince *time.Time           `json:"current-since,omitempty"` // pointer as omitempty doesn't work with time.Time directly
+	Schedule     *serviceScheduleInfo `json:"schedule,omitempty"`
+	Usage        *serviceUsageInfo    `json:"usage,omitempty"`
+}
+
+type serviceScheduleInfo struct {
+	Schedule   string     `json:"schedule"`
+	Next       *time.Time `json:"next,omitempty"`
+	LastRun    *time.Time `json:"last-run,omitempty"`
+	LastResult string     `json:"last-result,omitempty"`
+	Queued     bool       `json:"queued,omitempty"`
+}
+
+type serviceUsageInfo struct {
+	CPUSeconds float64 `json:"cpu-seconds"`
+	RSSBytes   int64   `json:"rss-bytes"`
+	OpenFDs    int     `json:"open-fds"`
+	Threads    int     `json:"threads"`
 }
 
 func v1GetServices(c *Command, r *http.Request, _ *UserState) Response {
-	names := strutil.MultiCommaSeparatedList(r.URL.Query()["names"])
+	query := r.URL.Query()
+	names := strutil.MultiCommaSeparatedList(query["names"])
+	usageStr := query.Get("usage")
+	if usageStr != "" && usageStr != "true" && usageStr != "false" {
+		return BadRequest(`usage parameter must be "true" or "false"`)
+	}
 
 	servmgr := overlordServiceManager(c.d.overlord)
 	services, err := servmgr.Services(names)
 	if err != nil {
 		return InternalError("%v", err)
 	}
+	var usages map[string]*servstate.ServiceUsage
+	if usageStr == "true" {
+		usages, err = servmgr.ServiceUsage(names)
+		if err != nil {
+			return InternalError("%v", err)
+		}
+	}
 
 	infos := make([]serviceInfo, 0, len(services))
 	for _, svc := range services {
@@ -54,6 +83,27 @@ func v1GetServices(c *Command, r *http.Request, _ *UserState) Response {
 		if !svc.CurrentSince.IsZero() {
 			info.CurrentSince = &svc.CurrentSince
 		}
+		if svc.Schedule != nil {
+			info.Schedule = &serviceScheduleInfo{
+				Schedule:   svc.Schedule.Schedule,
+				LastResult: string(svc.Schedule.LastResult),
+				Queued:     svc.Schedule.Queued,
+			}
+			if !svc.Schedule.Next.IsZero() {
+				info.Schedule.Next = &svc.Schedule.Next
+			}
+			if !svc.Schedule.LastRun.IsZero() {
+				info.Schedule.LastRun = &svc.Schedule.LastRun
+			}
+		}
+		if usage, ok := usages[svc.Name]; ok {
+			info.Usage = &serviceUsageInfo{
+				CPUSeconds: usage.CPUTime.Seconds(),
+				RSSBytes:   usage.RSS,
+				OpenFDs:    usage.OpenFDs,
+				Threads:    usage.Threads,
+			}
+		}
 		infos = append(infos, info)
 	}
 	return SyncResponse(infos)
@@ -29,6 +29,7 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/canonical/pebble/internals/overlord/servstate"
 	"github.com/canonical/pebble/internals/overlord/state"
 )
 
@@ -233,6 +234,106 @@ func (s *apiSuite) TestServicesGet(c *C) {
 	})
 }
 
+func (s *apiSuite) TestServicesGetScheduled(c *C) {
+	writeTestLayer(s.pebbleDir, `
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "echo test1"
+        schedule: "00:00-23:59"
+`)
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v1/services", nil)
+	c.Assert(err, IsNil)
+	rsp := v1GetServices(apiCmd("/v1/services"), req, nil).(*resp)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+
+	c.Check(rec.Code, Equals, 200)
+	var body map[string]any
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Check(err, IsNil)
+	result := body["result"].([]any)
+	c.Assert(result, HasLen, 1)
+	info := result[0].(map[string]any)
+	c.Check(info["name"], Equals, "test1")
+	schedule, ok := info["schedule"].(map[string]any)
+	c.Assert(ok, Equals, true)
+	c.Check(schedule["schedule"], Equals, "00:00-23:59")
+	c.Check(schedule["next"], NotNil)
+	c.Check(schedule["last-run"], IsNil)
+}
+
+func (s *apiSuite) TestServicesGetUsage(c *C) {
+	writeTestLayer(s.pebbleDir, `
+services:
+    test1:
+        override: replace
+        command: sleep 10
+    test2:
+        override: replace
+        command: sleep 10
+`)
+	d := s.daemon(c)
+	s.startOverlord()
+
+	payload := bytes.NewBufferString(`{"action": "start", "services": ["test1"]}`)
+	req, err := http.NewRequest("POST", "/v1/services", payload)
+	c.Assert(err, IsNil)
+	rsp := v1PostServices(apiCmd("/v1/services"), req, nil).(*resp)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Result().StatusCode, Equals, 202)
+
+	serviceMgr := d.overlord.ServiceManager()
+	for i := 0; ; i++ {
+		if i > 50 {
+			c.Fatalf("timed out waiting for service to start")
+		}
+		services, err := serviceMgr.Services([]string{"test1"})
+		c.Assert(err, IsNil)
+		if len(services) == 1 && services[0].Current == servstate.StatusActive {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	req, err = http.NewRequest("GET", "/v1/services?usage=true", nil)
+	c.Assert(err, IsNil)
+	rsp = v1GetServices(apiCmd("/v1/services"), req, nil).(*resp)
+	rec = httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+	var body map[string]any
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Assert(err, IsNil)
+	result := body["result"].([]any)
+	c.Assert(result, HasLen, 2)
+
+	// Only running services report their usage.
+	usage, ok := result[0].(map[string]any)["usage"].(map[string]any)
+	c.Assert(ok, Equals, true)
+	c.Check(usage["rss-bytes"].(float64) > 0, Equals, true)
+	c.Check(usage["threads"].(float64) >= 1, Equals, true)
+	c.Check(usage["open-fds"], NotNil)
+	c.Check(usage["cpu-seconds"], NotNil)
+	c.Check(result[1].(map[string]any)["usage"], IsNil)
+}
+
+func (s *apiSuite) TestServicesGetInvalidUsage(c *C) {
+	writeTestLayer(s.pebbleDir, servicesLayer)
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v1/services?usage=foo", nil)
+	c.Assert(err, IsNil)
+	rsp := v1GetServices(apiCmd("/v1/services"), req, nil).(*resp)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 400)
+	c.Check(rsp.Result.(*errorResult).Message, Equals, `usage parameter must be "true" or "false"`)
+}
+
 func (s *apiSuite) TestServicesRestart(c *C) {
 	// Setup
 	writeTestLayer(s.pebbleDir, servicesLayer)
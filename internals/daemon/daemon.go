@@ -48,6 +48,7 @@ import (
 	"github.com/canonical/pebble/internals/overlord/standby"
 	"github.com/canonical/pebble/internals/overlord/state"
 	"github.com/canonical/pebble/internals/overlord/tlsstate"
+	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/reaper"
 	"github.com/canonical/pebble/internals/systemd"
 )
@@ -1074,3 +1075,7 @@ func getListener(socketPath string, listenerMap map[string]net.Listener) (net.Li
 var getChecks = func(o *overlord.Overlord) ([]*checkstate.CheckInfo, error) {
 	return o.CheckManager().Checks()
 }
+
+var getPlan = func(o *overlord.Overlord) *plan.Plan {
+	return o.PlanManager().Plan()
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/canonical/pebble/internals/overlord"
 	"github.com/canonical/pebble/internals/overlord/checkstate"
 	"github.com/canonical/pebble/internals/overlord/state"
+	"github.com/canonical/pebble/internals/plan"
 )
 
 func FakeMuxVars(f func(*http.Request) map[string]string) (restore func()) {
@@ -47,6 +48,14 @@ func FakeGetChecks(f func(o *overlord.Overlord) ([]*checkstate.CheckInfo, error)
 	}
 }
 
+func FakeGetPlan(f func(o *overlord.Overlord) *plan.Plan) (restore func()) {
+	old := getPlan
+	getPlan = f
+	return func() {
+		getPlan = old
+	}
+}
+
 func FakeSyscallSync(f func()) (restore func()) {
 	old := syscallSync
 	syscallSync = f
@@ -45,8 +45,19 @@ func (nullLogger) Debug(string)  {}
 // NullLogger is a logger that does nothing
 var NullLogger = nullLogger{}
 
+// A Forwarder receives a copy of the messages logged, for example to send
+// them to a remote logging system. Its methods are called with the logger's
+// lock held, so they must not log.
+type Forwarder interface {
+	// Notice is called with each notice message (including security events).
+	Notice(msg string)
+	// Security is called with each security event, encoded as JSON.
+	Security(event string)
+}
+
 var (
 	logger     Logger = NullLogger
+	forwarder  Forwarder
 	loggerLock sync.Mutex
 )
 
@@ -56,6 +67,9 @@ func Panicf(format string, v ...any) {
 	defer loggerLock.Unlock()
 	msg := fmt.Sprintf(format, v...)
 	logger.Notice("PANIC " + msg)
+	if forwarder != nil {
+		forwarder.Notice("PANIC " + msg)
+	}
 	panic(msg)
 }
 
@@ -65,6 +79,20 @@ func Noticef(format string, v ...any) {
 	defer loggerLock.Unlock()
 	msg := fmt.Sprintf(format, v...)
 	logger.Notice(msg)
+	if forwarder != nil {
+		forwarder.Notice(msg)
+	}
+}
+
+// LocalNoticef is like Noticef, but the message isn't passed to the
+// forwarder. It's for notices about forwarding itself, such as a failure to
+// send logs to a log target, which could otherwise be forwarded (and fail)
+// in turn.
+func LocalNoticef(format string, v ...any) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	msg := fmt.Sprintf(format, v...)
+	logger.Notice(msg)
 }
 
 // Debugf records something in the debug log
@@ -118,6 +146,10 @@ func securityEvent(level string, event SecurityEvent, arg, description string) {
 		return
 	}
 	logger.Notice(buf.String())
+	if forwarder != nil {
+		forwarder.Notice(buf.String())
+		forwarder.Security(buf.String())
+	}
 }
 
 type SecurityEvent string
@@ -175,6 +207,16 @@ func SetLogger(l Logger) (old Logger) {
 	return old
 }
 
+// SetForwarder sets the forwarder which receives a copy of the messages
+// logged, or removes it if f is nil, and returns the previous one.
+func SetForwarder(f Forwarder) (old Forwarder) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	old = forwarder
+	forwarder = f
+	return old
+}
+
 type defaultLogger struct {
 	w      io.Writer
 	prefix string
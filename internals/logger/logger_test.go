@@ -69,6 +69,11 @@ func (s *LogSuite) TestNoticef(c *C) {
 	c.Check(s.logbuf.String(), Matches, `2\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\d\.\d\d\dZ PREFIX: xyzzy\n`)
 }
 
+func (s *LogSuite) TestLocalNoticef(c *C) {
+	logger.LocalNoticef("xyzzy")
+	c.Check(s.logbuf.String(), Matches, `2\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\d\.\d\d\dZ PREFIX: xyzzy\n`)
+}
+
 func (s *LogSuite) TestNewline(c *C) {
 	logger.Noticef("with newline\n")
 	c.Check(s.logbuf.String(), Matches, `2\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\d\.\d\d\dZ PREFIX: with newline\n`)
@@ -95,6 +100,41 @@ func (s *LogSuite) TestSecurityCritical(c *C) {
 	)
 }
 
+type testForwarder struct {
+	notices  []string
+	security []string
+}
+
+func (f *testForwarder) Notice(msg string) {
+	f.notices = append(f.notices, msg)
+}
+
+func (f *testForwarder) Security(event string) {
+	f.security = append(f.security, event)
+}
+
+func (s *LogSuite) TestForwarder(c *C) {
+	f := &testForwarder{}
+	old := logger.SetForwarder(f)
+	c.Check(old, IsNil)
+
+	logger.Noticef("foo %d", 42)
+	logger.Debugf("not forwarded")
+	logger.LocalNoticef("not forwarded either")
+	logger.SecurityWarn(logger.SecuritySysShutdown, "", "")
+	c.Check(func() { logger.Panicf("bar") }, Panics, "bar")
+
+	old = logger.SetForwarder(nil)
+	c.Check(old, Equals, f)
+	logger.Noticef("after removing")
+
+	c.Assert(f.notices, HasLen, 3)
+	c.Check(f.notices[0], Equals, "foo 42")
+	c.Check(f.notices[1], Matches, `\{"type":"security",.*"level":"WARN","event":"sys_shutdown",.*\}\n`)
+	c.Check(f.notices[2], Equals, "PANIC bar")
+	c.Check(f.security, DeepEquals, f.notices[1:2])
+}
+
 func (s *LogSuite) TestMockLoggerReadWriteThreadsafe(c *C) {
 	var t tomb.Tomb
 	t.Go(func() error {
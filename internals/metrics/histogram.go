@@ -0,0 +1,143 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// DurationBuckets are histogram bucket upper bounds suitable for durations
+// in seconds, from 5 milliseconds to 10 seconds.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations in buckets. It's safe for concurrent use.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // non-cumulative count for each bound
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, which
+// must be in increasing order.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+// Observe records a single observation.
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i, _ := slices.BinarySearch(h.bounds, value)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+}
+
+// Metric returns the histogram's current state as a TypeHistogram metric.
+func (h *Histogram) Metric(name, comment string, labels ...Label) Metric {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make([]Bucket, len(h.bounds))
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+	}
+	return Metric{
+		Name:    name,
+		Type:    TypeHistogram,
+		Comment: comment,
+		Labels:  labels,
+		Buckets: buckets,
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+}
+
+// summaryMaxSamples is the number of most recent observations a Summary
+// uses to calculate its quantiles.
+const summaryMaxSamples = 1024
+
+// Summary calculates quantiles over recent observations. The count and sum
+// include all observations, but the quantiles are calculated over the most
+// recent ones only. It's safe for concurrent use.
+type Summary struct {
+	mu        sync.Mutex
+	quantiles []float64
+	samples   []float64 // ring buffer of recent observations
+	next      int
+	count     uint64
+	sum       float64
+}
+
+// NewSummary creates a summary that reports the given quantiles, each
+// between 0 and 1.
+func NewSummary(quantiles []float64) *Summary {
+	return &Summary{quantiles: quantiles}
+}
+
+// Observe records a single observation.
+func (s *Summary) Observe(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.samples) < summaryMaxSamples {
+		s.samples = append(s.samples, value)
+	} else {
+		s.samples[s.next] = value
+		s.next = (s.next + 1) % summaryMaxSamples
+	}
+	s.count++
+	s.sum += value
+}
+
+// Metric returns the summary's current state as a TypeSummary metric.
+func (s *Summary) Metric(name, comment string, labels ...Label) Metric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := slices.Clone(s.samples)
+	slices.Sort(sorted)
+	quantiles := make([]Quantile, len(s.quantiles))
+	for i, q := range s.quantiles {
+		value := math.NaN()
+		if len(sorted) > 0 {
+			// Nearest-rank method.
+			rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+			value = sorted[max(min(rank, len(sorted)-1), 0)]
+		}
+		quantiles[i] = Quantile{Quantile: q, Value: value}
+	}
+	return Metric{
+		Name:      name,
+		Type:      TypeSummary,
+		Comment:   comment,
+		Labels:    labels,
+		Quantiles: quantiles,
+		Count:     s.count,
+		Sum:       s.sum,
+	}
+}
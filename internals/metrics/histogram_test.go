@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metrics_test
+
+import (
+	"math"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/metrics"
+)
+
+type HistogramSuite struct{}
+
+var _ = Suite(&HistogramSuite{})
+
+func (s *HistogramSuite) TestHistogram(c *C) {
+	h := metrics.NewHistogram([]float64{0.1, 1, 10})
+	for _, v := range []float64{0.05, 0.1, 0.5, 20} {
+		h.Observe(v)
+	}
+	m := h.Metric("latency_seconds", "Latency", metrics.NewLabel("check", "chk1"))
+	c.Check(m, DeepEquals, metrics.Metric{
+		Name:    "latency_seconds",
+		Type:    metrics.TypeHistogram,
+		Comment: "Latency",
+		Labels:  []metrics.Label{metrics.NewLabel("check", "chk1")},
+		Buckets: []metrics.Bucket{
+			{UpperBound: 0.1, Count: 2},
+			{UpperBound: 1, Count: 3},
+			{UpperBound: 10, Count: 3},
+		},
+		Count: 4,
+		Sum:   20.65,
+	})
+}
+
+func (s *HistogramSuite) TestSummary(c *C) {
+	summary := metrics.NewSummary([]float64{0.5, 0.9, 1})
+	m := summary.Metric("empty", "")
+	c.Assert(m.Quantiles, HasLen, 3)
+	c.Check(math.IsNaN(m.Quantiles[0].Value), Equals, true)
+	c.Check(m.Count, Equals, uint64(0))
+
+	for i := 1; i <= 10; i++ {
+		summary.Observe(float64(i))
+	}
+	m = summary.Metric("size", "Size")
+	c.Check(m.Type, Equals, metrics.TypeSummary)
+	c.Check(m.Quantiles, DeepEquals, []metrics.Quantile{
+		{Quantile: 0.5, Value: 5},
+		{Quantile: 0.9, Value: 9},
+		{Quantile: 1, Value: 10},
+	})
+	c.Check(m.Count, Equals, uint64(10))
+	c.Check(m.Sum, Equals, float64(55))
+}
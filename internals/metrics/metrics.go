@@ -17,6 +17,8 @@ package metrics
 import (
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 )
 
 type MetricType int
@@ -24,14 +26,21 @@ type MetricType int
 const (
 	TypeCounterInt MetricType = iota + 1
 	TypeGaugeInt
+	TypeGaugeFloat
+	TypeHistogram
+	TypeSummary
 )
 
 func (mt MetricType) String() string {
 	switch mt {
 	case TypeCounterInt:
 		return "counter"
-	case TypeGaugeInt:
+	case TypeGaugeInt, TypeGaugeFloat:
 		return "gauge"
+	case TypeHistogram:
+		return "histogram"
+	case TypeSummary:
+		return "summary"
 	default:
 		panic(fmt.Sprintf("internal error: invalid metric type %d", mt))
 	}
@@ -39,11 +48,45 @@ func (mt MetricType) String() string {
 
 // Metric represents a single metric.
 type Metric struct {
-	Name       string
-	Type       MetricType
+	Name    string
+	Type    MetricType
+	Comment string
+	Labels  []Label
+
+	// ValueInt64 is the value of TypeCounterInt and TypeGaugeInt metrics.
 	ValueInt64 int64
-	Comment    string
-	Labels     []Label
+
+	// ValueFloat64 is the value of TypeGaugeFloat metrics.
+	ValueFloat64 float64
+
+	// Buckets are the buckets of a TypeHistogram metric, in increasing
+	// order of upper bound. The "+Inf" bucket is implied by Count.
+	Buckets []Bucket
+
+	// Quantiles are the quantiles of a TypeSummary metric.
+	Quantiles []Quantile
+
+	// Count and Sum are the number and sum of the observations of a
+	// TypeHistogram or TypeSummary metric.
+	Count uint64
+	Sum   float64
+}
+
+// Bucket is a histogram bucket.
+type Bucket struct {
+	// UpperBound is the bucket's inclusive upper bound.
+	UpperBound float64
+
+	// Count is the number of observations less than or equal to UpperBound
+	// (so bucket counts are cumulative).
+	Count uint64
+}
+
+// Quantile is a summary quantile, for example the 0.9 quantile (90th
+// percentile) of the observations.
+type Quantile struct {
+	Quantile float64
+	Value    float64
 }
 
 // Label represents a label for metrics.
@@ -57,6 +100,16 @@ func NewLabel(key, value string) Label {
 	return Label{key, value}
 }
 
+// Key returns the label's key.
+func (l Label) Key() string {
+	return l.key
+}
+
+// Value returns the label's value.
+func (l Label) Value() string {
+	return l.value
+}
+
 type Writer interface {
 	Write(Metric) error
 }
@@ -85,18 +138,68 @@ func (otw *OpenTelemetryWriter) Write(m Metric) error {
 		return err
 	}
 
-	_, err = io.WriteString(otw.w, m.Name)
+	switch m.Type {
+	case TypeGaugeFloat:
+		err = otw.writeSample(m.Name, m.Labels, nil, formatFloat(m.ValueFloat64))
+	case TypeHistogram:
+		for _, bucket := range m.Buckets {
+			le := NewLabel("le", formatFloat(bucket.UpperBound))
+			err = otw.writeSample(m.Name+"_bucket", m.Labels, &le, strconv.FormatUint(bucket.Count, 10))
+			if err != nil {
+				return err
+			}
+		}
+		le := NewLabel("le", "+Inf")
+		err = otw.writeSample(m.Name+"_bucket", m.Labels, &le, strconv.FormatUint(m.Count, 10))
+		if err == nil {
+			err = otw.writeSumCount(m)
+		}
+	case TypeSummary:
+		for _, quantile := range m.Quantiles {
+			q := NewLabel("quantile", formatFloat(quantile.Quantile))
+			err = otw.writeSample(m.Name, m.Labels, &q, formatFloat(quantile.Value))
+			if err != nil {
+				return err
+			}
+		}
+		err = otw.writeSumCount(m)
+	default:
+		err = otw.writeSample(m.Name, m.Labels, nil, strconv.FormatInt(m.ValueInt64, 10))
+	}
 	if err != nil {
 		return err
 	}
 
-	if len(m.Labels) > 0 {
+	_, err = io.WriteString(otw.w, "\n")
+	return err
+}
+
+func (otw *OpenTelemetryWriter) writeSumCount(m Metric) error {
+	err := otw.writeSample(m.Name+"_sum", m.Labels, nil, formatFloat(m.Sum))
+	if err != nil {
+		return err
+	}
+	return otw.writeSample(m.Name+"_count", m.Labels, nil, strconv.FormatUint(m.Count, 10))
+}
+
+// writeSample writes a single sample line. The extra label, if not nil, is
+// added after the metric's labels (for example, a histogram's "le" label).
+func (otw *OpenTelemetryWriter) writeSample(name string, labels []Label, extra *Label, value string) error {
+	_, err := io.WriteString(otw.w, name)
+	if err != nil {
+		return err
+	}
+
+	if extra != nil {
+		labels = append(labels[:len(labels):len(labels)], *extra)
+	}
+	if len(labels) > 0 {
 		_, err = io.WriteString(otw.w, "{")
 		if err != nil {
 			return err
 		}
 
-		for i, label := range m.Labels {
+		for i, label := range labels {
 			if i > 0 {
 				_, err = io.WriteString(otw.w, ",")
 				if err != nil {
@@ -115,10 +218,20 @@ func (otw *OpenTelemetryWriter) Write(m Metric) error {
 		}
 	}
 
-	_, err = fmt.Fprintf(otw.w, " %d\n\n", m.ValueInt64)
-	if err != nil {
-		return err
-	}
+	_, err = fmt.Fprintf(otw.w, " %s\n", value)
+	return err
+}
 
-	return nil
+// formatFloat formats a float value as expected by the exposition format.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
 }
@@ -104,6 +104,70 @@ no_comment_metric{env="prod"} 42
 # TYPE special_chars gauge
 special_chars{key_with_underscore="value_with_underscore",key-with-dash="value-with-dash"} 42
 
+`[1:],
+		},
+		{
+			name: "GaugeFloat",
+			metric: metrics.Metric{
+				Name:         "my_float_gauge",
+				Type:         metrics.TypeGaugeFloat,
+				ValueFloat64: 12.5,
+				Comment:      "A float gauge",
+				Labels:       []metrics.Label{metrics.NewLabel("service", "svc1")},
+			},
+			expected: `
+# HELP my_float_gauge A float gauge
+# TYPE my_float_gauge gauge
+my_float_gauge{service="svc1"} 12.5
+
+`[1:],
+		},
+		{
+			name: "Histogram",
+			metric: metrics.Metric{
+				Name:    "my_histogram",
+				Type:    metrics.TypeHistogram,
+				Comment: "A histogram",
+				Labels:  []metrics.Label{metrics.NewLabel("check", "chk1")},
+				Buckets: []metrics.Bucket{
+					{UpperBound: 0.1, Count: 1},
+					{UpperBound: 1, Count: 3},
+				},
+				Count: 4,
+				Sum:   5.25,
+			},
+			expected: `
+# HELP my_histogram A histogram
+# TYPE my_histogram histogram
+my_histogram_bucket{check="chk1",le="0.1"} 1
+my_histogram_bucket{check="chk1",le="1"} 3
+my_histogram_bucket{check="chk1",le="+Inf"} 4
+my_histogram_sum{check="chk1"} 5.25
+my_histogram_count{check="chk1"} 4
+
+`[1:],
+		},
+		{
+			name: "Summary",
+			metric: metrics.Metric{
+				Name:    "my_summary",
+				Type:    metrics.TypeSummary,
+				Comment: "A summary",
+				Quantiles: []metrics.Quantile{
+					{Quantile: 0.5, Value: 0.25},
+					{Quantile: 0.99, Value: 2},
+				},
+				Count: 10,
+				Sum:   4,
+			},
+			expected: `
+# HELP my_summary A summary
+# TYPE my_summary summary
+my_summary{quantile="0.5"} 0.25
+my_summary{quantile="0.99"} 2
+my_summary_sum 4
+my_summary_count 10
+
 `[1:],
 		},
 	}
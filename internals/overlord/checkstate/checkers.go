@@ -15,14 +15,22 @@
 package checkstate
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -32,27 +40,111 @@ import (
 
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/osutil"
+	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/reaper"
 	"github.com/canonical/pebble/internals/servicelog"
+	"github.com/canonical/pebble/internals/tlsutil"
 )
 
 const (
-	maxErrorBytes = 512
-	maxErrorLines = 5
-	execWaitDelay = time.Second
+	maxErrorBytes    = 512
+	maxResponseBytes = 64 * 1024
+	maxErrorLines    = 5
+	execWaitDelay    = time.Second
+	logParserSize    = 4 * 1024
 )
 
-// httpChecker is a checker that ensures an HTTP GET at a specified URL returns 2xx.
+// httpChecker is a checker that ensures an HTTP request to a specified URL
+// returns 2xx (or one of the configured status codes), and that the response
+// body passes any configured assertions.
 type httpChecker struct {
-	name    string
-	url     string
-	headers map[string]string
+	name          string
+	url           string
+	headers       map[string]string
+	method        string
+	body          string
+	status        []int
+	responseMatch *regexp.Regexp
+	responseJSON  map[string]string
+	// noFollowRedirects is set to check redirect responses rather than
+	// following them.
+	noFollowRedirects bool
+	tls               *checkTLS
+	// warnf, if set, is called to record a warning, such as when the
+	// server's certificate is about to expire.
+	warnf func(format string, args ...any)
+}
+
+// checkTLS holds the TLS options of an HTTP or gRPC checker.
+type checkTLS struct {
+	options       plan.TLSOptions
+	expiryWarning time.Duration
+	expiryFailure time.Duration
+}
+
+// config builds the TLS configuration. The certificate files are read each
+// time so that renewed certificates are picked up without a replan.
+func (t *checkTLS) config() (*tls.Config, error) {
+	return tlsutil.ClientConfig(&t.options)
+}
+
+// checkExpiry returns an error if a certificate in the server's chain
+// expires within the expiry-failure threshold, or records a warning if one
+// expires within the expiry-warning threshold.
+func (t *checkTLS) checkExpiry(checkName, target string, certs []*x509.Certificate, warnf func(format string, args ...any)) error {
+	if len(certs) == 0 || (t.expiryWarning == 0 && t.expiryFailure == 0) {
+		return nil
+	}
+	notAfter := certs[0].NotAfter
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	remaining := time.Until(notAfter)
+	expiry := notAfter.UTC().Format(time.RFC3339)
+	if t.expiryFailure > 0 && remaining < t.expiryFailure {
+		return fmt.Errorf("server certificate expires at %s, within %v", expiry, t.expiryFailure)
+	}
+	if t.expiryWarning > 0 && remaining < t.expiryWarning {
+		if warnf != nil {
+			// The expiry time (not the remaining time) is used in the
+			// message so that repeated warnings are combined.
+			warnf("Check %q: server certificate for %s expires at %s", checkName, target, expiry)
+		}
+	}
+	return nil
 }
 
 func (c *httpChecker) check(ctx context.Context) error {
-	logger.Debugf("Check %q (http): requesting %q", c.name, c.url)
+	method := c.method
+	if method == "" {
+		method = "GET"
+	}
+	logger.Debugf("Check %q (http): requesting %s %q", c.name, method, c.url)
 	client := &http.Client{}
-	request, err := http.NewRequestWithContext(ctx, "GET", c.url, nil)
+	if c.tls != nil {
+		tlsConfig, err := c.tls.config()
+		if err != nil {
+			return err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		// A new transport is used for each check, so don't leave idle
+		// connections behind.
+		transport.DisableKeepAlives = true
+		client.Transport = transport
+	}
+	if c.noFollowRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	var body io.Reader
+	if c.body != "" {
+		body = strings.NewReader(c.body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.url, body)
 	if err != nil {
 		return fmt.Errorf("cannot build request: %w", err)
 	}
@@ -66,13 +158,29 @@ func (c *httpChecker) check(ctx context.Context) error {
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode < 200 || response.StatusCode > 299 {
-		// Include first few lines of response body in error details
-		output, err := io.ReadAll(io.LimitReader(response.Body, maxErrorBytes))
-		details := ""
+	if c.tls != nil && response.TLS != nil {
+		err := c.tls.checkExpiry(c.name, c.url, response.TLS.PeerCertificates, c.warnf)
 		if err != nil {
-			details = fmt.Sprintf("cannot read response: %v", err)
+			return err
+		}
+	}
+
+	// Only read as much of the body as needed for the assertions (if any),
+	// or to include in the error details.
+	limit := int64(maxErrorBytes)
+	if c.responseMatch != nil || len(c.responseJSON) > 0 {
+		limit = maxResponseBytes
+	}
+	output, readErr := io.ReadAll(io.LimitReader(response.Body, limit))
+	checkErr := func(err error) error {
+		details := ""
+		if readErr != nil {
+			details = fmt.Sprintf("cannot read response: %v", readErr)
 		} else {
+			// Include first few lines of response body in error details
+			if len(output) > maxErrorBytes {
+				output = output[:maxErrorBytes]
+			}
 			lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 			if len(lines) > maxErrorLines {
 				lines = lines[:maxErrorLines+1]
@@ -80,14 +188,77 @@ func (c *httpChecker) check(ctx context.Context) error {
 			}
 			details = strings.Join(lines, "\n")
 		}
-		return &detailsError{
-			error:   fmt.Errorf("non-2xx status code %d", response.StatusCode),
-			details: details,
+		return &detailsError{error: err, details: details}
+	}
+
+	if len(c.status) > 0 {
+		if !slices.Contains(c.status, response.StatusCode) {
+			return checkErr(fmt.Errorf("status code %d not in %v", response.StatusCode, c.status))
+		}
+	} else if response.StatusCode < 200 || response.StatusCode > 299 {
+		return checkErr(fmt.Errorf("non-2xx status code %d", response.StatusCode))
+	}
+	if readErr != nil && (c.responseMatch != nil || len(c.responseJSON) > 0) {
+		return checkErr(errors.New("cannot read response body"))
+	}
+
+	if c.responseMatch != nil && !c.responseMatch.Match(output) {
+		return checkErr(fmt.Errorf("response body does not match %q", c.responseMatch))
+	}
+
+	if len(c.responseJSON) > 0 {
+		var value any
+		err := json.Unmarshal(output, &value)
+		if err != nil {
+			return checkErr(fmt.Errorf("cannot decode response body as JSON: %w", err))
+		}
+		paths := slices.Sorted(maps.Keys(c.responseJSON))
+		for _, path := range paths {
+			expected := c.responseJSON[path]
+			actual, ok := jsonPathValue(value, path)
+			if !ok {
+				return checkErr(fmt.Errorf("response JSON has no value at %q", path))
+			}
+			if actual != expected {
+				return checkErr(fmt.Errorf("response JSON %q is %q, not %q", path, actual, expected))
+			}
 		}
 	}
 	return nil
 }
 
+// jsonPathValue returns the value at the given dot-separated path in a
+// decoded JSON value, where each element of the path is an object key or
+// an array index. Strings are returned as is, and other values as JSON.
+func jsonPathValue(value any, path string) (string, bool) {
+	for _, elem := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			value, ok = v[elem]
+			if !ok {
+				return "", false
+			}
+		case []any:
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			value = v[i]
+		default:
+			return "", false
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
 // tcpChecker is a checker that ensures a TCP port is open.
 type tcpChecker struct {
 	name string
@@ -115,6 +286,197 @@ func (c *tcpChecker) check(ctx context.Context) error {
 	return nil
 }
 
+// grpcChecker is a checker that calls the standard gRPC health checking
+// method (grpc.health.v1.Health/Check) and ensures the service is serving.
+// It uses HTTP/2 directly rather than a gRPC library, as it only needs to
+// encode and decode two trivial messages.
+type grpcChecker struct {
+	name    string
+	address string
+	service string
+	tls     *checkTLS
+	// warnf, if set, is called to record a warning, such as when the
+	// server's certificate is about to expire.
+	warnf func(format string, args ...any)
+}
+
+// Values of the grpc.health.v1.HealthCheckResponse.ServingStatus enum.
+var grpcServingStatuses = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// Names of the gRPC status codes.
+var grpcStatusCodes = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+func (c *grpcChecker) check(ctx context.Context) error {
+	logger.Debugf("Check %q (grpc): checking service %q at %s", c.name, c.service, c.address)
+
+	// A new transport is used for each check, so don't leave idle
+	// connections behind.
+	transport := &http.Transport{DisableKeepAlives: true}
+	protocols := &http.Protocols{}
+	scheme := "http"
+	if c.tls != nil {
+		tlsConfig, err := c.tls.config()
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig = tlsConfig
+		protocols.SetHTTP2(true)
+		scheme = "https"
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport.Protocols = protocols
+	client := &http.Client{Transport: transport}
+
+	target := scheme + "://" + c.address + "/grpc.health.v1.Health/Check"
+	request, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(grpcHealthCheckRequest(c.service)))
+	if err != nil {
+		return fmt.Errorf("cannot build request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("TE", "trailers")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if c.tls != nil && response.TLS != nil {
+		err := c.tls.checkExpiry(c.name, c.address, response.TLS.PeerCertificates, c.warnf)
+		if err != nil {
+			return err
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 HTTP status code %d", response.StatusCode)
+	}
+	// The trailers are only available once the body has been read.
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("cannot read response: %w", err)
+	}
+
+	// A response without a message has its status in the headers.
+	grpcStatus := response.Trailer.Get("Grpc-Status")
+	grpcMessage := response.Trailer.Get("Grpc-Message")
+	if grpcStatus == "" {
+		grpcStatus = response.Header.Get("Grpc-Status")
+		grpcMessage = response.Header.Get("Grpc-Message")
+	}
+	if grpcStatus != "0" {
+		code, err := strconv.Atoi(grpcStatus)
+		if err == nil && code > 0 && code < len(grpcStatusCodes) {
+			grpcStatus = grpcStatusCodes[code]
+		}
+		if message, err := url.PathUnescape(grpcMessage); err == nil {
+			grpcMessage = message
+		}
+		if grpcMessage != "" {
+			return fmt.Errorf("gRPC status %s: %s", grpcStatus, grpcMessage)
+		}
+		return fmt.Errorf("gRPC status %s", grpcStatus)
+	}
+
+	status, err := parseGRPCHealthCheckResponse(body)
+	if err != nil {
+		return fmt.Errorf("cannot parse response: %w", err)
+	}
+	if status != 1 {
+		name, ok := grpcServingStatuses[status]
+		if !ok {
+			name = strconv.FormatUint(status, 10)
+		}
+		if c.service != "" {
+			return fmt.Errorf("service %q is %s", c.service, name)
+		}
+		return fmt.Errorf("server is %s", name)
+	}
+	return nil
+}
+
+// grpcHealthCheckRequest returns the length-prefixed message for a
+// HealthCheckRequest with the given service name (field 1).
+func grpcHealthCheckRequest(service string) []byte {
+	var message []byte
+	if service != "" {
+		message = append(message, 1<<3|2) // field 1, length-delimited
+		message = binary.AppendUvarint(message, uint64(len(service)))
+		message = append(message, service...)
+	}
+	frame := []byte{0} // not compressed
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(message)))
+	return append(frame, message...)
+}
+
+// parseGRPCHealthCheckResponse parses a length-prefixed HealthCheckResponse
+// message, and returns its status (field 1).
+func parseGRPCHealthCheckResponse(frame []byte) (uint64, error) {
+	if len(frame) < 5 {
+		return 0, errors.New("message too short")
+	}
+	if frame[0] != 0 {
+		return 0, errors.New("compressed messages not supported")
+	}
+	length := binary.BigEndian.Uint32(frame[1:5])
+	message := frame[5:]
+	if uint32(len(message)) < length {
+		return 0, errors.New("message truncated")
+	}
+	message = message[:length]
+
+	var status uint64
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, errors.New("invalid field key")
+		}
+		message = message[n:]
+		field, wireType := key>>3, key&7
+		switch wireType {
+		case 0: // varint
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, errors.New("invalid varint")
+			}
+			message = message[n:]
+			if field == 1 {
+				status = value
+			}
+		case 1: // 64-bit
+			if len(message) < 8 {
+				return 0, errors.New("message truncated")
+			}
+			message = message[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, errors.New("message truncated")
+			}
+			message = message[n+int(length):]
+		case 5: // 32-bit
+			if len(message) < 4 {
+				return 0, errors.New("message truncated")
+			}
+			message = message[4:]
+		default:
+			return 0, fmt.Errorf("invalid wire type %d", wireType)
+		}
+	}
+	return status, nil
+}
+
 // execChecker is a checker that ensures a command executes successfully.
 type execChecker struct {
 	name        string
@@ -198,6 +560,98 @@ func (c *execChecker) check(ctx context.Context) error {
 	return nil
 }
 
+// logChecker is a checker that fails while a service's output has matched
+// a pattern within a sliding window. It keeps an iterator on the service's
+// log buffer, so each check only reads the output written since the last.
+type logChecker struct {
+	name      string
+	service   string
+	pattern   *regexp.Regexp
+	window    time.Duration
+	logBuffer LogBufferFunc
+
+	buffer   *servicelog.RingBuffer
+	iterator servicelog.Iterator
+	parser   *servicelog.Parser
+
+	// matchTimes holds the times of the matching lines in the window, oldest
+	// first, and lastMatches the most recent matching lines.
+	matchTimes  []time.Time
+	lastMatches []string
+}
+
+func (c *logChecker) check(ctx context.Context) error {
+	logger.Debugf("Check %q (log): reading output of service %q", c.name, c.service)
+
+	cutoff := time.Now().Add(-c.window)
+	if c.logBuffer != nil {
+		c.read(cutoff)
+	}
+
+	// Forget matches that have left the window.
+	i := 0
+	for i < len(c.matchTimes) && !c.matchTimes[i].After(cutoff) {
+		i++
+	}
+	c.matchTimes = c.matchTimes[i:]
+	if len(c.matchTimes) == 0 {
+		c.lastMatches = nil
+		return nil
+	}
+	return &detailsError{
+		error: fmt.Errorf("output of service %q matched %q %s in the last %v",
+			c.service, c.pattern, pluralise(len(c.matchTimes), "time", "times"), c.window),
+		details: strings.Join(c.lastMatches, "\n"),
+	}
+}
+
+// read reads the service's output since the last check, recording the
+// lines that match the pattern. When the check first sees a log buffer, it
+// reads all the output in it, so that a new checker (for example, one
+// recovering the check) finds the matches still in the window.
+func (c *logChecker) read(cutoff time.Time) {
+	buffer := c.logBuffer(c.service)
+	if buffer != c.buffer {
+		c.Close()
+		c.buffer = buffer
+		if buffer != nil {
+			c.iterator = buffer.TailIterator()
+			c.parser = servicelog.NewParser(c.iterator, logParserSize)
+		}
+	}
+	if c.iterator == nil {
+		return
+	}
+	for c.iterator.Next(nil) {
+		for c.parser.Next() {
+			entry := c.parser.Entry()
+			if !entry.Time.After(cutoff) || !c.pattern.MatchString(entry.Message) {
+				continue
+			}
+			c.matchTimes = append(c.matchTimes, entry.Time)
+			c.lastMatches = append(c.lastMatches, strings.TrimRight(entry.Message, "\n"))
+			if len(c.lastMatches) > maxErrorLines {
+				c.lastMatches = c.lastMatches[1:]
+			}
+		}
+		if err := c.parser.Err(); err != nil {
+			logger.Noticef("Check %q (log): cannot read output of service %q: %v", c.name, c.service, err)
+			return
+		}
+	}
+}
+
+// Close closes the iterator on the service's log buffer, if any.
+func (c *logChecker) Close() error {
+	if c.iterator == nil {
+		return nil
+	}
+	err := c.iterator.Close()
+	c.iterator = nil
+	c.parser = nil
+	return err
+}
+
 type detailsError struct {
 	error
 	details string
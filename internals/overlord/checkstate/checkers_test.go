@@ -17,18 +17,32 @@ package checkstate
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"log"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/user"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"time"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/reaper"
+	"github.com/canonical/pebble/internals/servicelog"
 )
 
 type CheckersSuite struct{}
@@ -119,6 +133,218 @@ func (s *CheckersSuite) TestHTTP(c *C) {
 	c.Assert(err, ErrorMatches, "cannot build request: .*")
 }
 
+func (s *CheckersSuite) TestHTTPAssertions(c *C) {
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		data, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		body = string(data)
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/health", http.StatusFound)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, "created")
+		case "/health":
+			fmt.Fprint(w, `{"status": "degraded", "checks": {"db": {"up": true}}, "items": [1, "two"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Method and body are sent.
+	chk := &httpChecker{url: server.URL + "/health", method: "POST", body: "probe"}
+	err := chk.check(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(method, Equals, "POST")
+	c.Check(body, Equals, "probe")
+
+	// Only the given status codes are successful.
+	chk = &httpChecker{url: server.URL + "/created", status: []int{200, 204}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `status code 201 not in \[200 204\]`)
+	c.Check(err.(*detailsError).Details(), Equals, "created")
+	chk = &httpChecker{url: server.URL + "/404", status: []int{404}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Redirects are followed unless disabled.
+	chk = &httpChecker{url: server.URL + "/redirect"}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	chk = &httpChecker{url: server.URL + "/redirect", noFollowRedirects: true}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, "non-2xx status code 302")
+	chk = &httpChecker{url: server.URL + "/redirect", noFollowRedirects: true, status: []int{302}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// The response body must match the regexp.
+	chk = &httpChecker{url: server.URL + "/health", responseMatch: regexp.MustCompile(`"status": "(ok|degraded)"`)}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	chk = &httpChecker{url: server.URL + "/health", responseMatch: regexp.MustCompile(`"status": "ok"`)}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `response body does not match "\\"status\\": \\"ok\\""`)
+	c.Check(err.(*detailsError).Details(), Matches, `\{"status": "degraded".*`)
+
+	// The values in the JSON response must be as expected.
+	chk = &httpChecker{url: server.URL + "/health", responseJSON: map[string]string{
+		"checks.db.up": "true",
+		"items.1":      "two",
+	}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	chk = &httpChecker{url: server.URL + "/health", responseJSON: map[string]string{
+		"checks.db.up": "true",
+		"status":       "ok",
+	}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `response JSON "status" is "degraded", not "ok"`)
+	chk = &httpChecker{url: server.URL + "/health", responseJSON: map[string]string{"items.2": "three"}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `response JSON has no value at "items.2"`)
+	chk = &httpChecker{url: server.URL + "/created", status: []int{201}, responseJSON: map[string]string{"status": "ok"}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `cannot decode response body as JSON: .*`)
+	c.Check(err.(*detailsError).Details(), Equals, "created")
+}
+
+func (s *CheckersSuite) TestCheckTLS(c *C) {
+	dir := c.MkDir()
+	clientCert, clientKey := writeTestCert(c, dir)
+	clientPEM, err := os.ReadFile(clientCert)
+	c.Assert(err, IsNil)
+	clientCAs := x509.NewCertPool()
+	c.Assert(clientCAs.AppendCertsFromPEM(clientPEM), Equals, true)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+	caCert := filepath.Join(dir, "ca.pem")
+	err = os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+	c.Assert(err, IsNil)
+
+	// Without the CA certificate the server isn't trusted
+	chk := &httpChecker{url: server.URL, tls: &checkTLS{}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, ".*certificate.*")
+
+	// With the CA certificate, or without verification, the check succeeds
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert}}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	insecureSkipVerify := true
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{InsecureSkipVerify: &insecureSkipVerify}}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// The server name used for verification can be overridden
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert, ServerName: "example.com"}}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert, ServerName: "other.test"}}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, ".*certificate is valid for .*, not other.test")
+
+	// Client certificate is sent and verified
+	server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert}}}
+	err = chk.check(context.Background())
+	c.Assert(err, NotNil)
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey}}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Invalid files are reported
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: filepath.Join(dir, "missing.pem")}}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, "cannot read CA certificate: .*")
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: clientKey}}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `cannot parse CA certificate ".*": no PEM certificates found`)
+	chk = &httpChecker{url: server.URL, tls: &checkTLS{options: plan.TLSOptions{CACert: caCert, ClientCert: clientCert, ClientKey: caCert}}}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, "cannot load client certificate: .*")
+}
+
+func (s *CheckersSuite) TestCheckTLSExpiry(c *C) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	remaining := time.Until(server.Certificate().NotAfter)
+	expiry := server.Certificate().NotAfter.UTC().Format(time.RFC3339)
+
+	var warnings []string
+	warnf := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	// Certificate doesn't expire within the thresholds
+	insecureSkipVerify := true
+	chk := &httpChecker{name: "chk", url: server.URL, warnf: warnf, tls: &checkTLS{
+		options:       plan.TLSOptions{InsecureSkipVerify: &insecureSkipVerify},
+		expiryWarning: remaining - time.Hour,
+		expiryFailure: remaining - 2*time.Hour,
+	}}
+	err := chk.check(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(warnings, HasLen, 0)
+
+	// Certificate expires within the warning threshold
+	chk.tls.expiryWarning = remaining + time.Hour
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(warnings, DeepEquals, []string{
+		fmt.Sprintf(`Check "chk": server certificate for %s expires at %s`, server.URL, expiry),
+	})
+
+	// Certificate expires within the failure threshold
+	chk.tls.expiryFailure = remaining + time.Hour
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, "server certificate expires at "+expiry+", within .*")
+	c.Assert(warnings, HasLen, 1)
+}
+
+// writeTestCert writes a self-signed certificate and its private key to
+// the given directory, and returns their paths.
+func writeTestCert(c *C, dir string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pebble-check"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	c.Assert(err, IsNil)
+
+	certPath = filepath.Join(dir, "client.pem")
+	err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	c.Assert(err, IsNil)
+	keyPath = filepath.Join(dir, "client.key")
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	c.Assert(err, IsNil)
+	return certPath, keyPath
+}
+
 func (s *CheckersSuite) TestTCP(c *C) {
 	listener, err := net.Listen("tcp", "localhost:")
 	c.Assert(err, IsNil)
@@ -154,6 +380,135 @@ func (s *CheckersSuite) TestTCP(c *C) {
 	c.Assert(err, ErrorMatches, ".* connection refused")
 }
 
+func (s *CheckersSuite) TestGRPC(c *C) {
+	var service string
+	var status uint64
+	var grpcStatus, grpcMessage string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.ProtoMajor, Equals, 2)
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/grpc.health.v1.Health/Check")
+		c.Check(r.Header.Get("Content-Type"), Equals, "application/grpc")
+		request, err := io.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		c.Check(request, DeepEquals, grpcHealthCheckRequest(service))
+
+		w.Header().Set("Content-Type", "application/grpc")
+		if grpcStatus != "0" {
+			// Trailers-only response
+			w.Header().Set("Grpc-Status", grpcStatus)
+			w.Header().Set("Grpc-Message", grpcMessage)
+			return
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte{0, 0, 0, 0, 2, 1 << 3, byte(status)})
+		w.Header().Set("Grpc-Status", "0")
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.Protocols = &http.Protocols{}
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	// Serving server and service
+	status, grpcStatus = 1, "0"
+	chk := &grpcChecker{address: address}
+	err := chk.check(context.Background())
+	c.Assert(err, IsNil)
+	service = "orders"
+	chk = &grpcChecker{address: address, service: "orders"}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Service not serving
+	status = 2
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `service "orders" is NOT_SERVING`)
+	status = 3
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `service "orders" is SERVICE_UNKNOWN`)
+	service = ""
+	status = 0
+	chk = &grpcChecker{address: address}
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `server is UNKNOWN`)
+
+	// gRPC errors
+	grpcStatus, grpcMessage = "5", "unknown%20service"
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `gRPC status NOT_FOUND: unknown service`)
+	grpcStatus, grpcMessage = "12", ""
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `gRPC status UNIMPLEMENTED`)
+
+	// Server not listening
+	server.Close()
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, ".*connection refused.*")
+}
+
+func (s *CheckersSuite) TestGRPCTLS(c *C) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.ProtoMajor, Equals, 2)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte{0, 0, 0, 0, 2, 1 << 3, 1})
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	chk := &grpcChecker{address: address, tls: &checkTLS{}}
+	err := chk.check(context.Background())
+	c.Assert(err, ErrorMatches, ".*certificate.*")
+
+	var warnings []string
+	insecureSkipVerify := true
+	chk = &grpcChecker{name: "chk", address: address, tls: &checkTLS{
+		options:       plan.TLSOptions{InsecureSkipVerify: &insecureSkipVerify},
+		expiryWarning: time.Until(server.Certificate().NotAfter) + time.Hour,
+	}, warnf: func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}}
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0], Matches, `Check "chk": server certificate for `+address+` expires at .*`)
+}
+
+func (s *CheckersSuite) TestParseGRPCHealthCheckResponse(c *C) {
+	status, err := parseGRPCHealthCheckResponse([]byte{0, 0, 0, 0, 0})
+	c.Assert(err, IsNil)
+	c.Check(status, Equals, uint64(0))
+
+	// Unknown fields of each wire type are skipped
+	status, err = parseGRPCHealthCheckResponse([]byte{
+		0, 0, 0, 0, 23,
+		2<<3 | 0, 0x96, 0x01,
+		3<<3 | 1, 1, 2, 3, 4, 5, 6, 7, 8,
+		4<<3 | 2, 2, 'h', 'i',
+		1<<3 | 0, 2,
+		5<<3 | 5, 1, 2, 3, 4,
+	})
+	c.Assert(err, IsNil)
+	c.Check(status, Equals, uint64(2))
+
+	_, err = parseGRPCHealthCheckResponse([]byte{0, 0, 0})
+	c.Check(err, ErrorMatches, "message too short")
+	_, err = parseGRPCHealthCheckResponse([]byte{1, 0, 0, 0, 0})
+	c.Check(err, ErrorMatches, "compressed messages not supported")
+	_, err = parseGRPCHealthCheckResponse([]byte{0, 0, 0, 0, 5, 1 << 3})
+	c.Check(err, ErrorMatches, "message truncated")
+	_, err = parseGRPCHealthCheckResponse([]byte{0, 0, 0, 0, 2, 1 << 3, 0x80})
+	c.Check(err, ErrorMatches, "invalid varint")
+	_, err = parseGRPCHealthCheckResponse([]byte{0, 0, 0, 0, 1, 1<<3 | 3})
+	c.Check(err, ErrorMatches, "invalid wire type 3")
+}
+
 func (s *CheckersSuite) TestExec(c *C) {
 	err := reaper.Start()
 	c.Assert(err, IsNil)
@@ -259,6 +614,74 @@ func (s *CheckersSuite) TestExec(c *C) {
 	c.Assert(detailsErr.Details(), Equals, currentUser.Username)
 }
 
+func (s *CheckersSuite) TestLog(c *C) {
+	buffer := servicelog.NewRingBuffer(4096)
+	defer buffer.Close()
+	writer := servicelog.NewFormatWriter(buffer, "svc1")
+	var logBuffer *servicelog.RingBuffer
+	chk := &logChecker{
+		name:    "chk",
+		service: "svc1",
+		pattern: regexp.MustCompile(`panic: (.*)`),
+		window:  time.Second,
+		logBuffer: func(service string) *servicelog.RingBuffer {
+			c.Check(service, Equals, "svc1")
+			return logBuffer
+		},
+	}
+	defer chk.Close()
+
+	// Service not started yet
+	err := chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Output that doesn't match
+	logBuffer = buffer
+	fmt.Fprintln(writer, "starting")
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Output that matches fails the check, until it leaves the window
+	fmt.Fprintln(writer, "panic: first")
+	fmt.Fprintln(writer, "recovering")
+	fmt.Fprintln(writer, "panic: second")
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `output of service "svc1" matched "panic: \(\.\*\)" 2 times in the last 1s`)
+	c.Check(err.(*detailsError).Details(), Equals, "panic: first\npanic: second")
+	err = chk.check(context.Background())
+	c.Assert(err, NotNil)
+
+	// A new checker (for example, when recovering) sees the earlier matches
+	chk2 := &logChecker{
+		name:      "chk",
+		service:   "svc1",
+		pattern:   regexp.MustCompile(`panic: (.*)`),
+		window:    time.Second,
+		logBuffer: chk.logBuffer,
+	}
+	defer chk2.Close()
+	err = chk2.check(context.Background())
+	c.Assert(err, ErrorMatches, `.* matched .* 2 times .*`)
+
+	chk.window = 50 * time.Millisecond
+	time.Sleep(60 * time.Millisecond)
+	err = chk.check(context.Background())
+	c.Assert(err, IsNil)
+
+	// Output of a new log buffer (if the service was removed and started
+	// again) is read from the start
+	newBuffer := servicelog.NewRingBuffer(4096)
+	defer newBuffer.Close()
+	fmt.Fprintln(servicelog.NewFormatWriter(newBuffer, "svc1"), "panic: third")
+	logBuffer = newBuffer
+	err = chk.check(context.Background())
+	c.Assert(err, ErrorMatches, `.* matched .* 1 time in the last 50ms`)
+	c.Check(err.(*detailsError).Details(), Equals, "panic: third")
+
+	c.Assert(chk.Close(), IsNil)
+	c.Check(chk.iterator, IsNil)
+}
+
 func (s *CheckersSuite) TestNewChecker(c *C) {
 	chk := newChecker(&plan.Check{
 		Name: "http",
@@ -272,6 +695,49 @@ func (s *CheckersSuite) TestNewChecker(c *C) {
 	c.Check(http.name, Equals, "http")
 	c.Check(http.url, Equals, "https://example.com/foo")
 	c.Check(http.headers, DeepEquals, map[string]string{"k": "v"})
+	c.Check(http.responseMatch, IsNil)
+	c.Check(http.tls, IsNil)
+
+	chk = newChecker(&plan.Check{
+		Name: "match",
+		HTTP: &plan.HTTPCheck{
+			URL:           "https://example.com/foo",
+			ResponseMatch: `"status": "ok"`,
+		},
+	})
+	http, ok = chk.(*httpChecker)
+	c.Assert(ok, Equals, true)
+	c.Check(http.responseMatch.String(), Equals, `"status": "ok"`)
+
+	insecureSkipVerify := true
+	chk = newChecker(&plan.Check{
+		Name: "https",
+		HTTP: &plan.HTTPCheck{
+			URL: "https://example.com/foo",
+			TLS: &plan.CheckTLS{
+				TLSOptions: plan.TLSOptions{
+					CACert:             "/ca.pem",
+					ClientCert:         "/client.pem",
+					ClientKey:          "/client.key",
+					ServerName:         "example.org",
+					InsecureSkipVerify: &insecureSkipVerify,
+				},
+				ExpiryWarning: plan.OptionalDuration{Value: time.Hour, IsSet: true},
+			},
+		},
+	})
+	http, ok = chk.(*httpChecker)
+	c.Assert(ok, Equals, true)
+	c.Check(http.tls, DeepEquals, &checkTLS{
+		options: plan.TLSOptions{
+			CACert:             "/ca.pem",
+			ClientCert:         "/client.pem",
+			ClientKey:          "/client.key",
+			ServerName:         "example.org",
+			InsecureSkipVerify: &insecureSkipVerify,
+		},
+		expiryWarning: time.Hour,
+	})
 
 	chk = newChecker(&plan.Check{
 		Name: "tcp",
@@ -308,6 +774,35 @@ func (s *CheckersSuite) TestNewChecker(c *C) {
 	c.Assert(exec.user, Equals, "user")
 	c.Assert(exec.groupID, Equals, &groupID)
 	c.Assert(exec.workingDir, Equals, "/working/dir")
+
+	chk = newChecker(&plan.Check{
+		Name: "grpc",
+		GRPC: &plan.GRPCCheck{
+			Address: "localhost:50051",
+			Service: "orders",
+			TLS:     &plan.CheckTLS{TLSOptions: plan.TLSOptions{ServerName: "example.org"}},
+		},
+	})
+	grpc, ok := chk.(*grpcChecker)
+	c.Assert(ok, Equals, true)
+	c.Check(grpc.name, Equals, "grpc")
+	c.Check(grpc.address, Equals, "localhost:50051")
+	c.Check(grpc.service, Equals, "orders")
+	c.Check(grpc.tls, DeepEquals, &checkTLS{options: plan.TLSOptions{ServerName: "example.org"}})
+
+	chk = newChecker(&plan.Check{
+		Name: "log",
+		Log: &plan.LogCheck{
+			Service: "svc1",
+			Pattern: "panic:",
+		},
+	})
+	log, ok := chk.(*logChecker)
+	c.Assert(ok, Equals, true)
+	c.Check(log.name, Equals, "log")
+	c.Check(log.service, Equals, "svc1")
+	c.Check(log.pattern.String(), Equals, "panic:")
+	c.Check(log.window, Equals, time.Minute)
 }
 
 func (s *CheckersSuite) TestExecContextNoOverride(c *C) {
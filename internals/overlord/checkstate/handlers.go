@@ -39,6 +39,12 @@ func (m *CheckManager) doPerformCheck(task *state.Task, tomb *tombpkg.Tomb) erro
 	}
 
 	logger.Debugf("Performing check %q with period %v", details.Name, config.Period.Value)
+	// Failures during the start period, before the check has first
+	// succeeded, don't count towards the threshold.
+	var startPeriodEnd time.Time
+	if config.StartPeriod.Value > 0 && details.Successes == 0 {
+		startPeriodEnd = m.serviceStartTime(config.Service).Add(config.StartPeriod.Value)
+	}
 	ticker := time.NewTicker(config.Period.Value)
 	defer ticker.Stop()
 
@@ -47,22 +53,35 @@ func (m *CheckManager) doPerformCheck(task *state.Task, tomb *tombpkg.Tomb) erro
 	refresh := data.refresh
 	m.checksLock.Unlock()
 
-	chk := newChecker(config)
+	chk := m.createChecker(config)
+	defer closeChecker(chk)
 
 	performCheck := func() (shouldExit bool, err error) {
 		//lint:ignore SA1012 providing a nil context to tomb.Context() is valid
+		start := time.Now()
 		err = runCheck(tomb.Context(nil), chk, config.Timeout.Value)
 		if !tomb.Alive() {
 			return true, checkStopped(config.Name, task.Kind(), tomb.Err())
 		}
+		duration := time.Since(start)
+		m.observeDurationMetric(config, duration)
+		m.recordRun(config, start, duration, err)
 		if err != nil {
 			m.incFailureMetric(config)
+			if details.Successes == 0 && time.Now().Before(startPeriodEnd) {
+				m.state.Lock()
+				task.Logf("failed during start period (not counted): %s", errorDetails(err))
+				m.state.Unlock()
+				logger.Debugf("Check %q failed during start period (not counted): %v", config.Name, err)
+				return false, err
+			}
 			// Record check failure and perform any action if the threshold
 			// is reached (for example, restarting a service).
 			details.Failures++
-			m.updateCheckData(config, changeID, details.Successes, details.Failures)
+			oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
 
 			m.state.Lock()
+			m.statusChanged(config, oldStatus, newStatus, details.Failures, err)
 			atThreshold := details.Failures >= config.Threshold
 			if atThreshold {
 				details.Proceed = true
@@ -91,16 +110,18 @@ func (m *CheckManager) doPerformCheck(task *state.Task, tomb *tombpkg.Tomb) erro
 		if details.Failures > 0 {
 			oldFailures := details.Failures
 			details.Failures = 0
-			m.updateCheckData(config, changeID, details.Successes, details.Failures)
+			oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
 
 			m.state.Lock()
+			m.statusChanged(config, oldStatus, newStatus, details.Failures, nil)
 			task.Logf("succeeded after %s", pluralise(oldFailures, "failure", "failures"))
 			task.Set(checkDetailsAttr, &details)
 			m.state.Unlock()
 		} else {
-			m.updateCheckData(config, changeID, details.Successes, details.Failures)
+			oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
 
 			m.state.Lock()
+			m.statusChanged(config, oldStatus, newStatus, details.Failures, nil)
 			task.Set(checkDetailsAttr, &details)
 			m.state.Unlock()
 		}
@@ -162,20 +183,26 @@ func (m *CheckManager) doRecoverCheck(task *state.Task, tomb *tombpkg.Tomb) erro
 	refresh := data.refresh
 	m.checksLock.Unlock()
 
-	chk := newChecker(config)
+	chk := m.createChecker(config)
+	defer closeChecker(chk)
 
 	recoverCheck := func() (shouldExit bool, err error) {
 		//lint:ignore SA1012 providing a nil context to tomb.Context() is valid
+		start := time.Now()
 		err = runCheck(tomb.Context(nil), chk, config.Timeout.Value)
 		if !tomb.Alive() {
 			return true, checkStopped(config.Name, task.Kind(), tomb.Err())
 		}
+		duration := time.Since(start)
+		m.observeDurationMetric(config, duration)
+		m.recordRun(config, start, duration, err)
 		if err != nil {
 			m.incFailureMetric(config)
 			details.Failures++
-			m.updateCheckData(config, changeID, details.Successes, details.Failures)
+			oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
 
 			m.state.Lock()
+			m.statusChanged(config, oldStatus, newStatus, details.Failures, err)
 			task.Set(checkDetailsAttr, &details)
 			task.Errorf("%s", errorDetails(err))
 			m.state.Unlock()
@@ -188,9 +215,10 @@ func (m *CheckManager) doRecoverCheck(task *state.Task, tomb *tombpkg.Tomb) erro
 		m.incSuccessMetric(config)
 		details.Successes = 1
 		details.Failures = 0
-		m.updateCheckData(config, changeID, details.Successes, details.Failures)
+		oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
 		details.Proceed = true
 		m.state.Lock()
+		m.statusChanged(config, oldStatus, newStatus, details.Failures, nil)
 		task.Set(checkDetailsAttr, &details)
 		m.state.Unlock()
 		return true, nil
@@ -0,0 +1,98 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package checkstate
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/canonical/pebble/internals/plan"
+)
+
+// LevelDefined reports whether level is one of the built-in check levels,
+// or a level defined in the plan's health-levels section.
+func LevelDefined(p *plan.Plan, level plan.CheckLevel) bool {
+	return levelDefined(healthLevels(p), level)
+}
+
+// Healthy reports whether the given checks are healthy at the given level.
+//
+// A level is healthy if none of its checks are down (inactive checks are
+// ignored), or if the level has a quorum, if at least that many of its
+// checks are up. Each level that it includes must also be healthy; the
+// "ready" level includes "alive". If level is unset, every level must be
+// healthy. If names is not empty, only the named checks are considered.
+func Healthy(p *plan.Plan, checks []*CheckInfo, level plan.CheckLevel, names []string) (bool, error) {
+	levels := healthLevels(p)
+	if !levelDefined(levels, level) {
+		return false, fmt.Errorf("level %q is not defined", level)
+	}
+
+	byLevel := make(map[plan.CheckLevel][]*CheckInfo)
+	for _, check := range checks {
+		if len(names) == 0 || slices.Contains(names, check.Name) {
+			byLevel[check.Level] = append(byLevel[check.Level], check)
+		}
+	}
+
+	if level == plan.UnsetLevel {
+		for checkLevel, levelChecks := range byLevel {
+			if !checksHealthy(levels[string(checkLevel)], levelChecks) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return levelHealthy(levels, byLevel, level), nil
+}
+
+func levelHealthy(levels map[string]*HealthLevel, byLevel map[plan.CheckLevel][]*CheckInfo, level plan.CheckLevel) bool {
+	config := levels[string(level)]
+	if !checksHealthy(config, byLevel[level]) {
+		return false
+	}
+	var includes []plan.CheckLevel
+	switch {
+	case level == plan.ReadyLevel:
+		includes = []plan.CheckLevel{plan.AliveLevel} // ready implies alive
+	case config != nil:
+		includes = config.Includes
+	}
+	for _, included := range includes {
+		if !levelHealthy(levels, byLevel, included) {
+			return false
+		}
+	}
+	return true
+}
+
+func checksHealthy(config *HealthLevel, checks []*CheckInfo) bool {
+	if config == nil || config.Quorum == 0 {
+		// CheckStatusUp is healthy, and CheckStatusInactive is ignored.
+		for _, check := range checks {
+			if check.Status == CheckStatusDown {
+				return false
+			}
+		}
+		return true
+	}
+	up := 0
+	for _, check := range checks {
+		if check.Status == CheckStatusUp {
+			up++
+		}
+	}
+	return up >= config.Quorum
+}
@@ -16,11 +16,17 @@ package checkstate
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
@@ -29,6 +35,7 @@ import (
 	"github.com/canonical/pebble/internals/overlord/planstate"
 	"github.com/canonical/pebble/internals/overlord/state"
 	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/servicelog"
 )
 
 const (
@@ -37,6 +44,9 @@ const (
 
 	noPruneAttr      = "check-no-prune"
 	checkDetailsAttr = "check-details"
+
+	// historySize is the number of runs kept in the history of each check.
+	historySize = 20
 )
 
 // CheckManager starts and manages the health checks.
@@ -45,20 +55,33 @@ type CheckManager struct {
 	planMgr *planstate.PlanManager
 
 	failureHandlers []FailureFunc
+	logBuffer       LogBufferFunc
+	webhooks        *webhookSender
 
 	checksLock sync.Mutex
 	checks     map[string]*checkData
+
+	// Start times of the running services, and whether a service has
+	// started or stopped since the last Ensure. Protected by checksLock.
+	services        map[string]time.Time
+	servicesChanged bool
 }
 
 // FailureFunc is the type of function called when a failure action is triggered.
 type FailureFunc func(name string)
 
+// LogBufferFunc is the type of function used by log checks to get the log
+// buffer of the named service, or nil if the service hasn't been started.
+type LogBufferFunc func(service string) *servicelog.RingBuffer
+
 // NewManager creates a new check manager.
 func NewManager(s *state.State, runner *state.TaskRunner, planMgr *planstate.PlanManager) *CheckManager {
 	manager := &CheckManager{
-		state:   s,
-		checks:  make(map[string]*checkData),
-		planMgr: planMgr,
+		state:    s,
+		checks:   make(map[string]*checkData),
+		services: make(map[string]time.Time),
+		planMgr:  planMgr,
+		webhooks: newWebhookSender(),
 	}
 
 	// Health check changes can be long-running; ensure they don't get pruned.
@@ -90,10 +113,97 @@ func NewManager(s *state.State, runner *state.TaskRunner, planMgr *planstate.Pla
 	return manager
 }
 
+// Ensure implements StateManager.Ensure. When a service has started or
+// stopped, it starts or stops the checks bound to that service.
 func (m *CheckManager) Ensure() error {
+	m.checksLock.Lock()
+	changed := m.servicesChanged
+	m.servicesChanged = false
+	m.checksLock.Unlock()
+	if !changed {
+		return nil
+	}
+
+	currentPlan := m.planMgr.Plan()
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	shouldEnsure := false
+	for _, config := range currentPlan.Checks {
+		if config.Service == "" {
+			continue
+		}
+		m.checksLock.Lock()
+		data, ok := m.checks[config.Name]
+		var info CheckInfo
+		var waiting bool
+		if ok {
+			info = *checkDataToInfo(data)
+			waiting = data.waiting
+		}
+		_, running := m.services[config.Service]
+		m.checksLock.Unlock()
+		if !ok {
+			continue
+		}
+
+		switch {
+		case running && waiting:
+			if m.startCheck(mergeServiceContext(currentPlan, config)) {
+				shouldEnsure = true
+			}
+		case !running && info.ChangeID != "":
+			change := m.state.Change(info.ChangeID)
+			if change != nil {
+				change.Abort()
+			}
+			// As with StopChecks, keep the successes and failures so that
+			// the state of the check when its service stopped is visible.
+			oldStatus, newStatus := m.updateCheckData(config, "", info.Successes, info.Failures)
+			m.setCheckWaiting(config.Name, true)
+			m.statusChanged(config, oldStatus, newStatus, info.Failures, nil)
+		}
+	}
+	if shouldEnsure {
+		m.state.EnsureBefore(0) // start new tasks right away
+	}
 	return nil
 }
 
+// ServiceStatusChanged is called by the service manager when a service starts
+// running or stops. Checks bound to the service are started or stopped by the
+// next Ensure.
+func (m *CheckManager) ServiceStatusChanged(service string, running bool) {
+	m.checksLock.Lock()
+	_, wasRunning := m.services[service]
+	if running == wasRunning {
+		m.checksLock.Unlock()
+		return
+	}
+	if running {
+		m.services[service] = time.Now()
+	} else {
+		delete(m.services, service)
+	}
+	m.servicesChanged = true
+	m.checksLock.Unlock()
+
+	m.state.EnsureBefore(0)
+}
+
+// Stop implements overlord.StateStopper and cancels the webhook calls in
+// progress.
+func (m *CheckManager) Stop() {
+	m.webhooks.stop()
+}
+
+// SetLogBufferFunc sets the function used by log checks to get the log
+// buffers of the services. It must be called before any checks are started.
+func (m *CheckManager) SetLogBufferFunc(f LogBufferFunc) {
+	m.logBuffer = f
+}
+
 // NotifyCheckFailed adds f to the list of functions that are called whenever
 // a check hits its failure threshold.
 func (m *CheckManager) NotifyCheckFailed(f FailureFunc) {
@@ -169,7 +279,8 @@ func (m *CheckManager) PlanChanged(newPlan *plan.Plan) {
 			} else {
 				// Check is new and should be inactive - no need to start it,
 				// but we need to add it to the list of existing checks.
-				m.updateCheckData(config, "", 0, 0)
+				oldStatus, newStatus := m.updateCheckData(config, "", 0, 0)
+				m.statusChanged(config, oldStatus, newStatus, 0, nil)
 			}
 		}
 	}
@@ -177,10 +288,9 @@ func (m *CheckManager) PlanChanged(newPlan *plan.Plan) {
 	// Start new or modified checks.
 	for _, config := range newPlan.Checks {
 		if newOrModified[config.Name] {
-			merged := mergeServiceContext(newPlan, config)
-			changeID := performCheckChange(m.state, merged)
-			m.updateCheckData(config, changeID, 0, 0)
-			shouldEnsure = true
+			if m.startCheck(mergeServiceContext(newPlan, config)) {
+				shouldEnsure = true
+			}
 		}
 	}
 	if shouldEnsure {
@@ -188,6 +298,26 @@ func (m *CheckManager) PlanChanged(newPlan *plan.Plan) {
 	}
 }
 
+// startCheck starts a perform-check change for the check. If the check is
+// bound to a service that isn't running, it's left inactive and started when
+// the service starts. It reports whether the check was started. The state
+// lock must be held.
+func (m *CheckManager) startCheck(config *plan.Check) bool {
+	m.checksLock.Lock()
+	_, running := m.services[config.Service]
+	m.checksLock.Unlock()
+	running = running || config.Service == ""
+
+	changeID := ""
+	if running {
+		changeID = performCheckChange(m.state, config)
+	}
+	oldStatus, newStatus := m.updateCheckData(config, changeID, 0, 0)
+	m.setCheckWaiting(config.Name, !running)
+	m.statusChanged(config, oldStatus, newStatus, 0, nil)
+	return running
+}
+
 func (m *CheckManager) changeStatusChanged(change *state.Change, old, new state.Status) {
 	shouldEnsure := false
 	switch {
@@ -198,7 +328,8 @@ func (m *CheckManager) changeStatusChanged(change *state.Change, old, new state.
 		}
 		config := m.state.Cached(performConfigKey{change.ID()}).(*plan.Check) // panic if key not present (always should be)
 		changeID := recoverCheckChange(m.state, config, details.Successes, details.Failures)
-		m.updateCheckData(config, changeID, details.Successes, details.Failures)
+		oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
+		m.statusChanged(config, oldStatus, newStatus, details.Failures, nil)
 		shouldEnsure = true
 
 	case change.Kind() == recoverCheckKind && new == state.DoneStatus:
@@ -208,7 +339,8 @@ func (m *CheckManager) changeStatusChanged(change *state.Change, old, new state.
 		}
 		config := m.state.Cached(recoverConfigKey{change.ID()}).(*plan.Check) // panic if key not present (always should be)
 		changeID := performCheckChange(m.state, config)
-		m.updateCheckData(config, changeID, details.Successes, details.Failures)
+		oldStatus, newStatus := m.updateCheckData(config, changeID, details.Successes, details.Failures)
+		m.statusChanged(config, oldStatus, newStatus, details.Failures, nil)
 		shouldEnsure = true
 	}
 
@@ -217,6 +349,49 @@ func (m *CheckManager) changeStatusChanged(change *state.Change, old, new state.
 	}
 }
 
+// statusChanged records a check-status notice and calls the check's
+// on-status-change webhook (if any) when the status of a check changes.
+// The err argument is the error from the last check, if it failed. The state
+// lock must be held.
+func (m *CheckManager) statusChanged(config *plan.Check, oldStatus, newStatus CheckStatus, failures int, err error) {
+	if oldStatus == "" || oldStatus == newStatus {
+		// New checks don't have a previous status to change from.
+		return
+	}
+	payload := &webhookPayload{
+		Check:          config.Name,
+		Status:         newStatus,
+		PreviousStatus: oldStatus,
+		Failures:       failures,
+		Threshold:      config.Threshold,
+		Time:           time.Now().UTC(),
+	}
+	data := map[string]string{
+		"status":          string(newStatus),
+		"previous-status": string(oldStatus),
+		"failures":        strconv.Itoa(failures),
+	}
+	if err != nil {
+		payload.Error = err.Error()
+		data["error"] = payload.Error
+		var detailsErr *detailsError
+		if errors.As(err, &detailsErr) && detailsErr.Details() != "" {
+			payload.Details = detailsErr.Details()
+			data["details"] = payload.Details
+		}
+	}
+	_, noticeErr := m.state.AddNotice(nil, state.CheckStatusNotice, config.Name, &state.AddNoticeOptions{
+		Data: data,
+		Time: payload.Time,
+	})
+	if noticeErr != nil {
+		logger.Noticef("Cannot record check-status notice for check %q: %v", config.Name, noticeErr)
+	}
+	if config.OnStatusChange != nil {
+		m.webhooks.send(config.OnStatusChange, payload)
+	}
+}
+
 func (m *CheckManager) callFailureHandlers(name string) {
 	for _, f := range m.failureHandlers {
 		f(name)
@@ -245,6 +420,10 @@ func checkType(config *plan.Check) string {
 		return "TCP"
 	case config.Exec != nil:
 		return "exec"
+	case config.GRPC != nil:
+		return "gRPC"
+	case config.Log != nil:
+		return "log"
 	default:
 		return "<unknown>"
 	}
@@ -255,10 +434,21 @@ func checkType(config *plan.Check) string {
 func newChecker(config *plan.Check) checker {
 	switch {
 	case config.HTTP != nil:
+		var responseMatch *regexp.Regexp
+		if config.HTTP.ResponseMatch != "" {
+			responseMatch = regexp.MustCompile(config.HTTP.ResponseMatch) // validated when parsing the plan
+		}
 		return &httpChecker{
-			name:    config.Name,
-			url:     config.HTTP.URL,
-			headers: config.HTTP.Headers,
+			name:              config.Name,
+			url:               config.HTTP.URL,
+			headers:           config.HTTP.Headers,
+			method:            config.HTTP.Method,
+			body:              config.HTTP.Body,
+			status:            config.HTTP.Status,
+			responseMatch:     responseMatch,
+			responseJSON:      config.HTTP.ResponseJSON,
+			noFollowRedirects: config.HTTP.FollowRedirects != nil && !*config.HTTP.FollowRedirects,
+			tls:               newCheckTLS(config.HTTP.TLS),
 		}
 
 	case config.TCP != nil:
@@ -280,12 +470,71 @@ func newChecker(config *plan.Check) checker {
 			workingDir:  config.Exec.WorkingDir,
 		}
 
+	case config.GRPC != nil:
+		return &grpcChecker{
+			name:    config.Name,
+			address: config.GRPC.Address,
+			service: config.GRPC.Service,
+			tls:     newCheckTLS(config.GRPC.TLS),
+		}
+
+	case config.Log != nil:
+		return &logChecker{
+			name:    config.Name,
+			service: config.Log.Service,
+			pattern: regexp.MustCompile(config.Log.Pattern), // validated when parsing the plan
+			window:  config.Log.WindowDuration(),
+		}
+
 	default:
 		// This has already been checked when parsing the config.
 		panic("internal error: invalid check config")
 	}
 }
 
+// newCheckTLS returns the TLS options of a checker, or nil if there are none.
+func newCheckTLS(config *plan.CheckTLS) *checkTLS {
+	if config == nil {
+		return nil
+	}
+	return &checkTLS{
+		options:       *config.TLSOptions.Copy(),
+		expiryWarning: config.ExpiryWarning.Value,
+		expiryFailure: config.ExpiryFailure.Value,
+	}
+}
+
+// createChecker creates a new checker like newChecker, and connects it to
+// the manager: warnings it records are added to the state as warning
+// notices, and log checks read the log buffers of the services.
+func (m *CheckManager) createChecker(config *plan.Check) checker {
+	warnf := func(format string, args ...any) {
+		m.state.Lock()
+		defer m.state.Unlock()
+		m.state.Warnf(format, args...)
+	}
+	chk := newChecker(config)
+	switch chk := chk.(type) {
+	case *httpChecker:
+		chk.warnf = warnf
+	case *grpcChecker:
+		chk.warnf = warnf
+	case *logChecker:
+		chk.logBuffer = m.logBuffer
+	}
+	return chk
+}
+
+// closeChecker releases the resources held by a checker, if any.
+func closeChecker(chk checker) {
+	if closer, ok := chk.(io.Closer); ok {
+		err := closer.Close()
+		if err != nil {
+			logger.Noticef("Cannot close checker: %v", err)
+		}
+	}
+}
+
 // mergeServiceContext returns the final check configuration with service
 // context merged (for exec checks). The original config is copied if needed,
 // not modified.
@@ -332,19 +581,35 @@ func (m *CheckManager) Checks() ([]*CheckInfo, error) {
 	return infos, nil
 }
 
+// CheckReady reports whether the named check is up and has passed since it
+// last failed.
+func (m *CheckManager) CheckReady(name string) bool {
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	check, ok := m.checks[name]
+	if !ok {
+		return false
+	}
+	return check.status == CheckStatusUp && check.successes > 0 && check.failures == 0
+}
+
 func (m *CheckManager) ensureCheck(name string) *checkData {
 	check, ok := m.checks[name]
 	if !ok {
 		check = &checkData{
-			name:    name,
-			refresh: make(chan refreshInfo),
+			name:           name,
+			refresh:        make(chan refreshInfo),
+			durationMetric: metrics.NewHistogram(metrics.DurationBuckets),
 		}
 		m.checks[name] = check
 	}
 	return check
 }
 
-func (m *CheckManager) updateCheckData(config *plan.Check, changeID string, successes, failures int) {
+// updateCheckData updates the data of the check, and returns its previous
+// status (empty if the check is new) and its new status.
+func (m *CheckManager) updateCheckData(config *plan.Check, changeID string, successes, failures int) (oldStatus, newStatus CheckStatus) {
 	m.checksLock.Lock()
 	defer m.checksLock.Unlock()
 
@@ -360,6 +625,7 @@ func (m *CheckManager) updateCheckData(config *plan.Check, changeID string, succ
 	}
 
 	check := m.ensureCheck(config.Name)
+	oldStatus = check.status
 	check.level = config.Level
 	check.startup = startup
 	check.status = status
@@ -367,6 +633,67 @@ func (m *CheckManager) updateCheckData(config *plan.Check, changeID string, succ
 	check.failures = failures
 	check.threshold = config.Threshold
 	check.changeID = changeID
+	return oldStatus, status
+}
+
+// setCheckWaiting sets whether the check is waiting for its service to start.
+func (m *CheckManager) setCheckWaiting(name string, waiting bool) {
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	check := m.ensureCheck(name)
+	check.waiting = waiting
+}
+
+// serviceStartTime returns the time the named service started running, or
+// the current time if it's not running (or name is empty).
+func (m *CheckManager) serviceStartTime(name string) time.Time {
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	started, ok := m.services[name]
+	if !ok {
+		return time.Now()
+	}
+	return started
+}
+
+// CheckHistory returns the most recent runs of the named check, oldest
+// first, or nil if the check hasn't run.
+func (m *CheckManager) CheckHistory(name string) []CheckRun {
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	check, ok := m.checks[name]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(check.history)
+}
+
+// recordRun adds a run of the check to its history, discarding the oldest
+// run if the history is full.
+func (m *CheckManager) recordRun(config *plan.Check, start time.Time, duration time.Duration, err error) {
+	run := CheckRun{
+		Time:     start,
+		Duration: duration,
+	}
+	if err != nil {
+		run.Error = err.Error()
+		var detailsErr *detailsError
+		if errors.As(err, &detailsErr) {
+			run.Details = detailsErr.Details()
+		}
+	}
+
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	check := m.ensureCheck(config.Name)
+	if len(check.history) >= historySize {
+		check.history = slices.Delete(check.history, 0, 1)
+	}
+	check.history = append(check.history, run)
 }
 
 func (m *CheckManager) incSuccessMetric(config *plan.Check) {
@@ -385,6 +712,14 @@ func (m *CheckManager) incFailureMetric(config *plan.Check) {
 	check.failureMetric += 1
 }
 
+func (m *CheckManager) observeDurationMetric(config *plan.Check, duration time.Duration) {
+	m.checksLock.Lock()
+	defer m.checksLock.Unlock()
+
+	check := m.ensureCheck(config.Name)
+	check.durationMetric.Observe(duration.Seconds())
+}
+
 func (m *CheckManager) deleteCheckData(name string) {
 	m.checksLock.Lock()
 	defer m.checksLock.Unlock()
@@ -404,6 +739,14 @@ type CheckInfo struct {
 	ChangeID  string
 }
 
+// CheckRun holds the result of a single run of a check.
+type CheckRun struct {
+	Time     time.Time
+	Duration time.Duration
+	Error    string // empty if the check succeeded
+	Details  string
+}
+
 type refreshInfo struct {
 	ctx    context.Context
 	result chan error
@@ -411,17 +754,20 @@ type refreshInfo struct {
 
 // checkData holds the metrics and other data for a single check.
 type checkData struct {
-	name          string
-	level         plan.CheckLevel
-	startup       plan.CheckStartup
-	status        CheckStatus
-	successes     int
-	failures      int
-	threshold     int
-	changeID      string
-	successMetric int64
-	failureMetric int64
-	refresh       chan refreshInfo
+	name           string
+	level          plan.CheckLevel
+	startup        plan.CheckStartup
+	status         CheckStatus
+	successes      int
+	failures       int
+	threshold      int
+	changeID       string
+	waiting        bool // waiting for its service to start
+	successMetric  int64
+	failureMetric  int64
+	durationMetric *metrics.Histogram
+	refresh        chan refreshInfo
+	history        []CheckRun
 }
 
 type CheckStatus string
@@ -477,6 +823,15 @@ func (c *checkData) writeMetric(writer metrics.Writer) error {
 		return err
 	}
 
+	err = writer.Write(c.durationMetric.Metric(
+		"pebble_check_duration_seconds",
+		"Time taken to run the check",
+		metrics.NewLabel("check", c.name),
+	))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -548,9 +903,9 @@ func (m *CheckManager) StartChecks(checks []string) (started []string, err error
 		if checkData.changeID != "" {
 			continue
 		}
-		changeID := performCheckChange(m.state, check)
-		m.updateCheckData(check, changeID, 0, 0)
-		started = append(started, check.Name)
+		if m.startCheck(check) {
+			started = append(started, check.Name)
+		}
 	}
 
 	return started, nil
@@ -587,8 +942,10 @@ func (m *CheckManager) StopChecks(checks []string) (stopped []string, err error)
 			logger.Noticef("check %s is in the plan but not known to the manager", name)
 			continue
 		}
-		// If the check is not running, skip it.
+		// If the check is not running, skip it, but don't start it when its
+		// service starts.
 		if checkData.changeID == "" {
+			m.setCheckWaiting(name, false)
 			continue
 		}
 		change := m.state.Change(checkData.changeID)
@@ -600,7 +957,8 @@ func (m *CheckManager) StopChecks(checks []string) (stopped []string, err error)
 		// same, so that people can inspect what the state of the check was when
 		// it was stopped. The status of the check will be "inactive", but the
 		// failure count combined with the threshold will give the full picture.
-		m.updateCheckData(check, "", checkData.successes, checkData.failures)
+		oldStatus, newStatus := m.updateCheckData(check, "", checkData.successes, checkData.failures)
+		m.statusChanged(check, oldStatus, newStatus, checkData.failures, nil)
 	}
 
 	return stopped, nil
@@ -631,8 +989,7 @@ func (m *CheckManager) Replan() {
 		if checkData.changeID != "" {
 			continue
 		}
-		changeID := performCheckChange(m.state, check)
-		m.updateCheckData(check, changeID, 0, 0)
+		m.startCheck(check)
 	}
 }
 
@@ -653,8 +1010,11 @@ func (m *CheckManager) RefreshCheck(ctx context.Context, check *plan.Check) (*Ch
 
 	// If the check is stopped, run the check directly without using changes and tasks.
 	if changeID == "" {
-		chk := newChecker(check)
+		chk := m.createChecker(check)
+		defer closeChecker(chk)
+		start := time.Now()
 		err := runCheck(ctx, chk, check.Timeout.Value)
+		m.recordRun(check, start, time.Since(start), err)
 		if err != nil {
 			return getCheckInfo(), fmt.Errorf("%s", errorDetails(err))
 		}
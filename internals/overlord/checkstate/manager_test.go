@@ -17,8 +17,11 @@ package checkstate_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -241,7 +244,7 @@ func (s *ManagerSuite) TestFailures(c *C) {
 			"chk1": {
 				Name:      "chk1",
 				Override:  "replace",
-				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond},
+				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
 				Timeout:   plan.OptionalDuration{Value: 100 * time.Millisecond},
 				Threshold: threshold,
 				Exec: &plan.ExecCheck{
@@ -305,7 +308,7 @@ func (s *ManagerSuite) TestFailuresBelowThreshold(c *C) {
 			"chk1": {
 				Name:      "chk1",
 				Override:  "replace",
-				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond},
+				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
 				Timeout:   plan.OptionalDuration{Value: 100 * time.Millisecond},
 				Threshold: threshold,
 				Exec: &plan.ExecCheck{
@@ -332,6 +335,40 @@ func (s *ManagerSuite) TestFailuresBelowThreshold(c *C) {
 	c.Assert(lastTaskLog(s.overlord.State(), check.ChangeID), Matches, `.* INFO succeeded after \d+ failure.*`)
 }
 
+func (s *ManagerSuite) TestCheckReady(c *C) {
+	testPath := c.MkDir() + "/test"
+	s.manager.PlanChanged(&plan.Plan{
+		Checks: map[string]*plan.Check{
+			"chk1": {
+				Name:      "chk1",
+				Override:  "replace",
+				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
+				Timeout:   plan.OptionalDuration{Value: 100 * time.Millisecond},
+				Threshold: 10,
+				Exec: &plan.ExecCheck{
+					Command: fmt.Sprintf(`/bin/sh -c '[ -f %s ]'`, testPath),
+				},
+			},
+		},
+	})
+	c.Check(s.manager.CheckReady("nonexistent"), Equals, false)
+
+	// Up but failing isn't ready.
+	check := waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.Failures >= 1
+	})
+	c.Assert(check.Status, Equals, checkstate.CheckStatusUp)
+	c.Check(s.manager.CheckReady("chk1"), Equals, false)
+
+	// Ready once it passes.
+	err := os.WriteFile(testPath, nil, 0o644)
+	c.Assert(err, IsNil)
+	waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.Failures == 0
+	})
+	c.Check(s.manager.CheckReady("chk1"), Equals, true)
+}
+
 func (s *ManagerSuite) TestPlanChangedSmarts(c *C) {
 	s.manager.PlanChanged(&plan.Plan{
 		Checks: map[string]*plan.Check{
@@ -952,6 +989,23 @@ pebble_check_success_count{check="chk1"} \d+
 # TYPE pebble_check_failure_count counter
 pebble_check_failure_count{check="chk1"} 0
 
+# HELP pebble_check_duration_seconds Time taken to run the check
+# TYPE pebble_check_duration_seconds histogram
+pebble_check_duration_seconds_bucket{check="chk1",le="0.005"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.01"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.025"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.05"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.1"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.25"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="1"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="2.5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="10"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="\+Inf"} \d+
+pebble_check_duration_seconds_sum{check="chk1"} [0-9.e-]+
+pebble_check_duration_seconds_count{check="chk1"} \d+
+
 `[1:]
 	c.Assert(buf.String(), Matches, expectedRegex)
 }
@@ -965,7 +1019,7 @@ func (s *ManagerSuite) TestMetricsCheckFailure(c *C) {
 			"chk1": {
 				Name:      "chk1",
 				Override:  "replace",
-				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond},
+				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
 				Timeout:   plan.OptionalDuration{Value: 100 * time.Millisecond},
 				Threshold: 3,
 				Exec: &plan.ExecCheck{
@@ -997,6 +1051,23 @@ pebble_check_success_count{check="chk1"} 0
 # TYPE pebble_check_failure_count counter
 pebble_check_failure_count{check="chk1"} \d+
 
+# HELP pebble_check_duration_seconds Time taken to run the check
+# TYPE pebble_check_duration_seconds histogram
+pebble_check_duration_seconds_bucket{check="chk1",le="0.005"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.01"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.025"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.05"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.1"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.25"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="0.5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="1"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="2.5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="5"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="10"} \d+
+pebble_check_duration_seconds_bucket{check="chk1",le="\+Inf"} \d+
+pebble_check_duration_seconds_sum{check="chk1"} [0-9.e-]+
+pebble_check_duration_seconds_count{check="chk1"} \d+
+
 `[1:]
 	c.Assert(buf.String(), Matches, expectedRegex)
 }
@@ -1032,6 +1103,23 @@ pebble_check_success_count{check="chk1"} 0
 # TYPE pebble_check_failure_count counter
 pebble_check_failure_count{check="chk1"} 0
 
+# HELP pebble_check_duration_seconds Time taken to run the check
+# TYPE pebble_check_duration_seconds histogram
+pebble_check_duration_seconds_bucket{check="chk1",le="0.005"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.01"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.025"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.05"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.1"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.25"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="0.5"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="1"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="2.5"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="5"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="10"} 0
+pebble_check_duration_seconds_bucket{check="chk1",le="+Inf"} 0
+pebble_check_duration_seconds_sum{check="chk1"} 0
+pebble_check_duration_seconds_count{check="chk1"} 0
+
 `[1:]
 	c.Assert(buf.String(), Equals, expected)
 }
@@ -1074,6 +1162,45 @@ func (s *ManagerSuite) TestRefreshCheck(c *C) {
 	})
 }
 
+func (s *ManagerSuite) TestCertificateExpiryWarning(c *C) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	insecureSkipVerify := true
+	chk1 := &plan.Check{
+		Name:      "chk1",
+		Override:  "replace",
+		Period:    plan.OptionalDuration{Value: time.Second},
+		Timeout:   plan.OptionalDuration{Value: time.Second},
+		Threshold: 3,
+		Startup:   plan.CheckStartupDisabled,
+		HTTP: &plan.HTTPCheck{
+			URL: server.URL,
+			TLS: &plan.CheckTLS{
+				TLSOptions:    plan.TLSOptions{InsecureSkipVerify: &insecureSkipVerify},
+				ExpiryWarning: plan.OptionalDuration{Value: 100 * 365 * 24 * time.Hour, IsSet: true},
+			},
+		},
+	}
+	err := s.planMgr.AppendLayer(&plan.Layer{Checks: map[string]*plan.Check{"chk1": chk1}}, false)
+	c.Assert(err, IsNil)
+	s.manager.PlanChanged(s.planMgr.Plan())
+
+	// Certificate expiring within the threshold records a warning, but the
+	// check still succeeds.
+	_, err = s.manager.RefreshCheck(context.Background(), chk1)
+	c.Assert(err, IsNil)
+
+	st := s.overlord.State()
+	st.Lock()
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.WarningNotice}})
+	st.Unlock()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].String(), Matches, `Notice .* \(public:warning:Check "chk1": server certificate for https://.* expires at .*\)`)
+}
+
 func (s *ManagerSuite) TestRefreshCheckFailure(c *C) {
 	testPath := c.MkDir() + "/test"
 	err := os.WriteFile(testPath, nil, 0o644)
@@ -1250,3 +1377,225 @@ func (s *ManagerSuite) TestChecksSuccesses(c *C) {
 		return chk.Successes >= 2 && chk.Successes < numSuccesses
 	})
 }
+
+func (s *ManagerSuite) TestStatusChangeNoticesAndWebhook(c *C) {
+	payloads := make(chan map[string]any, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.Header.Get("Content-Type"), Equals, "application/json")
+		c.Check(r.Header.Get("Authorization"), Equals, "Bearer token")
+		var payload map[string]any
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		c.Check(err, IsNil)
+		payloads <- payload
+	}))
+	defer webhook.Close()
+
+	chk1 := &plan.Check{
+		Name:      "chk1",
+		Override:  "replace",
+		Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
+		Timeout:   plan.OptionalDuration{Value: time.Second},
+		Threshold: 1,
+		Exec:      &plan.ExecCheck{Command: "/bin/sh -c 'echo details >&2; exit 1'"},
+		OnStatusChange: &plan.CheckStatusAction{
+			Webhook: webhook.URL,
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+	}
+	err := s.planMgr.AppendLayer(&plan.Layer{Checks: map[string]*plan.Check{"chk1": chk1}}, false)
+	c.Assert(err, IsNil)
+
+	// Run an Ensure pass to kick the check task into Doing status.
+	st := s.overlord.State()
+	st.EnsureBefore(0)
+
+	waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.Status == checkstate.CheckStatusDown
+	})
+
+	select {
+	case payload := <-payloads:
+		c.Check(payload["check"], Equals, "chk1")
+		c.Check(payload["status"], Equals, "down")
+		c.Check(payload["previous-status"], Equals, "up")
+		c.Check(payload["failures"], Equals, 1.0)
+		c.Check(payload["threshold"], Equals, 1.0)
+		c.Check(payload["error"], Equals, "exit status 1")
+		c.Check(payload["details"], Equals, "details")
+	case <-time.After(10 * time.Second):
+		c.Fatalf("timed out waiting for webhook")
+	}
+
+	_, err = s.manager.StopChecks([]string{"chk1"})
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.CheckStatusNotice}})
+	st.Unlock()
+	c.Assert(notices, HasLen, 1)
+	data, err := json.Marshal(notices[0])
+	c.Assert(err, IsNil)
+	var notice struct {
+		Key         string            `json:"key"`
+		Occurrences int               `json:"occurrences"`
+		LastData    map[string]string `json:"last-data"`
+	}
+	err = json.Unmarshal(data, &notice)
+	c.Assert(err, IsNil)
+	c.Check(notice.Key, Equals, "chk1")
+	c.Check(notice.Occurrences, Equals, 2)
+	c.Check(notice.LastData, DeepEquals, map[string]string{
+		"status":          "inactive",
+		"previous-status": "down",
+		"failures":        "1",
+	})
+
+	select {
+	case payload := <-payloads:
+		c.Check(payload["status"], Equals, "inactive")
+		c.Check(payload["previous-status"], Equals, "down")
+	case <-time.After(10 * time.Second):
+		c.Fatalf("timed out waiting for webhook")
+	}
+}
+
+func (s *ManagerSuite) TestServiceBinding(c *C) {
+	err := s.planMgr.AppendLayer(&plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {Name: "svc1", Override: "replace", Command: "sleep 10"},
+		},
+		Checks: map[string]*plan.Check{
+			"chk1": {
+				Name:      "chk1",
+				Override:  "replace",
+				Period:    plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
+				Timeout:   plan.OptionalDuration{Value: 10 * time.Millisecond, IsSet: true},
+				Threshold: 3,
+				Service:   "svc1",
+				Exec:      &plan.ExecCheck{Command: "echo chk1"},
+			},
+		},
+	}, false)
+	c.Assert(err, IsNil)
+
+	// The check stays inactive until its service starts.
+	waitChecks(c, s.manager, []*checkstate.CheckInfo{
+		{Name: "chk1", Startup: "enabled", Status: "inactive", Threshold: 3},
+	})
+	started, err := s.manager.StartChecks([]string{"chk1"})
+	c.Assert(err, IsNil)
+	c.Check(started, HasLen, 0)
+
+	s.manager.ServiceStatusChanged("svc1", true)
+	check := waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.Status == checkstate.CheckStatusUp && check.Successes > 0
+	})
+	c.Check(check.ChangeID, Not(Equals), "")
+
+	// And goes inactive again when the service stops.
+	s.manager.ServiceStatusChanged("svc1", false)
+	waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.Status == checkstate.CheckStatusInactive && check.ChangeID == ""
+	})
+	st := s.overlord.State()
+	st.Lock()
+	status := st.Change(check.ChangeID).Status()
+	st.Unlock()
+	c.Check(status, Not(Equals), state.DoingStatus)
+
+	// A check stopped explicitly isn't started when its service starts.
+	_, err = s.manager.StopChecks([]string{"chk1"})
+	c.Assert(err, IsNil)
+	s.manager.ServiceStatusChanged("svc1", true)
+	time.Sleep(50 * time.Millisecond)
+	checks, err := s.manager.Checks()
+	c.Assert(err, IsNil)
+	c.Assert(checks, HasLen, 1)
+	c.Check(checks[0].Status, Equals, checkstate.CheckStatusInactive)
+}
+
+func (s *ManagerSuite) TestStartPeriod(c *C) {
+	err := s.planMgr.AppendLayer(&plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {Name: "svc1", Override: "replace", Command: "sleep 10"},
+		},
+		Checks: map[string]*plan.Check{
+			"chk1": {
+				Name:        "chk1",
+				Override:    "replace",
+				Period:      plan.OptionalDuration{Value: 20 * time.Millisecond, IsSet: true},
+				Timeout:     plan.OptionalDuration{Value: 10 * time.Millisecond, IsSet: true},
+				Threshold:   1,
+				Service:     "svc1",
+				StartPeriod: plan.OptionalDuration{Value: time.Hour, IsSet: true},
+				Exec:        &plan.ExecCheck{Command: "/bin/sh -c 'exit 1'"},
+			},
+		},
+	}, false)
+	c.Assert(err, IsNil)
+	s.manager.ServiceStatusChanged("svc1", true)
+
+	check := waitCheck(c, s.manager, "chk1", func(check *checkstate.CheckInfo) bool {
+		return check.ChangeID != ""
+	})
+	st := s.overlord.State()
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			c.Fatalf("timed out waiting for start period failure")
+		}
+		if lastTaskLog(st, check.ChangeID) != "" {
+			break
+		}
+	}
+	c.Check(lastTaskLog(st, check.ChangeID), Matches, `.* failed during start period \(not counted\): exit status 1`)
+
+	// Failures during the start period don't bring the check down.
+	time.Sleep(100 * time.Millisecond)
+	checks, err := s.manager.Checks()
+	c.Assert(err, IsNil)
+	c.Assert(checks, HasLen, 1)
+	c.Check(checks[0].Status, Equals, checkstate.CheckStatusUp)
+	c.Check(checks[0].Failures, Equals, 0)
+}
+
+func (s *ManagerSuite) TestCheckHistory(c *C) {
+	c.Check(s.manager.CheckHistory("chk1"), IsNil)
+
+	s.manager.PlanChanged(&plan.Plan{
+		Checks: map[string]*plan.Check{
+			"chk1": {
+				Name:      "chk1",
+				Override:  "replace",
+				Period:    plan.OptionalDuration{Value: 10 * time.Millisecond},
+				Timeout:   plan.OptionalDuration{Value: time.Second},
+				Threshold: 1000,
+				Exec:      &plan.ExecCheck{Command: "/bin/sh -c 'echo details >&2; exit 1'"},
+			},
+		},
+	})
+
+	// The history is bounded, keeping only the most recent runs.
+	var history []checkstate.CheckRun
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			c.Fatalf("timed out waiting for check runs")
+		}
+		checks, err := s.manager.Checks()
+		c.Assert(err, IsNil)
+		if checks[0].Failures > 25 {
+			history = s.manager.CheckHistory("chk1")
+			break
+		}
+	}
+	c.Assert(history, HasLen, 20)
+	for i, run := range history {
+		c.Check(run.Time.IsZero(), Equals, false)
+		c.Check(run.Duration > 0, Equals, true)
+		c.Check(run.Error, Equals, "exit status 1")
+		c.Check(run.Details, Equals, "details")
+		if i > 0 {
+			c.Check(run.Time.After(history[i-1].Time), Equals, true)
+		}
+	}
+}
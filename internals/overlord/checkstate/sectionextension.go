@@ -0,0 +1,247 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package checkstate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/canonical/pebble/internals/plan"
+)
+
+// HealthLevelsField is the top level string key used in the Pebble plan.
+const HealthLevelsField = "health-levels"
+
+// HealthLevel defines a custom check level, in addition to the built-in
+// "alive" and "ready" levels, and how the health of its checks is evaluated.
+type HealthLevel struct {
+	Name     string        `yaml:"-"`
+	Override plan.Override `yaml:"override,omitempty"`
+
+	// Includes lists other levels that must also be healthy for this level
+	// to be healthy, in the same way that "ready" includes "alive".
+	Includes []plan.CheckLevel `yaml:"includes,omitempty"`
+
+	// Quorum is the number of checks at this level that must be up for
+	// the level to be healthy. If zero, the level is healthy unless one of
+	// its checks is down.
+	Quorum int `yaml:"quorum,omitempty"`
+}
+
+func (l *HealthLevel) copy() *HealthLevel {
+	copied := *l
+	copied.Includes = slices.Clone(l.Includes)
+	return &copied
+}
+
+func (l *HealthLevel) merge(other *HealthLevel) {
+	for _, level := range other.Includes {
+		if !slices.Contains(l.Includes, level) {
+			l.Includes = append(l.Includes, level)
+		}
+	}
+	if other.Quorum != 0 {
+		l.Quorum = other.Quorum
+	}
+}
+
+var _ plan.Section = (*HealthLevelsSection)(nil)
+
+// HealthLevelsSection is the health-levels section of a layer or plan.
+type HealthLevelsSection struct {
+	Entries map[string]*HealthLevel `yaml:",inline"`
+}
+
+func (s *HealthLevelsSection) IsZero() bool {
+	return len(s.Entries) == 0
+}
+
+func (s *HealthLevelsSection) Validate() error {
+	for name, level := range s.Entries {
+		if level == nil {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("health level object cannot be null for health level %q", name),
+			}
+		}
+		if isBuiltinLevel(plan.CheckLevel(name)) {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("health level %q is built in and cannot be redefined", name),
+			}
+		}
+		if level.Quorum < 0 {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("health level %q quorum must not be negative", name),
+			}
+		}
+	}
+	return nil
+}
+
+func (s *HealthLevelsSection) combine(other *HealthLevelsSection) error {
+	for name, level := range other.Entries {
+		if s.Entries == nil {
+			s.Entries = make(map[string]*HealthLevel)
+		}
+		switch level.Override {
+		case plan.MergeOverride:
+			if current, ok := s.Entries[name]; ok {
+				current.merge(level)
+			} else {
+				s.Entries[name] = level.copy()
+			}
+		case plan.ReplaceOverride:
+			s.Entries[name] = level.copy()
+		case plan.UnknownOverride:
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`health level %q must define "override" policy`, name),
+			}
+		default:
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`health level %q has invalid "override" policy: %q`, name, level.Override),
+			}
+		}
+	}
+	return nil
+}
+
+var _ plan.CheckLevelExtension = (*SectionExtension)(nil)
+
+// SectionExtension implements the Pebble plan.SectionExtension interface
+// for the health-levels section.
+type SectionExtension struct{}
+
+// DefinesCheckLevels implements plan.CheckLevelExtension, as the
+// health-levels section defines custom check levels.
+func (SectionExtension) DefinesCheckLevels() bool {
+	return true
+}
+
+func (SectionExtension) ParseSection(data yaml.Node) (plan.Section, error) {
+	section := &HealthLevelsSection{}
+	if err := plan.SectionDecode(&data, section); err != nil {
+		return nil, &plan.FormatError{
+			Message: fmt.Sprintf(`cannot parse the "health-levels" section: %v`, err),
+		}
+	}
+	for name, level := range section.Entries {
+		if level != nil {
+			level.Name = name
+		}
+	}
+	return section, nil
+}
+
+func (SectionExtension) CombineSections(sections ...plan.Section) (plan.Section, error) {
+	combined := &HealthLevelsSection{}
+	for _, section := range sections {
+		layer, ok := section.(*HealthLevelsSection)
+		if !ok {
+			return nil, fmt.Errorf("internal error: invalid section type %T", section)
+		}
+		if err := combined.combine(layer); err != nil {
+			return nil, err
+		}
+	}
+	return combined, nil
+}
+
+func (SectionExtension) ValidatePlan(p *plan.Plan) error {
+	levels := healthLevels(p)
+
+	// Iterate in sorted order so that errors are deterministic.
+	numChecks := make(map[plan.CheckLevel]int)
+	for _, name := range slices.Sorted(maps.Keys(p.Checks)) {
+		check := p.Checks[name]
+		if !levelDefined(levels, check.Level) {
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`plan check %q level %q must be "alive", "ready", or defined in "health-levels"`,
+					name, check.Level),
+			}
+		}
+		numChecks[check.Level]++
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(levels)) {
+		level := levels[name]
+		for _, included := range level.Includes {
+			if !levelDefined(levels, included) || included == plan.UnsetLevel {
+				return &plan.FormatError{
+					Message: fmt.Sprintf("plan health level %q includes non-existent level %q", name, included),
+				}
+			}
+		}
+		if n := numChecks[plan.CheckLevel(name)]; level.Quorum > n {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("plan health level %q quorum %d is greater than its number of checks (%d)",
+					name, level.Quorum, n),
+			}
+		}
+		if err := checkIncludeCycles(levels, name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIncludeCycles returns an error if the named level includes itself,
+// directly or indirectly.
+func checkIncludeCycles(levels map[string]*HealthLevel, name string, path []string) error {
+	if slices.Contains(path, name) {
+		return &plan.FormatError{
+			Message: fmt.Sprintf("plan health levels include each other: %s",
+				strings.Join(append(path, name), " -> ")),
+		}
+	}
+	level, ok := levels[name]
+	if !ok {
+		return nil
+	}
+	path = append(path, name)
+	for _, included := range level.Includes {
+		if err := checkIncludeCycles(levels, string(included), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healthLevels returns the custom levels defined in the plan's health-levels
+// section (nil if none).
+func healthLevels(p *plan.Plan) map[string]*HealthLevel {
+	if p == nil {
+		return nil
+	}
+	section, ok := p.Sections[HealthLevelsField].(*HealthLevelsSection)
+	if !ok {
+		return nil
+	}
+	return section.Entries
+}
+
+func isBuiltinLevel(level plan.CheckLevel) bool {
+	return level == plan.UnsetLevel || level == plan.AliveLevel || level == plan.ReadyLevel
+}
+
+func levelDefined(levels map[string]*HealthLevel, level plan.CheckLevel) bool {
+	if isBuiltinLevel(level) {
+		return true
+	}
+	_, ok := levels[string(level)]
+	return ok
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package checkstate_test
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/overlord/checkstate"
+	"github.com/canonical/pebble/internals/plan"
+)
+
+type healthLevelsSuite struct{}
+
+var _ = Suite(&healthLevelsSuite{})
+
+func (s *healthLevelsSuite) SetUpTest(c *C) {
+	plan.RegisterSectionExtension(checkstate.HealthLevelsField, &checkstate.SectionExtension{})
+}
+
+func (s *healthLevelsSuite) TearDownTest(c *C) {
+	plan.UnregisterSectionExtension(checkstate.HealthLevelsField)
+}
+
+// parsePlan parses and combines the given layers into a validated plan.
+func parsePlan(yamls ...string) (*plan.Plan, error) {
+	var layers []*plan.Layer
+	for i, yaml := range yamls {
+		layer, err := plan.ParseLayer(i, fmt.Sprintf("test-plan-layer-%v", i), []byte(yaml))
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	combined, err := plan.CombineLayers(layers...)
+	if err != nil {
+		return nil, err
+	}
+	p := &plan.Plan{
+		Layers:     layers,
+		Services:   combined.Services,
+		Checks:     combined.Checks,
+		LogTargets: combined.LogTargets,
+		Sections:   combined.Sections,
+	}
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+const healthLevelsChecks = `
+checks:
+    chk1:
+        override: replace
+        level: alive
+        exec:
+            command: true
+    chk2:
+        override: replace
+        level: replicas
+        exec:
+            command: true
+    chk3:
+        override: replace
+        level: replicas
+        exec:
+            command: true
+    chk4:
+        override: replace
+        level: replicas
+        exec:
+            command: true
+`
+
+var healthLevelsTests = []struct {
+	summary  string
+	layers   []string
+	combined map[string]*checkstate.HealthLevel
+	error    string
+}{{
+	summary: "simple level",
+	layers: []string{healthLevelsChecks, `
+health-levels:
+    replicas:
+        override: replace
+        includes: [alive]
+        quorum: 2
+`},
+	combined: map[string]*checkstate.HealthLevel{
+		"replicas": {
+			Name:     "replicas",
+			Override: plan.ReplaceOverride,
+			Includes: []plan.CheckLevel{plan.AliveLevel},
+			Quorum:   2,
+		},
+	},
+}, {
+	summary: "merged level",
+	layers: []string{healthLevelsChecks, `
+health-levels:
+    replicas:
+        override: replace
+        includes: [alive]
+        quorum: 2
+    startup:
+        override: replace
+`, `
+health-levels:
+    replicas:
+        override: merge
+        includes: [alive, startup]
+        quorum: 3
+`},
+	combined: map[string]*checkstate.HealthLevel{
+		"replicas": {
+			Name:     "replicas",
+			Override: plan.ReplaceOverride,
+			Includes: []plan.CheckLevel{plan.AliveLevel, "startup"},
+			Quorum:   3,
+		},
+		"startup": {
+			Name:     "startup",
+			Override: plan.ReplaceOverride,
+		},
+	},
+}, {
+	summary: "undefined check level",
+	layers:  []string{healthLevelsChecks},
+	error:   `plan check "chk2" level "replicas" must be "alive", "ready", or defined in "health-levels"`,
+}, {
+	summary: "missing override",
+	layers: []string{`
+health-levels:
+    replicas:
+        quorum: 2
+`},
+	error: `health level "replicas" must define "override" policy`,
+}, {
+	summary: "built-in level",
+	layers: []string{`
+health-levels:
+    ready:
+        override: replace
+`},
+	error: `health level "ready" is built in and cannot be redefined`,
+}, {
+	summary: "negative quorum",
+	layers: []string{`
+health-levels:
+    replicas:
+        override: replace
+        quorum: -1
+`},
+	error: `health level "replicas" quorum must not be negative`,
+}, {
+	summary: "quorum too large",
+	layers: []string{healthLevelsChecks, `
+health-levels:
+    replicas:
+        override: replace
+        quorum: 4
+`},
+	error: `plan health level "replicas" quorum 4 is greater than its number of checks \(3\)`,
+}, {
+	summary: "non-existent include",
+	layers: []string{healthLevelsChecks, `
+health-levels:
+    replicas:
+        override: replace
+        includes: [foo]
+`},
+	error: `plan health level "replicas" includes non-existent level "foo"`,
+}, {
+	summary: "include cycle",
+	layers: []string{`
+health-levels:
+    a:
+        override: replace
+        includes: [b]
+    b:
+        override: replace
+        includes: [a]
+`},
+	error: `plan health levels include each other: a -> b -> a`,
+}}
+
+func (s *healthLevelsSuite) TestHealthLevels(c *C) {
+	for _, test := range healthLevelsTests {
+		c.Logf(test.summary)
+		p, err := parsePlan(test.layers...)
+		if test.error != "" {
+			c.Assert(err, ErrorMatches, test.error)
+			continue
+		}
+		c.Assert(err, IsNil)
+		section, ok := p.Sections[checkstate.HealthLevelsField].(*checkstate.HealthLevelsSection)
+		c.Assert(ok, Equals, true)
+		c.Assert(section.Entries, DeepEquals, test.combined)
+	}
+}
+
+func (s *healthLevelsSuite) TestHealthy(c *C) {
+	p, err := parsePlan(healthLevelsChecks, `
+checks:
+    chk5:
+        override: replace
+        level: ready
+        exec:
+            command: true
+health-levels:
+    replicas:
+        override: replace
+        includes: [alive]
+        quorum: 2
+`)
+	c.Assert(err, IsNil)
+
+	checks := []*checkstate.CheckInfo{
+		{Name: "chk1", Level: plan.AliveLevel, Status: checkstate.CheckStatusUp},
+		{Name: "chk2", Level: "replicas", Status: checkstate.CheckStatusUp},
+		{Name: "chk3", Level: "replicas", Status: checkstate.CheckStatusDown},
+		{Name: "chk4", Level: "replicas", Status: checkstate.CheckStatusUp},
+		{Name: "chk5", Level: plan.ReadyLevel, Status: checkstate.CheckStatusUp},
+	}
+	healthy := func(level plan.CheckLevel, names ...string) bool {
+		healthy, err := checkstate.Healthy(p, checks, level, names)
+		c.Assert(err, IsNil)
+		return healthy
+	}
+
+	// Two of three replicas are up, which meets the quorum.
+	c.Check(healthy("replicas"), Equals, true)
+	c.Check(healthy(plan.UnsetLevel), Equals, true)
+	c.Check(healthy(plan.ReadyLevel), Equals, true)
+	c.Check(healthy("replicas", "chk2", "chk4"), Equals, true)
+	c.Check(healthy("replicas", "chk2", "chk3"), Equals, false)
+
+	// Only one replica is up.
+	checks[3].Status = checkstate.CheckStatusDown
+	c.Check(healthy("replicas"), Equals, false)
+	c.Check(healthy(plan.UnsetLevel), Equals, false)
+	c.Check(healthy(plan.ReadyLevel), Equals, true)
+
+	// The replicas have quorum again, but the included alive level is down.
+	checks[3].Status = checkstate.CheckStatusUp
+	checks[0].Status = checkstate.CheckStatusDown
+	c.Check(healthy("replicas"), Equals, false)
+	c.Check(healthy(plan.ReadyLevel), Equals, false)
+
+	_, err = checkstate.Healthy(p, checks, "foo", nil)
+	c.Check(err, ErrorMatches, `level "foo" is not defined`)
+}
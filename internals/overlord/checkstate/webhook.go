@@ -0,0 +1,135 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package checkstate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/canonical/pebble/cmd"
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/plan"
+)
+
+const webhookTimeout = 10 * time.Second
+
+var (
+	webhookAttempts       = 5
+	webhookInitialBackoff = time.Second
+	webhookMaxBackoff     = 30 * time.Second
+)
+
+// webhookPayload is the JSON body POSTed to a check's on-status-change
+// webhook.
+type webhookPayload struct {
+	Check          string      `json:"check"`
+	Status         CheckStatus `json:"status"`
+	PreviousStatus CheckStatus `json:"previous-status"`
+	Failures       int         `json:"failures"`
+	Threshold      int         `json:"threshold"`
+	Error          string      `json:"error,omitempty"`
+	Details        string      `json:"details,omitempty"`
+	Time           time.Time   `json:"time"`
+}
+
+// webhookSender calls webhooks in the background, so that slow or failing
+// webhooks don't hold up the checks.
+type webhookSender struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWebhookSender() *webhookSender {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookSender{ctx: ctx, cancel: cancel}
+}
+
+// send POSTs the payload to the action's webhook. If the request fails or
+// the response status isn't 2xx, it's retried with exponential backoff.
+func (s *webhookSender) send(action *plan.CheckStatusAction, payload *webhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Noticef("Internal error: cannot marshal webhook payload for check %q: %v", payload.Check, err)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		backoff := webhookInitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := postWebhook(s.ctx, action, body)
+			if err == nil {
+				return
+			}
+			if attempt >= webhookAttempts || s.ctx.Err() != nil {
+				logger.Noticef("Cannot call webhook for check %q after %s: %v",
+					payload.Check, pluralise(attempt, "attempt", "attempts"), err)
+				return
+			}
+			logger.Debugf("Cannot call webhook for check %q (attempt %d), retrying in %v: %v",
+				payload.Check, attempt, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-s.ctx.Done():
+				logger.Noticef("Cannot call webhook for check %q: %v", payload.Check, err)
+				return
+			}
+			backoff = min(backoff*2, webhookMaxBackoff)
+		}
+	}()
+}
+
+// stop cancels the webhook calls in progress and waits for them to finish.
+func (s *webhookSender) stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func postWebhook(ctx context.Context, action *plan.CheckStatusAction, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, "POST", action.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot build request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", fmt.Sprintf("%s/%s", cmd.ProgramName, cmd.Version))
+	for k, v := range action.Headers {
+		request.Header.Set(k, v)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Read some of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBytes))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("non-2xx status code %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package checkstate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/plan"
+)
+
+type webhookSuite struct {
+	oldInitialBackoff time.Duration
+	oldMaxBackoff     time.Duration
+}
+
+var _ = Suite(&webhookSuite{})
+
+func (s *webhookSuite) SetUpTest(c *C) {
+	s.oldInitialBackoff, s.oldMaxBackoff = webhookInitialBackoff, webhookMaxBackoff
+	webhookInitialBackoff = time.Millisecond
+	webhookMaxBackoff = 4 * time.Millisecond
+}
+
+func (s *webhookSuite) TearDownTest(c *C) {
+	webhookInitialBackoff, webhookMaxBackoff = s.oldInitialBackoff, s.oldMaxBackoff
+}
+
+func (s *webhookSuite) TestRetry(c *C) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender()
+	sender.send(&plan.CheckStatusAction{Webhook: server.URL}, &webhookPayload{Check: "chk1"})
+	waitCalls(c, &calls, 3)
+	sender.stop()
+	c.Check(calls.Load(), Equals, int32(3))
+}
+
+func (s *webhookSuite) TestGiveUp(c *C) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender()
+	sender.send(&plan.CheckStatusAction{Webhook: server.URL}, &webhookPayload{Check: "chk1"})
+	waitCalls(c, &calls, int32(webhookAttempts))
+	// Give it a chance to (incorrectly) make more attempts.
+	time.Sleep(20 * time.Millisecond)
+	sender.stop()
+	c.Check(calls.Load(), Equals, int32(webhookAttempts))
+}
+
+func (s *webhookSuite) TestStopCancels(c *C) {
+	webhookInitialBackoff = time.Hour
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender()
+	sender.send(&plan.CheckStatusAction{Webhook: server.URL}, &webhookPayload{Check: "chk1"})
+	waitCalls(c, &calls, 1)
+
+	done := make(chan struct{})
+	go func() {
+		sender.stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatalf("timed out waiting for webhook sender to stop")
+	}
+	c.Check(calls.Load(), Equals, int32(1))
+}
+
+func waitCalls(c *C, calls *atomic.Int32, n int32) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		if calls.Load() >= n {
+			return
+		}
+	}
+	c.Fatalf("timed out waiting for %d calls, got %d", n, calls.Load())
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package clientutil holds the parts shared by the log clients that send
+// batches of logs over HTTP: the buffer of logs waiting to be sent, and the
+// handling of the server's response.
+package clientutil
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/canonical/pebble/internals/logger"
+)
+
+// Buffer holds up to a maximum number of log entries waiting to be sent. If
+// it's full, adding an entry drops the oldest one.
+type Buffer[T any] struct {
+	maxEntries int
+
+	// To store log entries, keep a buffer of size 2*maxEntries with a
+	// sliding window 'entries' of size maxEntries
+	buffer  []T
+	entries []T
+}
+
+// NewBuffer returns an empty buffer for up to maxEntries log entries.
+func NewBuffer[T any](maxEntries int) *Buffer[T] {
+	b := &Buffer[T]{
+		maxEntries: maxEntries,
+		buffer:     make([]T, 2*maxEntries),
+	}
+	// b.entries should be backed by the same array as b.buffer
+	b.entries = b.buffer[:0]
+	return b
+}
+
+// Add adds an entry to the buffer, dropping the oldest entry if it's full.
+func (b *Buffer[T]) Add(entry T) {
+	var zero T
+	if len(b.entries) >= b.maxEntries {
+		// 'entries' is full - remove the first element to make room
+		// Zero the removed element to allow garbage collection
+		b.entries[0] = zero
+		b.entries = b.entries[1:]
+	}
+
+	if len(b.entries) >= cap(b.entries) {
+		// Copy all the elements to the start of the buffer
+		copy(b.buffer, b.entries)
+
+		// Reset the view into the buffer
+		b.entries = b.buffer[:len(b.entries):len(b.buffer)]
+
+		// Zero removed elements to allow garbage collection
+		for i := len(b.entries); i < len(b.buffer); i++ {
+			b.buffer[i] = zero
+		}
+	}
+
+	b.entries = append(b.entries, entry)
+}
+
+// Entries returns the buffered entries, oldest first. The slice is only
+// valid until the buffer is next changed.
+func (b *Buffer[T]) Entries() []T {
+	return b.entries
+}
+
+// Len returns the number of buffered entries.
+func (b *Buffer[T]) Len() int {
+	return len(b.entries)
+}
+
+// Reset drops all buffered entries (in the case of a successful send, or an
+// unrecoverable error).
+func (b *Buffer[T]) Reset() {
+	// Zero removed elements to allow garbage collection
+	clear(b.entries)
+	b.entries = b.buffer[:0]
+}
+
+// HandleResponse determines what to do with the buffered logs based on the
+// server's response to a request to send them, and closes the response body.
+// 4xx and 5xx responses indicate errors, so in this case, we will bubble up
+// the error to the caller.
+func HandleResponse[T any](resp *http.Response, targetName string, buffer *Buffer[T]) error {
+	defer func() {
+		// Drain request body to allow connection reuse
+		// see https://pkg.go.dev/net/http#Response.Body
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+		_ = resp.Body.Close()
+	}()
+
+	code := resp.StatusCode
+	switch {
+	case 200 <= code && code < 300:
+		// Success - safe to drop logs
+		buffer.Reset()
+		return nil
+
+	case code == http.StatusTooManyRequests:
+		// For 429, don't drop logs - just retry later
+		return errFromResponse(resp)
+
+	case 400 <= code && code < 500:
+		// Other 4xx codes indicate a client problem, so drop the logs (retrying won't help)
+		logger.LocalNoticef("Target %q: request failed with status %d, dropping %d logs",
+			targetName, code, buffer.Len())
+		buffer.Reset()
+		return errFromResponse(resp)
+
+	case 500 <= code && code < 600:
+		// 5xx indicates a problem with the server, so don't drop logs (retry later)
+		return errFromResponse(resp)
+
+	default:
+		// Unexpected response - don't drop logs to be safe
+		return fmt.Errorf("unexpected response from server: %v", resp.Status)
+	}
+}
+
+// errFromResponse generates an error from a failed *http.Response.
+// Note: this function reads the response body.
+func errFromResponse(resp *http.Response) error {
+	// Read response body to get more context
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err == nil {
+		logger.Debugf("HTTP %d error, response %q", resp.StatusCode, body)
+	} else {
+		logger.Debugf("HTTP %d error, but cannot read response: %v", resp.StatusCode, err)
+	}
+
+	return fmt.Errorf("server returned HTTP %v", resp.Status)
+}
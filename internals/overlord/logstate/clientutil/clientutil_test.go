@@ -0,0 +1,111 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package clientutil_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/logstate/clientutil"
+)
+
+type suite struct{}
+
+var _ = Suite(&suite{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+func (*suite) TestBufferFull(c *C) {
+	buffer := clientutil.NewBuffer[string](3)
+
+	// Check that the underlying buffer is as expected, with removed
+	// entries zeroed
+	checkBuffer := func(expected ...string) {
+		c.Assert(clientutil.GetBuffer(buffer), DeepEquals, expected)
+	}
+
+	checkBuffer("", "", "", "", "", "")
+	buffer.Add("1")
+	checkBuffer("1", "", "", "", "", "")
+	buffer.Add("2")
+	checkBuffer("1", "2", "", "", "", "")
+	buffer.Add("3")
+	checkBuffer("1", "2", "3", "", "", "")
+	buffer.Add("4")
+	checkBuffer("", "2", "3", "4", "", "")
+	buffer.Add("5")
+	checkBuffer("", "", "3", "4", "5", "")
+	buffer.Add("6")
+	checkBuffer("", "", "", "4", "5", "6")
+	buffer.Add("7")
+	checkBuffer("5", "6", "7", "", "", "")
+	c.Assert(buffer.Entries(), DeepEquals, []string{"5", "6", "7"})
+	c.Assert(buffer.Len(), Equals, 3)
+
+	buffer.Reset()
+	checkBuffer("", "", "", "", "", "")
+	c.Assert(buffer.Entries(), HasLen, 0)
+	c.Assert(buffer.Len(), Equals, 0)
+}
+
+func (*suite) TestHandleResponse(c *C) {
+	logBuf, restore := logger.MockLogger("")
+	defer restore()
+
+	tests := []struct {
+		code    int
+		error   string
+		dropped bool
+	}{
+		{http.StatusOK, "", true},
+		{http.StatusNoContent, "", true},
+		{http.StatusAccepted, "", true},
+		{http.StatusTooManyRequests, "server returned HTTP 429 Too Many Requests", false},
+		{http.StatusBadRequest, "server returned HTTP 400 Bad Request", true},
+		{http.StatusInternalServerError, "server returned HTTP 500 Internal Server Error", false},
+		{http.StatusFound, "unexpected response from server: 302 Found", false},
+	}
+	for _, test := range tests {
+		c.Logf("Status %d", test.code)
+		buffer := clientutil.NewBuffer[string](3)
+		buffer.Add("1")
+		buffer.Add("2")
+		resp := &http.Response{
+			StatusCode: test.code,
+			Status:     fmt.Sprintf("%d %s", test.code, http.StatusText(test.code)),
+			Body:       io.NopCloser(strings.NewReader("body")),
+		}
+		err := clientutil.HandleResponse(resp, "tgt1", buffer)
+		if test.error == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, test.error)
+		}
+		if test.dropped {
+			c.Check(buffer.Len(), Equals, 0)
+		} else {
+			c.Check(buffer.Entries(), DeepEquals, []string{"1", "2"})
+		}
+	}
+	c.Check(logBuf.String(), Matches, `(?s).*Target "tgt1": request failed with status 400, dropping 2 logs\n`)
+}
@@ -16,19 +16,25 @@ package logstate
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/tomb.v2"
 
 	"github.com/canonical/pebble/cmd"
 	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/logstate/httpjson"
 	"github.com/canonical/pebble/internals/overlord/logstate/loki"
 	"github.com/canonical/pebble/internals/overlord/logstate/opentelemetry"
 	"github.com/canonical/pebble/internals/overlord/logstate/syslog"
 	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/servicelog"
+	"github.com/canonical/pebble/internals/tlsutil"
 )
 
 const (
@@ -43,6 +49,12 @@ const (
 	// timeoutFinalFlush is measured from when the gatherer's main loop finishes,
 	// NOT from when Stop() is called like the other constants.
 	timeoutFinalFlush = 2 * time.Second
+
+	// Delay before the first retry of queued logs, which doubles after each
+	// failed attempt (up to the spool's backoff-limit).
+	spoolInitialBackoff = 1 * time.Second
+	// Delay between sending batches when replaying queued logs.
+	spoolReplayDelay = 10 * time.Millisecond
 )
 
 // logGatherer is responsible for collecting service logs from a bunch of
@@ -80,6 +92,17 @@ type logGatherer struct {
 	pullers *pullerGroup
 	// All pullers send logs on this channel, received by main loop
 	entryCh chan servicelog.Entry
+
+	// On-disk queue of logs still to be sent, if the target has a spool.
+	// Only used by the main loop.
+	spool        *spool
+	backoffLimit time.Duration
+	retryDelay   time.Duration
+	retryAt      time.Time
+
+	// Metrics for the spool, read by WriteMetrics.
+	droppedCount atomic.Int64
+	queueDepth   atomic.Int64
 }
 
 // logGathererOptions allows overriding the newLogClient method and time values
@@ -89,12 +112,18 @@ type logGathererOptions struct {
 	maxBufferedEntries  int
 	timeoutCurrentFlush time.Duration
 	timeoutFinalFlush   time.Duration
+	// Directory containing the spool directory of each target
+	spoolDir string
 	// method to get a new client
 	newClient func(*plan.LogTarget) (logClient, error)
 }
 
-func newLogGatherer(target *plan.LogTarget) (*logGatherer, error) {
-	return newLogGathererInternal(target, &logGathererOptions{})
+func newLogGatherer(target *plan.LogTarget, spoolDir string) (*logGatherer, error) {
+	return newLogGathererInternal(target, &logGathererOptions{
+		// Flush when the target's batch is full (zero means the default).
+		maxBufferedEntries: target.BatchSize,
+		spoolDir:           spoolDir,
+	})
 }
 
 // newLogGathererInternal contains the actual creation code for a logGatherer.
@@ -116,6 +145,20 @@ func newLogGathererInternal(target *plan.LogTarget, options *logGathererOptions)
 		entryCh:    make(chan servicelog.Entry),
 		pullers:    newPullerGroup(target.Name),
 	}
+	if target.Spool != nil {
+		if _, ok := client.(spoolClient); !ok {
+			return nil, fmt.Errorf("cannot use spool with log target type %q", target.Type)
+		}
+		if options.spoolDir == "" {
+			return nil, fmt.Errorf("cannot use spool: no spool directory")
+		}
+		g.spool, err = openSpool(filepath.Join(options.spoolDir, target.Name), target.Spool.MaxSizeBytes())
+		if err != nil {
+			return nil, fmt.Errorf("cannot open spool: %w", err)
+		}
+		g.backoffLimit = target.Spool.BackoffLimitDuration()
+		g.queueDepth.Store(int64(g.spool.Len()))
+	}
 	g.clientCtx, g.clientCancel = context.WithCancel(context.Background())
 	g.tomb.Go(g.loop)
 	g.tomb.Go(g.pullers.tomb.Wait)
@@ -154,6 +197,9 @@ func (g *logGatherer) PlanChanged(pl *plan.Plan, buffers map[string]*servicelog.
 			// We're still collecting logs from this service, so don't remove it.
 			continue
 		}
+		if slices.Contains(plan.LogSources, svcName) && target.LogsFromSource(svcName) {
+			continue
+		}
 
 		// Service no longer forwarding to this log target (or it was removed from
 		// the plan). Remove it from the gatherer.
@@ -183,7 +229,24 @@ func (g *logGatherer) PlanChanged(pl *plan.Plan, buffers map[string]*servicelog.
 		// pullers inside ServiceStarted.
 		buffer, svcStarted := buffers[service.Name]
 		if svcStarted {
-			g.pullers.Add(service.Name, buffer, g.entryCh)
+			g.pullers.Add(service.Name, servicelog.Format(service.LogFormat), buffer, g.entryCh)
+		}
+	}
+
+	// Add pullers for Pebble's own log sources. Their buffers live as long
+	// as the daemon, so existing pullers are kept to avoid resending logs.
+	for _, source := range plan.LogSources {
+		buffer, ok := buffers[source]
+		if !ok || !target.LogsFromSource(source) {
+			continue
+		}
+		select {
+		case g.setLabels <- svcWithLabels{source, evaluateLabels(target.Labels, nil)}:
+		case <-g.tomb.Dying():
+			return
+		}
+		if !g.pullers.contains(source) {
+			g.pullers.Add(source, sourceFormat(source), buffer, g.entryCh)
 		}
 	}
 }
@@ -191,7 +254,7 @@ func (g *logGatherer) PlanChanged(pl *plan.Plan, buffers map[string]*servicelog.
 // ServiceStarted is called by the LogManager on the start of a service which
 // logs to this gatherer's target.
 func (g *logGatherer) ServiceStarted(service *plan.Service, buffer *servicelog.RingBuffer) {
-	g.pullers.Add(service.Name, buffer, g.entryCh)
+	g.pullers.Add(service.Name, servicelog.Format(service.LogFormat), buffer, g.entryCh)
 }
 
 // evaluateLabels interprets the labels defined in the plan, substituting any
@@ -221,11 +284,25 @@ func (g *logGatherer) loop() error {
 	flushClient := func(ctx context.Context) {
 		// Mark timer as unset
 		flushTimer.Stop()
+		numWritten = 0
+		if g.spool != nil {
+			if delay := g.flushSpool(ctx); delay > 0 {
+				flushTimer.EnsureSet(delay)
+			}
+			return
+		}
 		err := g.client.Flush(ctx)
 		if err != nil {
-			logger.Noticef("Cannot flush logs to target %q: %v", g.targetName, err)
+			logger.LocalNoticef("Cannot flush logs to target %q: %v", g.targetName, err)
+		}
+	}
+
+	if g.spool != nil {
+		defer g.spool.Close()
+		if g.spool.Len() > 0 {
+			// Replay logs queued before a restart.
+			flushTimer.EnsureSet(g.bufferTimeout)
 		}
-		numWritten = 0
 	}
 
 mainLoop:
@@ -244,9 +321,14 @@ mainLoop:
 			g.client.SetLabels(args.service, args.labels)
 
 		case entry := <-g.entryCh:
-			err := g.client.Add(entry)
+			var err error
+			if g.spool != nil {
+				err = g.spoolEntry(entry)
+			} else {
+				err = g.client.Add(entry)
+			}
 			if err != nil {
-				logger.Noticef("Cannot write logs to target %q: %v", g.targetName, err)
+				logger.LocalNoticef("Cannot write logs to target %q: %v", g.targetName, err)
 				continue
 			}
 			numWritten++
@@ -268,6 +350,80 @@ mainLoop:
 	return nil
 }
 
+// spoolEntry adds a log entry to the spool, counting any older logs dropped
+// to make room for it.
+func (g *logGatherer) spoolEntry(entry servicelog.Entry) error {
+	dropped, err := g.spool.Append(entry)
+	if dropped > 0 {
+		logger.LocalNoticef("Spool for target %q is full, dropped %d logs", g.targetName, dropped)
+		g.droppedCount.Add(int64(dropped))
+	}
+	g.queueDepth.Store(int64(g.spool.Len()))
+	return err
+}
+
+// flushSpool sends the next batch of logs from the spool, and removes the
+// logs that were sent (or dropped by the client). It returns the delay
+// before the next flush if logs remain queued, or zero.
+func (g *logGatherer) flushSpool(ctx context.Context) time.Duration {
+	if wait := time.Until(g.retryAt); wait > 0 {
+		// Still backing off after a failed attempt.
+		return wait
+	}
+	entries, err := g.spool.Read(g.maxBufferedEntries)
+	if err != nil {
+		logger.LocalNoticef("Cannot read spool for target %q: %v", g.targetName, err)
+		return g.backoff()
+	}
+	if len(entries) == 0 {
+		return 0
+	}
+	for _, entry := range entries {
+		err := g.client.Add(entry)
+		if err != nil {
+			logger.LocalNoticef("Cannot write logs to target %q: %v", g.targetName, err)
+		}
+	}
+
+	done := len(entries)
+	flushErr := g.client.Flush(ctx)
+	if flushErr != nil {
+		// The client keeps the logs it can retry, and drops the rest (for
+		// example, if the target rejected them). The spool has its own copy
+		// of the logs to retry, so discard the client's.
+		remaining := g.client.(spoolClient).Discard()
+		if remaining == 0 {
+			logger.LocalNoticef("Cannot flush logs to target %q, dropped %d logs: %v", g.targetName, done, flushErr)
+			g.droppedCount.Add(int64(done))
+		} else {
+			done -= remaining
+		}
+	}
+	err = g.spool.Remove(done)
+	if err != nil {
+		logger.LocalNoticef("Cannot remove logs from spool for target %q: %v", g.targetName, err)
+	}
+	g.queueDepth.Store(int64(g.spool.Len()))
+
+	if flushErr != nil && done < len(entries) {
+		delay := g.backoff()
+		logger.LocalNoticef("Cannot flush logs to target %q, retrying in %v: %v", g.targetName, delay, flushErr)
+		return delay
+	}
+	g.retryDelay = 0
+	if g.spool.Len() > 0 {
+		return spoolReplayDelay
+	}
+	return 0
+}
+
+// backoff increases the retry delay exponentially, and returns it.
+func (g *logGatherer) backoff() time.Duration {
+	g.retryDelay = min(max(2*g.retryDelay, spoolInitialBackoff), g.backoffLimit)
+	g.retryAt = time.Now().Add(g.retryDelay)
+	return g.retryDelay
+}
+
 // Stop tears down the gatherer and associated resources (pullers, client).
 // This method will block until gatherer teardown is complete.
 //
@@ -367,20 +523,52 @@ type logClient interface {
 	SetLabels(serviceName string, labels map[string]string)
 }
 
+// spoolClient is a logClient which can be used with a spool.
+type spoolClient interface {
+	logClient
+
+	// Discard drops any logs still buffered after a failed Flush, returning
+	// how many were dropped, so that they can be retried from the spool.
+	Discard() int
+}
+
 func newLogClient(target *plan.LogTarget) (logClient, error) {
 	switch target.Type {
 	case plan.LokiTarget:
+		transport, err := newTransport(target)
+		if err != nil {
+			return nil, err
+		}
 		return loki.NewClient(&loki.ClientOptions{
 			TargetName: target.Name,
 			Location:   target.Location,
 			UserAgent:  fmt.Sprintf("%s/%s", cmd.ProgramName, cmd.Version),
+			Transport:  transport,
 		}), nil
 	case plan.OpenTelemetryTarget:
+		transport, err := newTransport(target)
+		if err != nil {
+			return nil, err
+		}
 		return opentelemetry.NewClient(&opentelemetry.ClientOptions{
 			TargetName: target.Name,
 			Location:   target.Location,
 			UserAgent:  fmt.Sprintf("%s/%s", cmd.ProgramName, cmd.Version),
 			ScopeName:  cmd.ProgramName,
+			Transport:  transport,
+		}), nil
+	case plan.HTTPJSONTarget:
+		transport, err := newTransport(target)
+		if err != nil {
+			return nil, err
+		}
+		return httpjson.NewClient(&httpjson.ClientOptions{
+			TargetName:        target.Name,
+			Location:          target.Location,
+			UserAgent:         fmt.Sprintf("%s/%s", cmd.ProgramName, cmd.Version),
+			MaxRequestEntries: target.BatchSize,
+			Gzip:              target.Gzip != nil && *target.Gzip,
+			Transport:         transport,
 		}), nil
 	case plan.SyslogTarget:
 		hostname, err := os.Hostname()
@@ -388,10 +576,18 @@ func newLogClient(target *plan.LogTarget) (logClient, error) {
 			logger.Noticef("Cannot get hostname for syslog: %v", err)
 			hostname = ""
 		}
+		var tlsConfig *tls.Config
+		if target.TLS != nil {
+			tlsConfig, err = tlsutil.ClientConfig(target.TLS)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return syslog.NewClient(&syslog.ClientOptions{
-			Location: target.Location,
-			Hostname: hostname,
-			SDID:     cmd.ProgramName,
+			Location:  target.Location,
+			Hostname:  hostname,
+			SDID:      cmd.ProgramName,
+			TLSConfig: tlsConfig,
 		})
 	default:
 		return nil, fmt.Errorf("unknown type %q for log target %q", target.Type, target.Name)
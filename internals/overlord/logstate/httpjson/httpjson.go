@@ -0,0 +1,182 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package httpjson implements a log client that sends logs as newline-delimited
+// JSON over HTTP, for collectors that don't speak the Loki or OpenTelemetry
+// protocols.
+package httpjson
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/canonical/pebble/internals/overlord/logstate/clientutil"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+const (
+	requestTimeout    = 10 * time.Second
+	maxRequestEntries = 100
+)
+
+type Client struct {
+	options    *ClientOptions
+	httpClient *http.Client
+
+	buffer *clientutil.Buffer[servicelog.Entry]
+
+	// store the custom labels for each service
+	labels map[string]map[string]string
+}
+
+func NewClient(options *ClientOptions) *Client {
+	opts := *options
+	fillDefaultOptions(&opts)
+	c := &Client{
+		options:    &opts,
+		httpClient: &http.Client{Timeout: opts.RequestTimeout, Transport: opts.Transport},
+		buffer:     clientutil.NewBuffer[servicelog.Entry](opts.MaxRequestEntries),
+		labels:     make(map[string]map[string]string),
+	}
+	return c
+}
+
+// ClientOptions allows overriding default parameters (e.g. for testing)
+type ClientOptions struct {
+	RequestTimeout    time.Duration
+	MaxRequestEntries int
+	UserAgent         string
+	TargetName        string
+	Location          string
+
+	// Gzip enables gzip compression of request bodies.
+	Gzip bool
+
+	// Transport, if set, is used to make the HTTP requests, for example to
+	// add authentication or use custom TLS settings.
+	Transport http.RoundTripper
+}
+
+func fillDefaultOptions(options *ClientOptions) {
+	if options.RequestTimeout == 0 {
+		options.RequestTimeout = requestTimeout
+	}
+	if options.MaxRequestEntries == 0 {
+		options.MaxRequestEntries = maxRequestEntries
+	}
+}
+
+func (c *Client) SetLabels(serviceName string, labels map[string]string) {
+	if labels == nil {
+		delete(c.labels, serviceName)
+		return
+	}
+	// Make a copy to avoid altering the original map
+	c.labels[serviceName] = maps.Clone(labels)
+}
+
+func (c *Client) Add(entry servicelog.Entry) error {
+	c.buffer.Add(entry)
+	return nil
+}
+
+// jsonEntry is the JSON object sent for each log entry, one per line.
+type jsonEntry struct {
+	Time       time.Time         `json:"time"`
+	Service    string            `json:"service"`
+	Message    string            `json:"message"`
+	Level      string            `json:"level,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+func (c *Client) Flush(ctx context.Context) error {
+	if c.buffer.Len() == 0 {
+		return nil // no-op
+	}
+
+	body, err := c.buildBody()
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.Location, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create HTTP request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-ndjson")
+	httpReq.Header.Set("User-Agent", c.options.UserAgent)
+	if c.options.Gzip {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+
+	return clientutil.HandleResponse(resp, c.options.TargetName, c.buffer)
+}
+
+// buildBody encodes the buffered entries as newline-delimited JSON,
+// compressing the result if required.
+func (c *Client) buildBody() ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gzipWriter *gzip.Writer
+	if c.options.Gzip {
+		gzipWriter = gzip.NewWriter(&buf)
+		w = gzipWriter
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, entry := range c.buffer.Entries() {
+		err := encoder.Encode(jsonEntry{
+			Time:       entry.Time.UTC(),
+			Service:    entry.Service,
+			Message:    strings.TrimSuffix(entry.Message, "\n"),
+			Level:      entry.Level,
+			Attributes: entry.Attributes,
+			Labels:     c.labels[entry.Service],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode log entry to JSON: %v", err)
+		}
+	}
+
+	if gzipWriter != nil {
+		err := gzipWriter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot compress request: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Discard drops any logs still buffered after a failed Flush, returning how
+// many were dropped. It's used when the caller keeps its own copy of the
+// logs to retry later.
+func (c *Client) Discard() int {
+	n := c.buffer.Len()
+	c.buffer.Reset()
+	return n
+}
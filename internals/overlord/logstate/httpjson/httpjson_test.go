@@ -0,0 +1,173 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package httpjson_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/overlord/logstate/httpjson"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+type suite struct{}
+
+var _ = Suite(&suite{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+func (*suite) TestRequest(c *C) {
+	input := []servicelog.Entry{{
+		Time:    time.Date(2023, 12, 31, 12, 34, 50, 0, time.UTC),
+		Service: "svc1",
+		Message: "log line #1\n",
+	}, {
+		Time:    time.Date(2023, 12, 31, 12, 34, 51, 0, time.UTC),
+		Service: "svc2",
+		Message: "log line #2\n",
+	}, {
+		Time:       time.Date(2023, 12, 31, 12, 34, 52, 0, time.UTC),
+		Service:    "svc1",
+		Message:    "log line #3\n",
+		Level:      "warn",
+		Attributes: map[string]string{"attempt": "2"},
+	}}
+
+	expected := `{"time":"2023-12-31T12:34:50Z","service":"svc1","message":"log line #1","labels":{"env":"prod"}}
+{"time":"2023-12-31T12:34:51Z","service":"svc2","message":"log line #2"}
+{"time":"2023-12-31T12:34:52Z","service":"svc1","message":"log line #3","level":"warn","attributes":{"attempt":"2"},"labels":{"env":"prod"}}
+`
+
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+		c.Assert(r.Header.Get("Content-Type"), Equals, "application/x-ndjson")
+		c.Assert(r.Header.Get("User-Agent"), Equals, "pebble/1.23.0")
+
+		reqBody, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(reqBody), Equals, expected)
+		received++
+	}))
+	defer server.Close()
+
+	client := httpjson.NewClient(&httpjson.ClientOptions{
+		Location:  server.URL,
+		UserAgent: "pebble/1.23.0",
+	})
+	client.SetLabels("svc1", map[string]string{"env": "prod"})
+	client.SetLabels("svc2", map[string]string{})
+	for _, entry := range input {
+		err := client.Add(entry)
+		c.Assert(err, IsNil)
+	}
+
+	err := client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(received, Equals, 1)
+
+	// Buffer was reset, so nothing more is sent.
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(received, Equals, 1)
+}
+
+func (*suite) TestGzip(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Content-Encoding"), Equals, "gzip")
+
+		reader, err := gzip.NewReader(r.Body)
+		c.Assert(err, IsNil)
+		reqBody, err := io.ReadAll(reader)
+		c.Assert(err, IsNil)
+		c.Assert(string(reqBody), Equals, `{"time":"2023-12-31T12:34:50Z","service":"svc1","message":"hello"}`+"\n")
+	}))
+	defer server.Close()
+
+	client := httpjson.NewClient(&httpjson.ClientOptions{
+		Location: server.URL,
+		Gzip:     true,
+	})
+	err := client.Add(servicelog.Entry{
+		Time:    time.Date(2023, 12, 31, 12, 34, 50, 0, time.UTC),
+		Service: "svc1",
+		Message: "hello\n",
+	})
+	c.Assert(err, IsNil)
+
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+}
+
+func (*suite) TestServerErrors(c *C) {
+	status := http.StatusServiceUnavailable
+	var lines int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		lines = 0
+		for _, b := range reqBody {
+			if b == '\n' {
+				lines++
+			}
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := httpjson.NewClient(&httpjson.ClientOptions{
+		Location:          server.URL,
+		MaxRequestEntries: 3,
+	})
+	add := func(message string) {
+		err := client.Add(servicelog.Entry{Service: "svc1", Message: message})
+		c.Assert(err, IsNil)
+	}
+
+	// 5xx errors keep the logs to retry later.
+	add("1")
+	add("2")
+	err := client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 503 Service Unavailable")
+	c.Assert(lines, Equals, 2)
+
+	// At most MaxRequestEntries are kept, dropping the oldest.
+	add("3")
+	add("4")
+	err = client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 503 Service Unavailable")
+	c.Assert(lines, Equals, 3)
+
+	// 4xx errors drop the logs.
+	status = http.StatusBadRequest
+	err = client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 400 Bad Request")
+	c.Assert(lines, Equals, 3)
+
+	status = http.StatusAccepted
+	add("5")
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(lines, Equals, 1)
+}
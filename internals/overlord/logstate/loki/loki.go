@@ -19,7 +19,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"maps"
 	"net/http"
 	"sort"
@@ -28,6 +27,7 @@ import (
 	"time"
 
 	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/logstate/clientutil"
 	"github.com/canonical/pebble/internals/servicelog"
 )
 
@@ -40,10 +40,7 @@ type Client struct {
 	options    *ClientOptions
 	httpClient *http.Client
 
-	// To store log entries, keep a buffer of size 2*MaxRequestEntries with a
-	// sliding window 'entries' of size MaxRequestEntries
-	buffer  []entryWithService
-	entries []entryWithService
+	buffer *clientutil.Buffer[entryWithService]
 
 	// store the custom labels for each service
 	labels map[string]json.RawMessage
@@ -54,12 +51,10 @@ func NewClient(options *ClientOptions) *Client {
 	fillDefaultOptions(&opts)
 	c := &Client{
 		options:    &opts,
-		httpClient: &http.Client{Timeout: opts.RequestTimeout},
-		buffer:     make([]entryWithService, 2*opts.MaxRequestEntries),
+		httpClient: &http.Client{Timeout: opts.RequestTimeout, Transport: opts.Transport},
+		buffer:     clientutil.NewBuffer[entryWithService](opts.MaxRequestEntries),
 		labels:     make(map[string]json.RawMessage),
 	}
-	// c.entries should be backed by the same array as c.buffer
-	c.entries = c.buffer[:0]
 	return c
 }
 
@@ -70,6 +65,10 @@ type ClientOptions struct {
 	UserAgent         string
 	TargetName        string
 	Location          string
+
+	// Transport, if set, is used to make the HTTP requests, for example to
+	// add authentication or use custom TLS settings.
+	Transport http.RoundTripper
 }
 
 func fillDefaultOptions(options *ClientOptions) {
@@ -103,42 +102,32 @@ func (c *Client) SetLabels(serviceName string, labels map[string]string) {
 }
 
 func (c *Client) Add(entry servicelog.Entry) error {
-	if len(c.entries) >= c.options.MaxRequestEntries {
-		// 'entries' is full - remove the first element to make room
-		// Zero the removed element to allow garbage collection
-		c.entries[0] = entryWithService{}
-		c.entries = c.entries[1:]
-	}
-
-	if len(c.entries) >= cap(c.entries) {
-		// Copy all the elements to the start of the buffer
-		copy(c.buffer, c.entries)
-
-		// Reset the view into the buffer
-		c.entries = c.buffer[:len(c.entries):len(c.buffer)]
-
-		// Zero removed elements to allow garbage collection
-		for i := len(c.entries); i < len(c.buffer); i++ {
-			c.buffer[i] = entryWithService{}
-		}
-	}
-
-	c.entries = append(c.entries, entryWithService{
+	c.buffer.Add(entryWithService{
 		entry:   encodeEntry(entry),
 		service: entry.Service,
+		level:   entry.Level,
 	})
 	return nil
 }
 
 func encodeEntry(entry servicelog.Entry) lokiEntry {
+	line := strings.TrimSuffix(entry.Message, "\n")
+	if len(entry.Attributes) > 0 {
+		// Append attributes in logfmt format, which Loki can parse at query
+		// time, rather than as labels, which must have low cardinality.
+		if line != "" {
+			line += " "
+		}
+		line += servicelog.FormatAttributes(entry.Attributes)
+	}
 	return lokiEntry{
 		strconv.FormatInt(entry.Time.UnixNano(), 10),
-		strings.TrimSuffix(entry.Message, "\n"),
+		line,
 	}
 }
 
 func (c *Client) Flush(ctx context.Context) error {
-	if len(c.entries) == 0 {
+	if c.buffer.Len() == 0 {
 		return nil // no-op
 	}
 
@@ -160,38 +149,43 @@ func (c *Client) Flush(ctx context.Context) error {
 		return err
 	}
 
-	return c.handleServerResponse(resp)
+	return clientutil.HandleResponse(resp, c.options.TargetName, c.buffer)
 }
 
-// resetBuffer drops all buffered logs (in the case of a successful send, or an
-// unrecoverable error).
-func (c *Client) resetBuffer() {
-	// Zero removed elements to allow garbage collection
-	for i := 0; i < len(c.entries); i++ {
-		c.entries[i] = entryWithService{}
-	}
-	c.entries = c.buffer[:0]
+// Discard drops any logs still buffered after a failed Flush, returning how
+// many were dropped. It's used when the caller keeps its own copy of the
+// logs to retry later.
+func (c *Client) Discard() int {
+	n := c.buffer.Len()
+	c.buffer.Reset()
+	return n
 }
 
 func (c *Client) buildRequest() lokiRequest {
-	// Put entries into service "buckets"
-	bucketedEntries := map[string][]lokiEntry{}
-	for _, data := range c.entries {
-		bucketedEntries[data.service] = append(bucketedEntries[data.service], data.entry)
+	// Put entries into service (and level, if set) "buckets"
+	bucketedEntries := map[streamKey][]lokiEntry{}
+	for _, data := range c.buffer.Entries() {
+		key := streamKey{data.service, data.level}
+		bucketedEntries[key] = append(bucketedEntries[key], data.entry)
 	}
 
-	// Sort service names to guarantee deterministic output
-	var services []string
-	for service := range bucketedEntries {
-		services = append(services, service)
+	// Sort streams to guarantee deterministic output
+	var keys []streamKey
+	for key := range bucketedEntries {
+		keys = append(keys, key)
 	}
-	sort.Strings(services)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		return keys[i].level < keys[j].level
+	})
 
 	var req lokiRequest
-	for _, service := range services {
-		entries := bucketedEntries[service]
+	for _, key := range keys {
+		entries := bucketedEntries[key]
 		stream := lokiStream{
-			Labels:  c.labels[service],
+			Labels:  c.streamLabels(key),
 			Entries: entries,
 		}
 		req.Streams = append(req.Streams, stream)
@@ -199,6 +193,34 @@ func (c *Client) buildRequest() lokiRequest {
 	return req
 }
 
+// streamLabels returns the encoded labels for a stream, adding a "level"
+// label for logs with a level.
+func (c *Client) streamLabels(key streamKey) json.RawMessage {
+	labels := c.labels[key.service]
+	if key.level == "" {
+		return labels
+	}
+	var m map[string]string
+	if labels != nil {
+		// Can't fail, as the labels were encoded from a map[string]string
+		_ = json.Unmarshal(labels, &m)
+	}
+	if m == nil {
+		m = map[string]string{}
+	}
+	m["level"] = key.level
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		logger.Panicf("Loki client for %q: cannot marshal labels: %v", c.options.TargetName, err)
+	}
+	return encoded
+}
+
+type streamKey struct {
+	service string
+	level   string
+}
+
 type lokiRequest struct {
 	Streams []lokiStream `json:"streams"`
 }
@@ -213,57 +235,5 @@ type lokiEntry [2]string
 type entryWithService struct {
 	entry   lokiEntry
 	service string
-}
-
-// handleServerResponse determines what to do based on the response from the
-// Loki server. 4xx and 5xx responses indicate errors, so in this case, we will
-// bubble up the error to the caller.
-func (c *Client) handleServerResponse(resp *http.Response) error {
-	defer func() {
-		// Drain request body to allow connection reuse
-		// see https://pkg.go.dev/net/http#Response.Body
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
-		_ = resp.Body.Close()
-	}()
-
-	code := resp.StatusCode
-	switch {
-	case code == http.StatusOK || code == http.StatusNoContent:
-		// Success - safe to drop logs
-		c.resetBuffer()
-		return nil
-
-	case code == http.StatusTooManyRequests:
-		// For 429, don't drop logs - just retry later
-		return errFromResponse(resp)
-
-	case 400 <= code && code < 500:
-		// Other 4xx codes indicate a client problem, so drop the logs (retrying won't help)
-		logger.Noticef("Target %q: request failed with status %d, dropping %d logs",
-			c.options.TargetName, code, len(c.entries))
-		c.resetBuffer()
-		return errFromResponse(resp)
-
-	case 500 <= code && code < 600:
-		// 5xx indicates a problem with the server, so don't drop logs (retry later)
-		return errFromResponse(resp)
-
-	default:
-		// Unexpected response - don't drop logs to be safe
-		return fmt.Errorf("unexpected response from server: %v", resp.Status)
-	}
-}
-
-// errFromResponse generates an error from a failed *http.Response.
-// Note: this function reads the response body.
-func errFromResponse(resp *http.Response) error {
-	// Read response body to get more context
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
-	if err == nil {
-		logger.Debugf("HTTP %d error, response %q", resp.StatusCode, body)
-	} else {
-		logger.Debugf("HTTP %d error, but cannot read response: %v", resp.StatusCode, err)
-	}
-
-	return fmt.Errorf("server returned HTTP %v", resp.Status)
+	level   string
 }
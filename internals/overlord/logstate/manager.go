@@ -15,9 +15,12 @@
 package logstate
 
 import (
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/metrics"
 	"github.com/canonical/pebble/internals/plan"
 	"github.com/canonical/pebble/internals/servicelog"
 )
@@ -28,15 +31,27 @@ type LogManager struct {
 	buffers   map[string]*servicelog.RingBuffer
 	plan      *plan.Plan
 
+	// Pebble's own log sources, which are forwarded like services.
+	sources map[string]*logSource
+
 	newGatherer func(*plan.LogTarget) (*logGatherer, error)
 }
 
-func NewLogManager() *LogManager {
-	return &LogManager{
-		gatherers:   map[string]*logGatherer{},
-		buffers:     map[string]*servicelog.RingBuffer{},
-		newGatherer: newLogGatherer,
+// NewLogManager creates a log manager. Log targets with a spool queue logs
+// in a subdirectory of spoolDir named after the target.
+func NewLogManager(spoolDir string) *LogManager {
+	m := &LogManager{
+		gatherers: map[string]*logGatherer{},
+		buffers:   map[string]*servicelog.RingBuffer{},
+		sources:   map[string]*logSource{},
+		newGatherer: func(target *plan.LogTarget) (*logGatherer, error) {
+			return newLogGatherer(target, spoolDir)
+		},
+	}
+	for _, name := range plan.LogSources {
+		m.sources[name] = newLogSource(name)
 	}
+	return m
 }
 
 // PlanChanged is called by the service manager when the plan changes.
@@ -49,6 +64,12 @@ func (m *LogManager) PlanChanged(pl *plan.Plan) {
 	// Old gatherers will be moved over or deleted.
 	newGatherers := make(map[string]*logGatherer, len(pl.LogTargets))
 
+	// Gatherers find the buffers of Pebble's log sources with the services'.
+	buffers := maps.Clone(m.buffers)
+	for name, source := range m.sources {
+		buffers[name] = source.buffer
+	}
+
 	for _, target := range pl.LogTargets {
 		gatherer := m.gatherers[target.Name]
 		if gatherer == nil {
@@ -68,7 +89,7 @@ func (m *LogManager) PlanChanged(pl *plan.Plan) {
 		}
 
 		// Update iterators for gatherer
-		gatherer.PlanChanged(pl, m.buffers)
+		gatherer.PlanChanged(pl, buffers)
 	}
 
 	// Old gatherers for now-removed targets need to be shut down.
@@ -109,6 +130,46 @@ func (m *LogManager) ServiceStarted(service *plan.Service, buffer *servicelog.Ri
 	}
 }
 
+// WriteMetrics writes the metrics for the spool of each log target that has
+// one to the provided writer.
+func (m *LogManager) WriteMetrics(writer metrics.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for name, gatherer := range m.gatherers {
+		if gatherer.spool != nil {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		gatherer := m.gatherers[name]
+		err := writer.Write(metrics.Metric{
+			Name:       "pebble_log_target_queue_depth",
+			Type:       metrics.TypeGaugeInt,
+			ValueInt64: gatherer.queueDepth.Load(),
+			Comment:    "Number of logs queued on disk to send to the log target",
+			Labels:     []metrics.Label{metrics.NewLabel("target", name)},
+		})
+		if err != nil {
+			return err
+		}
+		err = writer.Write(metrics.Metric{
+			Name:       "pebble_log_target_dropped_count",
+			Type:       metrics.TypeCounterInt,
+			ValueInt64: gatherer.droppedCount.Load(),
+			Comment:    "Number of queued logs dropped because the queue was full or the log target rejected them",
+			Labels:     []metrics.Label{metrics.NewLabel("target", name)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Ensure implements overlord.StateManager.
 func (m *LogManager) Ensure() error {
 	return nil
@@ -119,6 +180,12 @@ func (m *LogManager) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// Close the log sources' buffers, so that the gatherers can stop
+	// forwarding their logs.
+	for _, source := range m.sources {
+		_ = source.buffer.Close()
+	}
+
 	wg := sync.WaitGroup{}
 	for _, gatherer := range m.gatherers {
 		wg.Add(1)
@@ -44,7 +44,7 @@ func (*managerSuite) TestPlanChange(c *C) {
 			return &testClient{}, nil
 		},
 	}
-	m := NewLogManager()
+	m := NewLogManager("")
 	m.newGatherer = func(t *plan.LogTarget) (*logGatherer, error) {
 		return newLogGathererInternal(t, &gathererOptions)
 	}
@@ -137,7 +137,7 @@ func (s *managerSuite) TestTimelyShutdown(c *C) {
 		},
 	}
 
-	m := NewLogManager()
+	m := NewLogManager("")
 	m.newGatherer = func(t *plan.LogTarget) (*logGatherer, error) {
 		return newLogGathererInternal(t, &gathererOptions)
 	}
@@ -219,7 +219,7 @@ func (s *managerSuite) TestLabels(c *C) {
 		notifySetLabels: make(chan struct{}, 2),
 	}
 
-	m := NewLogManager()
+	m := NewLogManager("")
 	m.newGatherer = func(t *plan.LogTarget) (*logGatherer, error) {
 		return newLogGathererInternal(t, &logGathererOptions{
 			newClient: func(_ *plan.LogTarget) (logClient, error) { return fakeClient, nil },
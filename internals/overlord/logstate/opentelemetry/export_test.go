@@ -16,8 +16,8 @@ package opentelemetry
 
 type EntryWithService = entryWithService
 
-func GetBuffer(c *Client) []EntryWithService {
-	return c.buffer
+func GetEntries(c *Client) []EntryWithService {
+	return c.buffer.Entries()
 }
 
 func GetMessage(e EntryWithService) string {
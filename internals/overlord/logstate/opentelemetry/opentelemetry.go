@@ -19,14 +19,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/logstate/clientutil"
 	"github.com/canonical/pebble/internals/servicelog"
 )
 
@@ -117,10 +116,7 @@ type Client struct {
 	options    *ClientOptions
 	httpClient *http.Client
 
-	// To store log entries, keep a buffer of size 2*MaxRequestEntries with a
-	// sliding window "entries" of size MaxRequestEntries.
-	buffer  []entryWithService
-	entries []entryWithService
+	buffer *clientutil.Buffer[entryWithService]
 
 	// Store the custom labels for each service (resource attributes in OTEL).
 	resourceAttributes map[string][]keyValue
@@ -131,12 +127,10 @@ func NewClient(options *ClientOptions) *Client {
 	fillDefaultOptions(&opts)
 	c := &Client{
 		options:            &opts,
-		httpClient:         &http.Client{Timeout: opts.RequestTimeout},
-		buffer:             make([]entryWithService, 2*opts.MaxRequestEntries),
+		httpClient:         &http.Client{Timeout: opts.RequestTimeout, Transport: opts.Transport},
+		buffer:             clientutil.NewBuffer[entryWithService](opts.MaxRequestEntries),
 		resourceAttributes: make(map[string][]keyValue),
 	}
-	// c.entries should be backed by the same array as c.buffer.
-	c.entries = c.buffer[:0]
 	return c
 }
 
@@ -148,6 +142,10 @@ type ClientOptions struct {
 	ScopeName         string
 	TargetName        string
 	Location          string
+
+	// Transport, if set, is used to make the HTTP requests, for example to
+	// add authentication or use custom TLS settings.
+	Transport http.RoundTripper
 }
 
 func fillDefaultOptions(options *ClientOptions) {
@@ -194,27 +192,7 @@ func (c *Client) SetLabels(serviceName string, attributes map[string]string) {
 }
 
 func (c *Client) Add(entry servicelog.Entry) error {
-	if len(c.entries) >= c.options.MaxRequestEntries {
-		// "entries" is full - remove the first element to make room.
-		// Zero the removed element to allow garbage collection.
-		c.entries[0] = entryWithService{}
-		c.entries = c.entries[1:]
-	}
-
-	if len(c.entries) >= cap(c.entries) {
-		// Copy all the elements to the start of the buffer.
-		copy(c.buffer, c.entries)
-
-		// Reset the view into the buffer.
-		c.entries = c.buffer[:len(c.entries):len(c.buffer)]
-
-		// Zero removed elements to allow garbage collection.
-		for i := len(c.entries); i < len(c.buffer); i++ {
-			c.buffer[i] = entryWithService{}
-		}
-	}
-
-	c.entries = append(c.entries, entryWithService{
+	c.buffer.Add(entryWithService{
 		entry:   encodeEntry(entry),
 		service: entry.Service,
 	})
@@ -224,10 +202,42 @@ func (c *Client) Add(entry servicelog.Entry) error {
 func encodeEntry(entry servicelog.Entry) logRecord {
 	message := strings.TrimSuffix(entry.Message, "\n")
 
-	return logRecord{
-		TimeUnixNano: strconv.FormatInt(entry.Time.UnixNano(), 10),
-		Body:         anyValue{StringValue: &message},
+	record := logRecord{
+		TimeUnixNano:   strconv.FormatInt(entry.Time.UnixNano(), 10),
+		SeverityNumber: severityNumbers[entry.Level],
+		SeverityText:   entry.Level,
+		Body:           anyValue{StringValue: &message},
+	}
+	if len(entry.Attributes) > 0 {
+		// Sort attributes to ensure deterministic order.
+		keys := make([]string, 0, len(entry.Attributes))
+		for k := range entry.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		record.Attributes = make([]keyValue, 0, len(keys))
+		for _, k := range keys {
+			v := entry.Attributes[k]
+			record.Attributes = append(record.Attributes, keyValue{
+				Key:   k,
+				Value: anyValue{StringValue: &v},
+			})
+		}
 	}
+	return record
+}
+
+// severityNumbers maps log levels to the first severity number of the
+// corresponding range in the OpenTelemetry Log Data Model. Unknown levels
+// map to 0 (unspecified).
+var severityNumbers = map[string]int{
+	servicelog.LevelTrace: 1,
+	servicelog.LevelDebug: 5,
+	servicelog.LevelInfo:  9,
+	servicelog.LevelWarn:  13,
+	servicelog.LevelError: 17,
+	servicelog.LevelFatal: 21,
 }
 
 type payload struct {
@@ -236,13 +246,13 @@ type payload struct {
 
 // Flush sends the buffered logs to the OpenTelemetry collector.
 func (c *Client) Flush(ctx context.Context) error {
-	if len(c.entries) == 0 {
+	if c.buffer.Len() == 0 {
 		return nil // no-op
 	}
 
 	// Group entries by service.
 	serviceBatches := make(map[string][]logRecord)
-	for _, otelEntryWithService := range c.entries {
+	for _, otelEntryWithService := range c.buffer.Entries() {
 		serviceName := otelEntryWithService.service
 		logRecord := otelEntryWithService.entry
 		serviceBatches[serviceName] = append(serviceBatches[serviceName], logRecord)
@@ -299,72 +309,19 @@ func (c *Client) sendBatch(ctx context.Context, payload payload) error {
 		return fmt.Errorf("cannot send logs: %v", err)
 	}
 
-	return c.handleServerResponse(resp)
+	return clientutil.HandleResponse(resp, c.options.TargetName, c.buffer)
 }
 
-// resetBuffer drops all buffered logs (in the case of a successful send, or an unrecoverable error).
-func (c *Client) resetBuffer() {
-	// Zero removed elements to allow garbage collection.
-	for i := 0; i < len(c.entries); i++ {
-		c.entries[i] = entryWithService{}
-	}
-	c.entries = c.buffer[:0]
+// Discard drops any logs still buffered after a failed Flush, returning how
+// many were dropped. It's used when the caller keeps its own copy of the
+// logs to retry later.
+func (c *Client) Discard() int {
+	n := c.buffer.Len()
+	c.buffer.Reset()
+	return n
 }
 
 type entryWithService struct {
 	entry   logRecord
 	service string
 }
-
-// handleServerResponse determines what to do based on the response from the
-// OpenTelemetry collector. 4xx and 5xx responses indicate errors, so in this case, we will
-// bubble up the error to the caller.
-func (c *Client) handleServerResponse(resp *http.Response) error {
-	defer func() {
-		// Drain request body to allow connection reuse.
-		// See https://pkg.go.dev/net/http#Response.Body
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
-		_ = resp.Body.Close()
-	}()
-
-	code := resp.StatusCode
-	switch {
-	case code == http.StatusOK || code == http.StatusNoContent:
-		// Success - safe to drop logs.
-		c.resetBuffer()
-		return nil
-
-	case code == http.StatusTooManyRequests:
-		// For 429, don't drop logs - just retry later.
-		return errFromResponse(resp)
-
-	case 400 <= code && code < 500:
-		// Other 4xx codes indicate a client problem, so drop the logs (retrying won't help).
-		logger.Noticef("Target %q: request failed with status %d, dropping %d logs",
-			c.options.TargetName, code, len(c.entries))
-		c.resetBuffer()
-		return errFromResponse(resp)
-
-	case 500 <= code && code < 600:
-		// 5xx indicates a problem with the server, so don't drop logs (retry later).
-		return errFromResponse(resp)
-
-	default:
-		// Unexpected response - don't drop logs to be safe.
-		return fmt.Errorf("unexpected response from server: %v", resp.Status)
-	}
-}
-
-// errFromResponse generates an error from a failed *http.Response.
-// Note: this function reads the response body.
-func errFromResponse(resp *http.Response) error {
-	// Read response body to get more context
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
-	if err == nil {
-		logger.Debugf("HTTP %d error, response %q", resp.StatusCode, body)
-	} else {
-		logger.Debugf("HTTP %d error, but cannot read response: %v", resp.StatusCode, err)
-	}
-
-	return fmt.Errorf("server returned HTTP %v", resp.Status)
-}
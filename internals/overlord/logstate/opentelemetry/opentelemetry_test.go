@@ -253,42 +253,30 @@ func (*suite) TestBufferFull(c *C) {
 		c.Assert(err, IsNil)
 	}
 
-	// Check that the client's buffer is as expected
-	buffer := opentelemetry.GetBuffer(client)
-	checkBuffer := func(expected []any) {
-		if len(buffer) != len(expected) {
-			c.Fatalf("buffer length is %v, expected %v", len(buffer), len(expected))
-		}
-
-		for i := range expected {
-			// 'nil' means c.buffer[i] should be zero
-			if expected[i] == nil {
-				c.Assert(buffer[i], DeepEquals, opentelemetry.EntryWithService{},
-					Commentf("buffer[%d] should be zero, obtained %v", i, buffer[i]))
-				continue
-			}
-
-			// Otherwise, check buffer message matches string
-			msg := expected[i].(string)
-			c.Assert(opentelemetry.GetMessage(buffer[i]), Equals, msg)
+	// Check that the client keeps the newest entries
+	checkEntries := func(expected ...string) {
+		entries := opentelemetry.GetEntries(client)
+		c.Assert(entries, HasLen, len(expected))
+		for i, msg := range expected {
+			c.Assert(opentelemetry.GetMessage(entries[i]), Equals, msg)
 		}
 	}
 
-	checkBuffer([]any{nil, nil, nil, nil, nil, nil})
+	checkEntries()
 	addEntry("1")
-	checkBuffer([]any{"1", nil, nil, nil, nil, nil})
+	checkEntries("1")
 	addEntry("2")
-	checkBuffer([]any{"1", "2", nil, nil, nil, nil})
+	checkEntries("1", "2")
 	addEntry("3")
-	checkBuffer([]any{"1", "2", "3", nil, nil, nil})
+	checkEntries("1", "2", "3")
 	addEntry("4")
-	checkBuffer([]any{nil, "2", "3", "4", nil, nil})
+	checkEntries("2", "3", "4")
 	addEntry("5")
-	checkBuffer([]any{nil, nil, "3", "4", "5", nil})
+	checkEntries("3", "4", "5")
 	addEntry("6")
-	checkBuffer([]any{nil, nil, nil, "4", "5", "6"})
+	checkEntries("4", "5", "6")
 	addEntry("7")
-	checkBuffer([]any{"5", "6", "7", nil, nil, nil})
+	checkEntries("5", "6", "7")
 }
 
 func (*suite) TestLabels(c *C) {
@@ -347,6 +335,56 @@ func (*suite) TestLabels(c *C) {
 	}
 }
 
+func (*suite) TestStructured(c *C) {
+	var reqBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		reqBody, err = io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+	}))
+	defer server.Close()
+
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		Location:  server.URL,
+		ScopeName: "pebble",
+	})
+	client.SetLabels("svc1", map[string]string{})
+
+	err := client.Add(servicelog.Entry{
+		Service:    "svc1",
+		Time:       time.Date(2023, 10, 3, 4, 20, 33, 0, time.UTC),
+		Message:    "request failed\n",
+		Level:      "warn",
+		Attributes: map[string]string{"status": "500", "path": "/foo"},
+	})
+	c.Assert(err, IsNil)
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+
+	expected := compactJSON(`
+{"resourceLogs": [{
+	"resource": {
+		"attributes": [
+			{"key": "service.name", "value": {"stringValue": "svc1"}}
+		]
+	},
+	"scopeLogs": [{
+		"scope": {"name": "pebble"},
+		"logRecords": [{
+			"timeUnixNano": "1696306833000000000",
+			"severityNumber": 13,
+			"severityText": "warn",
+			"body": {"stringValue": "request failed"},
+			"attributes": [
+				{"key": "path", "value": {"stringValue": "/foo"}},
+				{"key": "status", "value": {"stringValue": "500"}}
+			]
+		}]
+	}]
+}]}`)
+	c.Assert(string(reqBody), Equals, string(expected))
+}
+
 // Strips all extraneous whitespace from JSON
 func compactJSON(s string) []byte {
 	var buf bytes.Buffer
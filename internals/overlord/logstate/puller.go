@@ -28,6 +28,7 @@ import (
 type logPuller struct {
 	iterator servicelog.Iterator
 	entryCh  chan<- servicelog.Entry
+	format   servicelog.Format
 
 	tomb tomb.Tomb
 }
@@ -48,7 +49,7 @@ func (p *logPuller) loop() error {
 			}
 
 			select {
-			case p.entryCh <- parser.Entry():
+			case p.entryCh <- servicelog.ParseStructured(parser.Entry(), p.format):
 			case <-p.tomb.Dying():
 				return nil
 			}
@@ -89,8 +90,9 @@ func newPullerGroup(targetName string) *pullerGroup {
 }
 
 // Add adds a new puller to the group. This puller will read from the given
-// buffer, and send parsed logs on the provided channel.
-func (pg *pullerGroup) Add(serviceName string, buffer *servicelog.RingBuffer, entryCh chan<- servicelog.Entry) {
+// buffer, and send logs parsed using the service's log format on the
+// provided channel.
+func (pg *pullerGroup) Add(serviceName string, format servicelog.Format, buffer *servicelog.RingBuffer, entryCh chan<- servicelog.Entry) {
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
 
@@ -101,6 +103,7 @@ func (pg *pullerGroup) Add(serviceName string, buffer *servicelog.RingBuffer, en
 	lp := &logPuller{
 		iterator: buffer.TailIterator(),
 		entryCh:  entryCh,
+		format:   format,
 	}
 	lp.tomb.Go(lp.loop)
 	pg.tomb.Go(lp.tomb.Wait)
@@ -161,7 +164,7 @@ func (pg *pullerGroup) Done() <-chan struct{} {
 	return pg.tomb.Dead()
 }
 
-// contains is used for testing.
+// contains returns true if there is a puller for the named service.
 func (pg *pullerGroup) contains(serviceName string) bool {
 	pg.mu.RLock()
 	defer pg.mu.RUnlock()
@@ -0,0 +1,130 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/state"
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+// Size of the buffer for each of Pebble's own log sources, the same as for
+// a service.
+const sourceBufferSize = 100 * 1024
+
+// logSource is one of Pebble's own log sources (see plan.LogSources), which
+// log targets can select like a service. Its logs are written to a ring
+// buffer in the same format as service logs, so they can be forwarded the
+// same way.
+type logSource struct {
+	buffer *servicelog.RingBuffer
+	writer io.Writer
+}
+
+func newLogSource(name string) *logSource {
+	buffer := servicelog.NewRingBuffer(sourceBufferSize)
+	return &logSource{
+		buffer: buffer,
+		writer: servicelog.NewFormatWriter(buffer, name),
+	}
+}
+
+// write writes a message to the source's buffer, adding a trailing newline
+// if needed. Errors are ignored, as they can't be logged.
+func (s *logSource) write(msg string) {
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	_, _ = io.WriteString(s.writer, msg)
+}
+
+// sourceFormat returns how the messages of the given log source are
+// structured.
+func sourceFormat(source string) servicelog.Format {
+	if source == plan.ChangesLogSource {
+		return servicelog.FormatLogfmt
+	}
+	return servicelog.FormatPlain
+}
+
+// Forwarder returns a logger.Forwarder which writes Pebble's own logs and
+// security events to the "pebble:daemon" and "pebble:security" log sources.
+func (m *LogManager) Forwarder() logger.Forwarder {
+	return &sourceForwarder{
+		daemon:   m.sources[plan.DaemonLogSource],
+		security: m.sources[plan.SecurityLogSource],
+	}
+}
+
+type sourceForwarder struct {
+	daemon   *logSource
+	security *logSource
+}
+
+func (f *sourceForwarder) Notice(msg string) {
+	f.daemon.write(msg)
+}
+
+func (f *sourceForwarder) Security(event string) {
+	f.security.write(event)
+}
+
+// ChangeStatusChanged writes a change's status transition to the
+// "pebble:changes" log source. It's called with the state locked.
+func (m *LogManager) ChangeStatusChanged(chg *state.Change, old, new state.Status) {
+	m.sources[plan.ChangesLogSource].write(formatStatusChange(
+		"Change status changed", chg.ID(), "", chg.Kind(), chg.Summary(), old, new))
+}
+
+// TaskStatusChanged writes a task's status transition to the
+// "pebble:changes" log source. It's called with the state locked.
+func (m *LogManager) TaskStatusChanged(task *state.Task, old, new state.Status) {
+	changeID := ""
+	if chg := task.Change(); chg != nil {
+		changeID = chg.ID()
+	}
+	m.sources[plan.ChangesLogSource].write(formatStatusChange(
+		"Task status changed", task.ID(), changeID, task.Kind(), task.Summary(), old, new))
+}
+
+// formatStatusChange formats a status transition in logfmt, with the level
+// set to "error" if the new status is Error.
+func formatStatusChange(msg, id, changeID, kind, summary string, old, new state.Status) string {
+	// New tasks and changes have the default status, which means Do.
+	if old == state.DefaultStatus {
+		old = state.DoStatus
+	}
+	level := servicelog.LevelInfo
+	if new == state.ErrorStatus {
+		level = servicelog.LevelError
+	}
+	attrs := map[string]string{
+		"id":      id,
+		"kind":    kind,
+		"summary": summary,
+		"old":     old.String(),
+		"new":     new.String(),
+	}
+	if changeID != "" {
+		attrs["change"] = changeID
+	}
+	return fmt.Sprintf("level=%s msg=%s %s", level, strconv.Quote(msg), servicelog.FormatAttributes(attrs))
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/state"
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+type sourcesSuite struct{}
+
+var _ = Suite(&sourcesSuite{})
+
+func (*sourcesSuite) TestForwardSources(c *C) {
+	recv := make(chan []servicelog.Entry)
+	gathererOptions := logGathererOptions{
+		bufferTimeout: 1 * time.Millisecond,
+		newClient: func(target *plan.LogTarget) (logClient, error) {
+			return &testClient{sendCh: recv}, nil
+		},
+	}
+	m := NewLogManager("")
+	m.newGatherer = func(t *plan.LogTarget) (*logGatherer, error) {
+		return newLogGathererInternal(t, &gathererOptions)
+	}
+
+	// "all" doesn't select Pebble's log sources.
+	m.PlanChanged(&plan.Plan{
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {Name: "tgt1", Services: []string{"all", "pebble:daemon", "pebble:changes"}},
+		},
+	})
+	gatherer := m.gatherers["tgt1"]
+	c.Check(gatherer.pullers.contains("pebble:daemon"), Equals, true)
+	c.Check(gatherer.pullers.contains("pebble:security"), Equals, false)
+	c.Check(gatherer.pullers.contains("pebble:changes"), Equals, true)
+
+	forwarder := m.Forwarder()
+	forwarder.Notice("Started daemon")
+	forwarder.Security(`{"type":"security","level":"WARN"}` + "\n")
+	entries := receiveEntries(c, recv, 1)
+	c.Check(entries[0].Service, Equals, "pebble:daemon")
+	c.Check(entries[0].Message, Equals, "Started daemon\n")
+
+	st := state.New(nil)
+	st.Lock()
+	st.AddChangeStatusChangedHandler(m.ChangeStatusChanged)
+	st.AddTaskStatusChangedHandler(m.TaskStatusChanged)
+	chg := st.NewChange("start", `Start service "svc1"`)
+	task := st.NewTask("start", `Start service "svc1"`)
+	chg.AddTask(task)
+	task.SetStatus(state.ErrorStatus)
+	st.Unlock()
+
+	// The change's status is updated before the handlers for the task.
+	entries = receiveEntries(c, recv, 2)
+	c.Check(entries[0].Service, Equals, "pebble:changes")
+	c.Check(entries[0].Message, Equals, "Change status changed\n")
+	c.Check(entries[0].Level, Equals, "error")
+	c.Check(entries[0].Attributes, DeepEquals, map[string]string{
+		"id":      chg.ID(),
+		"kind":    "start",
+		"summary": `Start service "svc1"`,
+		"old":     "Do",
+		"new":     "Error",
+	})
+	c.Check(entries[1].Service, Equals, "pebble:changes")
+	c.Check(entries[1].Message, Equals, "Task status changed\n")
+	c.Check(entries[1].Level, Equals, "error")
+	c.Check(entries[1].Attributes, DeepEquals, map[string]string{
+		"id":      task.ID(),
+		"change":  chg.ID(),
+		"kind":    "start",
+		"summary": `Start service "svc1"`,
+		"old":     "Do",
+		"new":     "Error",
+	})
+
+	// Pebble's log sources are no longer forwarded once deselected.
+	m.PlanChanged(&plan.Plan{
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {Name: "tgt1", Services: []string{"pebble:daemon", "pebble:security", "-pebble:daemon"}},
+		},
+	})
+	c.Check(gatherer.pullers.contains("pebble:daemon"), Equals, false)
+	c.Check(gatherer.pullers.contains("pebble:security"), Equals, true)
+	c.Check(gatherer.pullers.contains("pebble:changes"), Equals, false)
+
+	// Logs already in the buffer when a source is selected are forwarded.
+	entries = receiveEntries(c, recv, 1)
+	c.Check(entries[0].Service, Equals, "pebble:security")
+	c.Check(entries[0].Message, Equals, `{"type":"security","level":"WARN"}`+"\n")
+
+	m.Stop()
+}
+
+func (*sourcesSuite) TestFailureNoticesNotForwarded(c *C) {
+	logs, restore := logger.MockLogger("")
+	defer restore()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	m := NewLogManager("")
+	m.newGatherer = func(t *plan.LogTarget) (*logGatherer, error) {
+		return newLogGathererInternal(t, &logGathererOptions{bufferTimeout: 1 * time.Millisecond})
+	}
+	defer logger.SetForwarder(logger.SetForwarder(m.Forwarder()))
+	m.PlanChanged(&plan.Plan{
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {Name: "tgt1", Type: plan.LokiTarget, Location: server.URL, Services: []string{"pebble:daemon"}},
+		},
+	})
+
+	// The target's failure to send this is logged, but not forwarded to
+	// the target (which would fail to send that in turn).
+	logger.Noticef("Started daemon")
+	for i := 0; !strings.Contains(logs.String(), "request failed with status 401"); i++ {
+		if i >= 100 {
+			c.Fatalf("timed out waiting for failure notice")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	c.Check(requests.Load(), Equals, int32(1))
+	c.Check(logs.String(), Matches, `(?s).*Cannot flush logs to target "tgt1".*`)
+
+	m.Stop()
+}
+
+// receiveEntries waits for n entries to be flushed by the test client.
+func receiveEntries(c *C, recv chan []servicelog.Entry, n int) []servicelog.Entry {
+	var entries []servicelog.Entry
+	for len(entries) < n {
+		select {
+		case received := <-recv:
+			entries = append(entries, received...)
+		case <-time.After(1 * time.Second):
+			c.Fatalf("timed out waiting for %d logs, got %d", n, len(entries))
+		}
+	}
+	c.Assert(entries, HasLen, n)
+	return entries
+}
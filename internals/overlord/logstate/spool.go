@@ -0,0 +1,376 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canonical/pebble/internals/osutil"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+const (
+	spoolSegmentSuffix = ".spool"
+	spoolHeadFile      = "head"
+
+	// Number of segment files the spool is split into when full, which is
+	// the granularity at which the oldest logs are dropped.
+	spoolSegments = 8
+)
+
+// spool is an on-disk queue of log entries for a log target, used to keep
+// logs while the target is unreachable. Entries are appended as JSON lines
+// to numbered segment files, and removed from the head once sent. The
+// position of the head is saved, so that queued logs are replayed in order
+// after a restart.
+type spool struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+
+	// Segments on disk, oldest first. The last one is open for writing.
+	segments []*spoolSegment
+	writer   *os.File
+
+	// Byte offset and index of the first queued entry in segments[0].
+	headOffset int64
+	headIndex  int
+
+	// Total size in bytes and number of queued entries.
+	size  int64
+	count int
+
+	// Sizes of the entries returned by the last Read, used by Remove.
+	readSizes []int64
+}
+
+type spoolSegment struct {
+	seq   uint64
+	size  int64
+	count int
+}
+
+// spoolEntry is the JSON encoding of a queued log entry.
+type spoolEntry struct {
+	Time       time.Time         `json:"time"`
+	Service    string            `json:"service"`
+	Message    string            `json:"message"`
+	Level      string            `json:"level,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// openSpool opens the spool in the given directory, creating it if needed,
+// and loads any entries queued before a restart.
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create spool directory: %w", err)
+	}
+	s := &spool{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: max(maxSize/spoolSegments, 1),
+	}
+	err = s.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.segments) == 0 {
+		err = os.Remove(filepath.Join(dir, spoolHeadFile))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot remove spool head: %w", err)
+		}
+		err = s.newSegment(1)
+	} else {
+		last := s.segments[len(s.segments)-1]
+		s.writer, err = os.OpenFile(s.segmentPath(last.seq), os.O_WRONLY|os.O_APPEND, 0o600)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open spool segment: %w", err)
+	}
+	return s, nil
+}
+
+// load reads the segments and head position from disk.
+func (s *spool) load() error {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolSegmentSuffix))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), spoolSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segment, err := s.loadSegment(seq)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, segment)
+	}
+	slices.SortFunc(s.segments, func(a, b *spoolSegment) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	if len(s.segments) > 0 {
+		data, err := os.ReadFile(filepath.Join(s.dir, spoolHeadFile))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot read spool head: %w", err)
+		}
+		var seq uint64
+		var offset int64
+		var index int
+		_, err = fmt.Sscanf(string(data), "%d %d %d", &seq, &offset, &index)
+		if err == nil && seq == s.segments[0].seq && offset <= s.segments[0].size && index <= s.segments[0].count {
+			s.headOffset = offset
+			s.headIndex = index
+		}
+	}
+	for i, segment := range s.segments {
+		s.size += segment.size
+		s.count += segment.count
+		if i == 0 {
+			s.size -= s.headOffset
+			s.count -= s.headIndex
+		}
+	}
+	return nil
+}
+
+// loadSegment counts the entries in a segment, truncating a partially
+// written last line (if Pebble was stopped in the middle of a write).
+func (s *spool) loadSegment(seq uint64) (*spoolSegment, error) {
+	path := s.segmentPath(seq)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read spool segment: %w", err)
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		err = os.Truncate(path, int64(complete))
+		if err != nil {
+			return nil, fmt.Errorf("cannot truncate spool segment: %w", err)
+		}
+	}
+	return &spoolSegment{
+		seq:   seq,
+		size:  int64(complete),
+		count: bytes.Count(data[:complete], []byte{'\n'}),
+	}, nil
+}
+
+func (s *spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016d%s", seq, spoolSegmentSuffix))
+}
+
+// newSegment creates a new segment for writing.
+func (s *spool) newSegment(seq uint64) error {
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if s.writer != nil {
+		_ = s.writer.Close()
+	}
+	s.writer = f
+	s.segments = append(s.segments, &spoolSegment{seq: seq})
+	return nil
+}
+
+// Len returns the number of queued entries.
+func (s *spool) Len() int {
+	return s.count
+}
+
+// Append adds an entry to the end of the queue. If the queue is then larger
+// than its maximum size, the oldest segments are removed, and the number of
+// entries dropped is returned.
+func (s *spool) Append(entry servicelog.Entry) (dropped int, err error) {
+	data, err := json.Marshal(spoolEntry{
+		Time:       entry.Time,
+		Service:    entry.Service,
+		Message:    entry.Message,
+		Level:      entry.Level,
+		Attributes: entry.Attributes,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode log entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	last := s.segments[len(s.segments)-1]
+	if last.size > 0 && last.size+int64(len(data)) > s.segmentSize {
+		err = s.newSegment(last.seq + 1)
+		if err != nil {
+			return 0, fmt.Errorf("cannot create spool segment: %w", err)
+		}
+		last = s.segments[len(s.segments)-1]
+	}
+	_, err = s.writer.Write(data)
+	if err != nil {
+		return 0, fmt.Errorf("cannot write log entry: %w", err)
+	}
+	last.size += int64(len(data))
+	last.count++
+	s.size += int64(len(data))
+	s.count++
+
+	for s.size > s.maxSize && len(s.segments) > 1 {
+		oldest := s.segments[0]
+		dropped += oldest.count - s.headIndex
+		err = s.removeOldest()
+		if err != nil {
+			return dropped, err
+		}
+	}
+	return dropped, nil
+}
+
+// removeOldest removes the oldest segment, moving the head to the start of
+// the next one.
+func (s *spool) removeOldest() error {
+	oldest := s.segments[0]
+	err := os.Remove(s.segmentPath(oldest.seq))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove spool segment: %w", err)
+	}
+	s.size -= oldest.size - s.headOffset
+	s.count -= oldest.count - s.headIndex
+	s.segments = s.segments[1:]
+	s.headOffset = 0
+	s.headIndex = 0
+	return s.saveHead()
+}
+
+// Read returns up to n entries from the head of the queue, without
+// removing them.
+func (s *spool) Read(n int) ([]servicelog.Entry, error) {
+	s.readSizes = s.readSizes[:0]
+	var entries []servicelog.Entry
+	offset := s.headOffset
+	for _, segment := range s.segments {
+		if len(entries) >= n {
+			break
+		}
+		if offset < segment.size {
+			var err error
+			entries, err = s.readSegment(segment, offset, n, entries)
+			if err != nil {
+				return nil, err
+			}
+		}
+		offset = 0
+	}
+	return entries, nil
+}
+
+func (s *spool) readSegment(segment *spoolSegment, offset int64, n int, entries []servicelog.Entry) ([]servicelog.Entry, error) {
+	f, err := os.Open(s.segmentPath(segment.seq))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open spool segment: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read spool segment: %w", err)
+	}
+
+	reader := bufio.NewReader(io.LimitReader(f, segment.size-offset))
+	for len(entries) < n {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read spool segment: %w", err)
+		}
+		var e spoolEntry
+		// Entries that can't be decoded (which shouldn't happen) are
+		// returned with an empty message, so they're still removed.
+		_ = json.Unmarshal(line, &e)
+		entries = append(entries, servicelog.Entry{
+			Time:       e.Time,
+			Service:    e.Service,
+			Message:    e.Message,
+			Level:      e.Level,
+			Attributes: e.Attributes,
+		})
+		s.readSizes = append(s.readSizes, int64(len(line)))
+	}
+	return entries, nil
+}
+
+// Remove removes the first n entries returned by the last Read from the
+// head of the queue.
+func (s *spool) Remove(n int) error {
+	for _, size := range s.readSizes[:n] {
+		err := s.removeSent()
+		if err != nil {
+			return err
+		}
+		s.headOffset += size
+		s.headIndex++
+		s.size -= size
+		s.count--
+	}
+	s.readSizes = s.readSizes[:0]
+	err := s.removeSent()
+	if err != nil {
+		return err
+	}
+	return s.saveHead()
+}
+
+// removeSent removes the segments at the head which have been fully sent,
+// except the one being written.
+func (s *spool) removeSent() error {
+	for len(s.segments) > 1 && s.headOffset >= s.segments[0].size {
+		err := os.Remove(s.segmentPath(s.segments[0].seq))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove spool segment: %w", err)
+		}
+		s.segments = s.segments[1:]
+		s.headOffset = 0
+		s.headIndex = 0
+	}
+	return nil
+}
+
+// saveHead saves the head position, so that sent entries aren't sent again
+// after a restart.
+func (s *spool) saveHead() error {
+	data := fmt.Sprintf("%d %d %d\n", s.segments[0].seq, s.headOffset, s.headIndex)
+	err := osutil.AtomicWriteFile(filepath.Join(s.dir, spoolHeadFile), []byte(data), 0o600, 0)
+	if err != nil {
+		return fmt.Errorf("cannot save spool head: %w", err)
+	}
+	return nil
+}
+
+// Close closes the segment being written.
+func (s *spool) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/logstate/loki"
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+type spoolSuite struct{}
+
+var _ = Suite(&spoolSuite{})
+
+func appendEntries(c *C, s *spool, messages ...string) (dropped int) {
+	for _, message := range messages {
+		n, err := s.Append(servicelog.Entry{
+			Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Service: "svc1",
+			Message: message,
+		})
+		c.Assert(err, IsNil)
+		dropped += n
+	}
+	return dropped
+}
+
+func readMessages(c *C, s *spool, n int) []string {
+	entries, err := s.Read(n)
+	c.Assert(err, IsNil)
+	var messages []string
+	for _, entry := range entries {
+		c.Check(entry.Service, Equals, "svc1")
+		c.Check(entry.Time.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)), Equals, true)
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
+
+func (*spoolSuite) TestAppendReadRemove(c *C) {
+	dir := c.MkDir()
+	s, err := openSpool(dir, 1024)
+	c.Assert(err, IsNil)
+	c.Check(s.Len(), Equals, 0)
+	c.Check(readMessages(c, s, 10), HasLen, 0)
+
+	appendEntries(c, s, "1", "2", "3", "4", "5")
+	c.Check(s.Len(), Equals, 5)
+	c.Check(readMessages(c, s, 3), DeepEquals, []string{"1", "2", "3"})
+
+	// Only remove some of the entries read.
+	err = s.Remove(2)
+	c.Assert(err, IsNil)
+	c.Check(s.Len(), Equals, 3)
+	c.Check(readMessages(c, s, 10), DeepEquals, []string{"3", "4", "5"})
+	err = s.Remove(1)
+	c.Assert(err, IsNil)
+
+	// The head position is kept after reopening.
+	err = s.Close()
+	c.Assert(err, IsNil)
+	s, err = openSpool(dir, 1024)
+	c.Assert(err, IsNil)
+	c.Check(s.Len(), Equals, 2)
+	appendEntries(c, s, "6")
+	c.Check(readMessages(c, s, 10), DeepEquals, []string{"4", "5", "6"})
+	err = s.Remove(3)
+	c.Assert(err, IsNil)
+	c.Check(s.Len(), Equals, 0)
+	c.Check(readMessages(c, s, 10), HasLen, 0)
+	err = s.Close()
+	c.Assert(err, IsNil)
+}
+
+func (*spoolSuite) TestSegments(c *C) {
+	dir := c.MkDir()
+	// Each entry is about 64 bytes, so a segment (1/8 of the size) holds 2.
+	s, err := openSpool(dir, 8*130)
+	c.Assert(err, IsNil)
+	defer s.Close()
+
+	appendEntries(c, s, "1", "2", "3", "4", "5")
+	segments, err := filepath.Glob(filepath.Join(dir, "*.spool"))
+	c.Assert(err, IsNil)
+	c.Check(segments, HasLen, 3)
+
+	// Segments are removed once sent.
+	c.Check(readMessages(c, s, 3), DeepEquals, []string{"1", "2", "3"})
+	err = s.Remove(3)
+	c.Assert(err, IsNil)
+	segments, err = filepath.Glob(filepath.Join(dir, "*.spool"))
+	c.Assert(err, IsNil)
+	c.Check(segments, HasLen, 2)
+	c.Check(readMessages(c, s, 10), DeepEquals, []string{"4", "5"})
+}
+
+func (*spoolSuite) TestFull(c *C) {
+	dir := c.MkDir()
+	s, err := openSpool(dir, 8*130)
+	c.Assert(err, IsNil)
+	defer s.Close()
+
+	var messages []string
+	for i := range 16 {
+		messages = append(messages, fmt.Sprint(i+10))
+	}
+	c.Check(appendEntries(c, s, messages...), Equals, 0)
+	c.Check(s.Len(), Equals, 16)
+
+	// The oldest segment is dropped to make room.
+	c.Check(appendEntries(c, s, "26"), Equals, 2)
+	c.Check(s.Len(), Equals, 15)
+	c.Check(readMessages(c, s, 1), DeepEquals, []string{"12"})
+
+	// Only the entries not yet sent are counted as dropped.
+	err = s.Remove(1)
+	c.Assert(err, IsNil)
+	c.Check(appendEntries(c, s, "27", "28", "29"), Equals, 1)
+	c.Check(readMessages(c, s, 1), DeepEquals, []string{"14"})
+}
+
+func (*spoolSuite) TestPartialWrite(c *C) {
+	dir := c.MkDir()
+	s, err := openSpool(dir, 1024)
+	c.Assert(err, IsNil)
+	appendEntries(c, s, "1", "2")
+	err = s.Close()
+	c.Assert(err, IsNil)
+
+	// Simulate Pebble being stopped in the middle of a write.
+	f, err := os.OpenFile(filepath.Join(dir, "0000000000000001.spool"), os.O_WRONLY|os.O_APPEND, 0)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`{"time":"2026-01-02T03:04:05Z","ser`)
+	c.Assert(err, IsNil)
+	err = f.Close()
+	c.Assert(err, IsNil)
+
+	s, err = openSpool(dir, 1024)
+	c.Assert(err, IsNil)
+	defer s.Close()
+	appendEntries(c, s, "3")
+	c.Check(s.Len(), Equals, 3)
+	c.Check(readMessages(c, s, 10), DeepEquals, []string{"1", "2", "3"})
+}
+
+func (*spoolSuite) TestGathererRetry(c *C) {
+	var mu sync.Mutex
+	status := http.StatusServiceUnavailable
+	var received []string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		for _, match := range regexp.MustCompile(`"log line #(\d+)"`).FindAllSubmatch(body, -1) {
+			received = append(received, string(match[1]))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	target := &plan.LogTarget{
+		Name:     "tgt1",
+		Type:     plan.LokiTarget,
+		Location: server.URL,
+		Services: []string{"all"},
+		Spool:    &plan.LogTargetSpool{BackoffLimit: plan.OptionalDuration{Value: 10 * time.Millisecond, IsSet: true}},
+	}
+	spoolDir := c.MkDir()
+	g, err := newLogGathererInternal(target, &logGathererOptions{
+		bufferTimeout:      1 * time.Millisecond,
+		maxBufferedEntries: 3,
+		spoolDir:           spoolDir,
+		newClient: func(target *plan.LogTarget) (logClient, error) {
+			return loki.NewClient(&loki.ClientOptions{
+				TargetName:        target.Name,
+				Location:          target.Location,
+				MaxRequestEntries: 3,
+			}), nil
+		},
+	})
+	c.Assert(err, IsNil)
+
+	testSvc := newTestService("svc1")
+	g.ServiceStarted(testSvc.config, testSvc.ringBuffer)
+	for i := range 10 {
+		testSvc.writeLog(fmt.Sprintf("log line #%d", i+1))
+	}
+
+	// Wait for a few failed attempts: the logs are all kept in the spool.
+	waitFor(c, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests >= 3 && g.queueDepth.Load() == 10
+	})
+	c.Check(g.droppedCount.Load(), Equals, int64(0))
+
+	// Once the server is back, all the logs are sent in order.
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+	waitFor(c, func() bool {
+		return g.queueDepth.Load() == 0
+	})
+	mu.Lock()
+	c.Check(received, DeepEquals, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"})
+
+	// Logs rejected by the server are dropped.
+	status = http.StatusBadRequest
+	mu.Unlock()
+	testSvc.writeLog("log line #11")
+	waitFor(c, func() bool {
+		return g.droppedCount.Load() == 1
+	})
+	c.Check(g.queueDepth.Load(), Equals, int64(0))
+
+	m := NewLogManager(spoolDir)
+	m.gatherers[target.Name] = g
+	var buf bytes.Buffer
+	err = m.WriteMetrics(metrics.NewOpenTelemetryWriter(&buf))
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, `
+# HELP pebble_log_target_queue_depth Number of logs queued on disk to send to the log target
+# TYPE pebble_log_target_queue_depth gauge
+pebble_log_target_queue_depth{target="tgt1"} 0
+
+# HELP pebble_log_target_dropped_count Number of queued logs dropped because the queue was full or the log target rejected them
+# TYPE pebble_log_target_dropped_count counter
+pebble_log_target_dropped_count{target="tgt1"} 1
+
+`[1:])
+
+	err = testSvc.stop()
+	c.Assert(err, IsNil)
+	g.Stop()
+}
+
+func waitFor(c *C, cond func() bool) {
+	for i := 0; !cond(); i++ {
+		if i >= 500 {
+			c.Fatalf("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -3,6 +3,7 @@ package syslog
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -20,7 +21,7 @@ const (
 	dialTimeout                = 10 * time.Second
 	canonicalPrivEnterpriseNum = 28978
 	initialSendBufferSize      = 4 * 1024
-	priorityAndVersionPrefix   = "<13>1 " // priority 13 = 1*8+5 (facility user, priority notice), version 1
+	priorityAndVersionPrefix   = "<13>1 " // priority 13 = 1*8+5 (facility user, severity notice), version 1
 	maxUDPMessageSize          = 4000     // Maximum size of a UDP syslog message payload
 )
 
@@ -30,6 +31,10 @@ type ClientOptions struct {
 	Hostname          string
 	SDID              string
 	DialTimeout       time.Duration
+
+	// TLSConfig, if set, makes the client connect to a TCP location using
+	// TLS.
+	TLSConfig *tls.Config
 }
 
 type Client struct {
@@ -72,6 +77,9 @@ func NewClient(options *ClientOptions) (*Client, error) {
 	if (u.Scheme != "tcp" && u.Scheme != "udp") || u.Host == "" {
 		return nil, fmt.Errorf(`invalid syslog server location %q, must be in form "tcp://host:port" or "udp://host:port"`, opts.Location)
 	}
+	if opts.TLSConfig != nil && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("cannot use TLS with syslog server location %q", opts.Location)
+	}
 
 	c := &Client{
 		location: u,
@@ -147,6 +155,15 @@ func (c *Client) Add(entry servicelog.Entry) error {
 	}
 
 	entry.Message = strings.TrimSuffix(entry.Message, "\n")
+	if len(entry.Attributes) > 0 {
+		// Send attributes as part of the message in logfmt format, as the
+		// structured data element is used for the labels.
+		if entry.Message != "" {
+			entry.Message += " "
+		}
+		entry.Message += servicelog.FormatAttributes(entry.Attributes)
+		entry.Attributes = nil
+	}
 
 	c.entries = append(c.entries, entry)
 	return nil
@@ -159,8 +176,18 @@ func (c *Client) ensureConnected(ctx context.Context) error {
 		return fmt.Errorf("write to closed SyslogBackend")
 	}
 
-	d := net.Dialer{Timeout: c.options.DialTimeout}
-	conn, err := d.DialContext(ctx, c.location.Scheme, c.location.Host)
+	var conn net.Conn
+	var err error
+	if c.options.TLSConfig != nil {
+		d := tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: c.options.DialTimeout},
+			Config:    c.options.TLSConfig,
+		}
+		conn, err = d.DialContext(ctx, c.location.Scheme, c.location.Host)
+	} else {
+		d := net.Dialer{Timeout: c.options.DialTimeout}
+		conn, err = d.DialContext(ctx, c.location.Scheme, c.location.Host)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot connect to %s: %w", c.location, err)
 	}
@@ -180,6 +207,26 @@ func (c *Client) Close() error {
 	return err
 }
 
+// levelPriorityPrefixes maps log levels to the priority and version prefix,
+// using facility user (1) and the RFC 5424 severity for the level.
+var levelPriorityPrefixes = map[string]string{
+	servicelog.LevelTrace: "<15>1 ", // severity debug
+	servicelog.LevelDebug: "<15>1 ", // severity debug
+	servicelog.LevelInfo:  "<14>1 ", // severity informational
+	servicelog.LevelWarn:  "<12>1 ", // severity warning
+	servicelog.LevelError: "<11>1 ", // severity error
+	servicelog.LevelFatal: "<10>1 ", // severity critical
+}
+
+// priorityPrefix returns the priority and version prefix for an entry's
+// level, defaulting to severity notice for unknown or unset levels.
+func priorityPrefix(level string) string {
+	if prefix, ok := levelPriorityPrefixes[level]; ok {
+		return prefix
+	}
+	return priorityAndVersionPrefix
+}
+
 // encodeOneEntry encodes common parts for UDP and TCP syslog entries into c.sendBuf
 func (c *Client) encodeOneEntry(entry *servicelog.Entry, messageSuffix string, timeBuf []byte) {
 	hostname := c.options.Hostname
@@ -193,7 +240,7 @@ func (c *Client) encodeOneEntry(entry *servicelog.Entry, messageSuffix string, t
 	}
 
 	// Message format as per RFC 5424: <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
-	c.sendBuf.WriteString(priorityAndVersionPrefix)
+	c.sendBuf.WriteString(priorityPrefix(entry.Level))
 	c.sendBuf.Write(timeBuf)
 	c.sendBuf.WriteByte(' ')
 	c.sendBuf.WriteString(hostname)
@@ -228,7 +275,7 @@ func (c *Client) buildSendBufferTCP() {
 		timeBuf = entry.Time.AppendFormat(timeBuf[:0], time.RFC3339Nano)
 
 		// TCP: Octet framing as per RFC 5425: <length> <message>
-		frameLength := len(priorityAndVersionPrefix) + len(timeBuf) + 1 + len(hostname) + 1 +
+		frameLength := len(priorityPrefix(entry.Level)) + len(timeBuf) + 1 + len(hostname) + 1 +
 			len(entry.Service) + 5 + len(structuredData) + 1 + len(entry.Message)
 		lengthBuf = strconv.AppendInt(lengthBuf[:0], int64(frameLength), 10)
 		c.sendBuf.Write(lengthBuf)
@@ -315,6 +362,15 @@ func (c *Client) resetBufferToIndex(last int) {
 	c.entries = c.entries[last:]
 }
 
+// Discard drops any logs still buffered after a failed Flush, returning how
+// many were dropped. It's used when the caller keeps its own copy of the
+// logs to retry later.
+func (c *Client) Discard() int {
+	n := len(c.entries)
+	c.resetBuffer()
+	return n
+}
+
 func (c *Client) resetBuffer() {
 	for i := range c.entries {
 		c.entries[i] = servicelog.Entry{}
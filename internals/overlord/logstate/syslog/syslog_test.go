@@ -17,8 +17,11 @@ package syslog_test
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"net"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -256,6 +259,85 @@ func (*suite) TestTCPFlushEmpty(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (*suite) TestTLSAddAndFlush(c *C) {
+	// Borrow the test certificate of an httptest server (valid for 127.0.0.1).
+	httpServer := httptest.NewTLSServer(nil)
+	serverConfig := httpServer.TLS.Clone()
+	roots := x509.NewCertPool()
+	roots.AddCert(httpServer.Certificate())
+	httpServer.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	c.Assert(err, IsNil)
+	defer listener.Close()
+
+	msgChan := make(chan string, 1)
+	srv := &testSyslogServer{
+		listener: listener,
+		msgChan:  msgChan,
+	}
+	go srv.run()
+
+	client, err := syslog.NewClient(&syslog.ClientOptions{
+		Location:  "tcp://" + listener.Addr().String(),
+		Hostname:  "test-machine",
+		SDID:      "test-sdid",
+		TLSConfig: &tls.Config{RootCAs: roots},
+	})
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	err = client.Add(exampleEntries[0])
+	c.Assert(err, IsNil)
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+
+	select {
+	case msg := <-msgChan:
+		c.Check(msg, Equals, `68 <13>1 2023-12-31T12:00:00Z test-machine svc1 - - - message from svc1`)
+	case <-time.After(2 * time.Second):
+		c.Fatal("timed out waiting for message")
+	}
+}
+
+func (*suite) TestTCPStructured(c *C) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+
+	msgChan := make(chan string, 1)
+	srv := &testSyslogServer{
+		listener: listener,
+		msgChan:  msgChan,
+	}
+	go srv.run()
+
+	client, err := syslog.NewClient(&syslog.ClientOptions{
+		Location: "tcp://" + listener.Addr().String(),
+		Hostname: "test-machine",
+	})
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	err = client.Add(servicelog.Entry{
+		Time:       time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC),
+		Service:    "svc1",
+		Message:    "request failed\n",
+		Level:      "error",
+		Attributes: map[string]string{"status": "500"},
+	})
+	c.Assert(err, IsNil)
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+
+	select {
+	case msg := <-msgChan:
+		c.Check(msg, Equals, `76 <11>1 2023-12-31T12:00:00Z test-machine svc1 - - - request failed status=500`)
+	case <-time.After(2 * time.Second):
+		c.Fatal("timed out waiting for message")
+	}
+}
+
 func (*suite) TestInvalidLocation(c *C) {
 	// Invalid scheme
 	_, err := syslog.NewClient(&syslog.ClientOptions{
@@ -273,6 +355,12 @@ func (*suite) TestInvalidLocation(c *C) {
 		Location: "udp://localhost:514",
 	})
 	c.Assert(err, IsNil)
+	// TLS is only supported over TCP
+	_, err = syslog.NewClient(&syslog.ClientOptions{
+		Location:  "udp://localhost:514",
+		TLSConfig: &tls.Config{},
+	})
+	c.Assert(err, ErrorMatches, `cannot use TLS with syslog server location "udp://localhost:514"`)
 }
 
 type testSyslogServer struct {
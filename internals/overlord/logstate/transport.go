@@ -0,0 +1,80 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/tlsutil"
+)
+
+// newTransport returns the HTTP transport for an HTTP-based log target,
+// which applies the target's TLS options, headers and authentication. It
+// returns nil if the target doesn't need one, so the default is used.
+func newTransport(target *plan.LogTarget) (http.RoundTripper, error) {
+	if target.TLS == nil && len(target.Headers) == 0 && target.BasicAuth == nil && target.BearerTokenFile == "" {
+		return nil, nil
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if target.TLS != nil {
+		config, err := tlsutil.ClientConfig(target.TLS)
+		if err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig = config
+	}
+	return &authTransport{
+		base:            base,
+		headers:         target.Headers,
+		basicAuth:       target.BasicAuth,
+		bearerTokenFile: target.BearerTokenFile,
+	}, nil
+}
+
+// authTransport adds a log target's headers and credentials to each request.
+type authTransport struct {
+	base            http.RoundTripper
+	headers         map[string]string
+	basicAuth       *plan.LogTargetBasicAuth
+	bearerTokenFile string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request, so add to a copy.
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case t.basicAuth != nil:
+		req.SetBasicAuth(t.basicAuth.Username, t.basicAuth.Password)
+	case t.bearerTokenFile != "":
+		// Read the token for each request, so that it can be rotated.
+		token, err := os.ReadFile(t.bearerTokenFile)
+		if err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("cannot read bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	return t.base.RoundTrip(req)
+}
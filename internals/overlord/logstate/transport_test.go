@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logstate
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/plan"
+)
+
+type transportSuite struct{}
+
+var _ = Suite(&transportSuite{})
+
+func (*transportSuite) TestNoTransport(c *C) {
+	transport, err := newTransport(&plan.LogTarget{Name: "tgt1", Type: plan.LokiTarget})
+	c.Assert(err, IsNil)
+	c.Assert(transport, IsNil)
+}
+
+func (*transportSuite) TestHeadersBasicAuth(c *C) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+	}))
+	defer server.Close()
+
+	transport, err := newTransport(&plan.LogTarget{
+		Name:      "tgt1",
+		Headers:   map[string]string{"X-Tenant": "foo"},
+		BasicAuth: &plan.LogTargetBasicAuth{Username: "user", Password: "pass"},
+	})
+	c.Assert(err, IsNil)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Post(server.URL, "application/json", nil)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(request.Header.Get("X-Tenant"), Equals, "foo")
+	username, password, ok := request.BasicAuth()
+	c.Assert(ok, Equals, true)
+	c.Assert(username, Equals, "user")
+	c.Assert(password, Equals, "pass")
+}
+
+func (*transportSuite) TestBearerTokenFile(c *C) {
+	tokenFile := filepath.Join(c.MkDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("token1\n"), 0o600)
+	c.Assert(err, IsNil)
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	transport, err := newTransport(&plan.LogTarget{Name: "tgt1", BearerTokenFile: tokenFile})
+	c.Assert(err, IsNil)
+	client := &http.Client{Transport: transport}
+	post := func() error {
+		resp, err := client.Post(server.URL, "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	c.Assert(post(), IsNil)
+	c.Assert(authorization, Equals, "Bearer token1")
+
+	// The file is read again for each request.
+	err = os.WriteFile(tokenFile, []byte("token2"), 0o600)
+	c.Assert(err, IsNil)
+	c.Assert(post(), IsNil)
+	c.Assert(authorization, Equals, "Bearer token2")
+
+	err = os.Remove(tokenFile)
+	c.Assert(err, IsNil)
+	c.Assert(post(), ErrorMatches, ".*cannot read bearer token: .*")
+}
+
+func (*transportSuite) TestTLS(c *C) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caCert := filepath.Join(c.MkDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err := os.WriteFile(caCert, data, 0o600)
+	c.Assert(err, IsNil)
+
+	// The server's certificate isn't trusted by default.
+	transport, err := newTransport(&plan.LogTarget{
+		Name: "tgt1",
+		TLS:  &plan.TLSOptions{ServerName: "example.com"},
+	})
+	c.Assert(err, IsNil)
+	client := &http.Client{Transport: transport}
+	_, err = client.Get(server.URL)
+	c.Assert(err, ErrorMatches, ".*certificate.*")
+
+	transport, err = newTransport(&plan.LogTarget{
+		Name: "tgt1",
+		TLS:  &plan.TLSOptions{CACert: caCert, ServerName: "example.com"},
+	})
+	c.Assert(err, IsNil)
+	client = &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	_, err = newTransport(&plan.LogTarget{
+		Name: "tgt1",
+		TLS:  &plan.TLSOptions{CACert: filepath.Join(c.MkDir(), "missing.pem")},
+	})
+	c.Assert(err, ErrorMatches, "cannot read CA certificate: .*")
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// metricstate pushes metrics to the targets in the plan's metric-targets
+// section.
+package metricstate
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/plan"
+)
+
+// Source is implemented by the managers whose metrics are pushed, such as
+// the service and check managers. These are the same metrics that are
+// served by the /v1/metrics endpoint.
+type Source interface {
+	WriteMetrics(writer metrics.Writer) error
+}
+
+type MetricManager struct {
+	mu      sync.Mutex
+	sources []Source
+	pushers map[string]*pusher
+}
+
+// NewManager creates a metric manager that pushes the metrics written by
+// the given sources, in order.
+func NewManager(sources ...Source) *MetricManager {
+	return &MetricManager{
+		sources: sources,
+		pushers: make(map[string]*pusher),
+	}
+}
+
+// PlanChanged is called by the plan manager when the plan changes. It starts
+// a pusher for each new target, and restarts the pushers of targets whose
+// configuration has changed.
+func (m *MetricManager) PlanChanged(pl *plan.Plan) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var targets map[string]*MetricTarget
+	if section, ok := pl.Sections[MetricTargetsField].(*MetricTargetsSection); ok {
+		targets = section.Entries
+	}
+
+	newPushers := make(map[string]*pusher, len(targets))
+	for name, target := range targets {
+		old := m.pushers[name]
+		if old != nil && reflect.DeepEqual(old.target, target) {
+			newPushers[name] = old
+			delete(m.pushers, name)
+			continue
+		}
+		p, err := newPusher(target, m.sources)
+		if err != nil {
+			logger.Noticef("Internal error: cannot create pusher for metric target %q: %v", name, err)
+			continue
+		}
+		newPushers[name] = p
+	}
+
+	// Pushers for removed or changed targets need to be shut down.
+	for _, p := range m.pushers {
+		go p.Stop()
+	}
+	m.pushers = newPushers
+}
+
+// Ensure implements overlord.StateManager.
+func (m *MetricManager) Ensure() error {
+	return nil
+}
+
+// Stop implements overlord.StateStopper and stops all metric pushing.
+func (m *MetricManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range m.pushers {
+		wg.Add(1)
+		go func(p *pusher) {
+			p.Stop()
+			wg.Done()
+		}(p)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metricstate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/metricstate"
+)
+
+// fakeSource writes a single gauge metric.
+type fakeSource struct {
+	name string
+}
+
+func (s fakeSource) WriteMetrics(writer metrics.Writer) error {
+	return writer.Write(metrics.Metric{
+		Name:       s.name,
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: 1,
+	})
+}
+
+// pushRequest is the subset of an OTLP metrics request used by the tests.
+type pushRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes []struct {
+				Key   string `json:"key"`
+				Value struct {
+					StringValue string `json:"stringValue"`
+				} `json:"value"`
+			} `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []struct {
+				Name string `json:"name"`
+			} `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+func (r *pushRequest) metricNames() []string {
+	var names []string
+	for _, m := range r.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// collector starts a fake OTLP collector, returning the server and a channel
+// that receives each request.
+func collector(c *C) (*httptest.Server, chan *pushRequest) {
+	requests := make(chan *pushRequest, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/metrics")
+		var req pushRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		c.Check(err, IsNil)
+		requests <- &req
+	}))
+	return server, requests
+}
+
+func waitRequest(c *C, requests chan *pushRequest) *pushRequest {
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(5 * time.Second):
+		c.Fatalf("timed out waiting for metrics to be pushed")
+	}
+	return nil
+}
+
+func (s *metricSuite) TestPush(c *C) {
+	server, requests := collector(c)
+	defer server.Close()
+	m := metricstate.NewManager(fakeSource{"metric_a"}, fakeSource{"metric_b"})
+	defer m.Stop()
+
+	p, err := parsePlan(fmt.Sprintf(`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: %s
+        interval: 10ms
+        labels:
+            env: test
+`, server.URL))
+	c.Assert(err, IsNil)
+	m.PlanChanged(p)
+
+	req := waitRequest(c, requests)
+	c.Check(req.metricNames(), DeepEquals, []string{"metric_a", "metric_b"})
+	attrs := req.ResourceMetrics[0].Resource.Attributes
+	c.Assert(attrs, HasLen, 2)
+	c.Check(attrs[0].Key, Equals, "service.name")
+	c.Check(attrs[0].Value.StringValue, Equals, "pebble")
+	c.Check(attrs[1].Key, Equals, "env")
+	c.Check(attrs[1].Value.StringValue, Equals, "test")
+
+	// Metrics continue to be pushed periodically.
+	req = waitRequest(c, requests)
+	c.Check(req.metricNames(), DeepEquals, []string{"metric_a", "metric_b"})
+}
+
+func (s *metricSuite) TestPlanChanged(c *C) {
+	server1, requests1 := collector(c)
+	defer server1.Close()
+	server2, requests2 := collector(c)
+	defer server2.Close()
+	m := metricstate.NewManager(fakeSource{"metric_a"})
+	defer m.Stop()
+
+	layer := `
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: %s
+        interval: 10ms
+`
+	p, err := parsePlan(fmt.Sprintf(layer, server1.URL))
+	c.Assert(err, IsNil)
+	m.PlanChanged(p)
+	waitRequest(c, requests1)
+
+	// Changing the target's configuration moves pushes to the new location.
+	p, err = parsePlan(fmt.Sprintf(layer, server2.URL))
+	c.Assert(err, IsNil)
+	m.PlanChanged(p)
+	waitRequest(c, requests2)
+	drainRequests(requests1, 50*time.Millisecond)
+	c.Check(requests1, HasLen, 0)
+
+	// Removing the target stops pushes.
+	p, err = parsePlan()
+	c.Assert(err, IsNil)
+	m.PlanChanged(p)
+	drainRequests(requests2, 50*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	c.Check(requests2, HasLen, 0)
+}
+
+// drainRequests discards requests received until none have been received
+// for the given duration.
+func drainRequests(requests chan *pushRequest, quiet time.Duration) {
+	for {
+		select {
+		case <-requests:
+		case <-time.After(quiet):
+			return
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package opentelemetry
+
+import (
+	"time"
+)
+
+// FakeTimeNow fakes the time used for data point timestamps.
+func FakeTimeNow(t time.Time) (restore func()) {
+	old := timeNow
+	timeNow = func() time.Time {
+		return t
+	}
+	return func() {
+		timeNow = old
+	}
+}
+
+// BufferedNames returns the names of the metrics in the client's buffer.
+func BufferedNames(c *Client) []string {
+	names := make([]string, len(c.metrics))
+	for i, m := range c.metrics {
+		names[i] = m.Name
+	}
+	return names
+}
@@ -0,0 +1,447 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/metrics"
+)
+
+const (
+	requestTimeout     = 10 * time.Second
+	maxBufferedMetrics = 1000
+
+	// aggregationTemporalityCumulative means a sum or histogram data point
+	// covers all observations since the start time.
+	aggregationTemporalityCumulative = 2
+)
+
+// timeNow can be faked during testing.
+var timeNow = time.Now
+
+// A collection of ScopeMetrics from a Resource.
+// Refer to `type ResourceMetrics struct` in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/metrics/v1/metrics.pb.go#L241
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics,omitempty"`
+}
+
+// Resource information, partially from 'type Resource struct' in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/resource/v1/resource.pb.go#L30
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+// keyValue is a key-value pair that stores attributes.
+// from 'type KeyValue struct' in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/common/v1/common.pb.go#L286
+type keyValue struct {
+	Key   string   `json:"key,omitempty"`
+	Value anyValue `json:"value"`
+}
+
+// anyValue represents the OTLP attribute value format.
+// Refer to `type AnyValue struct` in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/common/v1/common.pb.go#L31
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+}
+
+// A collection of Metrics produced by a Scope.
+// Refer to `type ScopeMetrics struct` in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/metrics/v1/metrics.pb.go#L318
+type scopeMetrics struct {
+	Scope   instrumentationScope `json:"scope"`
+	Metrics []metric             `json:"metrics,omitempty"`
+}
+
+// Refer to `type InstrumentationScope struct` in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/common/v1/common.pb.go#L340
+type instrumentationScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// A metric with a single data point, as collected by one call to Write.
+// Exactly one of Gauge, Sum, Histogram and Summary is set.
+// Refer to `type Metric struct` in
+// https://github.com/open-telemetry/opentelemetry-collector/blob/3c0fd3946f70a0b1fa97813c39dbc4d91d95afa6/pdata/internal/data/protogen/metrics/v1/metrics.pb.go#L481
+type metric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *gauge     `json:"gauge,omitempty"`
+	Sum         *sum       `json:"sum,omitempty"`
+	Histogram   *histogram `json:"histogram,omitempty"`
+	Summary     *summary   `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+// 64-bit integers are encoded as strings in OTLP/JSON, and times are in
+// nanoseconds since the Unix epoch.
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsInt             *string    `json:"asInt,omitempty"`
+	AsDouble          *float64   `json:"asDouble,omitempty"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	BucketCounts      []string   `json:"bucketCounts"`
+	ExplicitBounds    []float64  `json:"explicitBounds"`
+}
+
+type summaryDataPoint struct {
+	Attributes        []keyValue      `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	QuantileValues    []quantileValue `json:"quantileValues,omitempty"`
+}
+
+type quantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// Client pushes metrics to an OpenTelemetry collector using OTLP/HTTP with
+// JSON encoding. It implements metrics.Writer: metrics written to it are
+// buffered until the next call to Flush.
+type Client struct {
+	options    *ClientOptions
+	httpClient *http.Client
+
+	// Time the client was created, used as the start time of cumulative
+	// data points.
+	startTime time.Time
+
+	// Metrics written since the last successful flush. If flushing fails
+	// with a retryable error, they're sent again on the next flush, with
+	// the oldest dropped once MaxBufferedMetrics is reached.
+	metrics []metric
+
+	resourceAttributes []keyValue
+}
+
+func NewClient(options *ClientOptions) *Client {
+	opts := *options
+	fillDefaultOptions(&opts)
+	c := &Client{
+		options:    &opts,
+		httpClient: &http.Client{Timeout: opts.RequestTimeout},
+		startTime:  timeNow(),
+	}
+	c.SetLabels(nil)
+	return c
+}
+
+// ClientOptions allows overriding default parameters (e.g. for testing).
+type ClientOptions struct {
+	RequestTimeout     time.Duration
+	MaxBufferedMetrics int
+	UserAgent          string
+	ScopeName          string
+	TargetName         string
+	Location           string
+}
+
+func fillDefaultOptions(options *ClientOptions) {
+	if options.RequestTimeout == 0 {
+		options.RequestTimeout = requestTimeout
+	}
+	if options.MaxBufferedMetrics == 0 {
+		options.MaxBufferedMetrics = maxBufferedMetrics
+	}
+}
+
+// SetLabels sets the resource attributes sent with all metrics. The
+// "service.name" attribute defaults to the scope name.
+func (c *Client) SetLabels(attributes map[string]string) {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	// Sort labels to ensure deterministic order.
+	sort.Strings(keys)
+
+	keyValuePairs := make([]keyValue, 0, len(attributes)+1)
+	if _, ok := attributes["service.name"]; !ok {
+		serviceName := c.options.ScopeName
+		keyValuePairs = append(keyValuePairs, keyValue{
+			Key:   "service.name",
+			Value: anyValue{StringValue: &serviceName},
+		})
+	}
+	for _, k := range keys {
+		v := attributes[k]
+		keyValuePairs = append(keyValuePairs, keyValue{
+			Key:   k,
+			Value: anyValue{StringValue: &v},
+		})
+	}
+	c.resourceAttributes = keyValuePairs
+}
+
+// Write implements metrics.Writer, converting the metric to OTLP format and
+// adding it to the client's buffer.
+func (c *Client) Write(m metrics.Metric) error {
+	encoded, err := c.encodeMetric(m)
+	if err != nil {
+		return err
+	}
+	if len(c.metrics) >= c.options.MaxBufferedMetrics {
+		// Buffer is full - drop the oldest metric to make room.
+		c.metrics[0] = metric{}
+		c.metrics = c.metrics[1:]
+	}
+	c.metrics = append(c.metrics, encoded)
+	return nil
+}
+
+func (c *Client) encodeMetric(m metrics.Metric) (metric, error) {
+	attributes := make([]keyValue, 0, len(m.Labels))
+	for _, label := range m.Labels {
+		value := label.Value()
+		attributes = append(attributes, keyValue{
+			Key:   label.Key(),
+			Value: anyValue{StringValue: &value},
+		})
+	}
+	startTime := strconv.FormatInt(c.startTime.UnixNano(), 10)
+	now := strconv.FormatInt(timeNow().UnixNano(), 10)
+
+	encoded := metric{
+		Name:        m.Name,
+		Description: m.Comment,
+	}
+	switch m.Type {
+	case metrics.TypeCounterInt:
+		value := strconv.FormatInt(m.ValueInt64, 10)
+		encoded.Sum = &sum{
+			DataPoints: []numberDataPoint{{
+				Attributes:        attributes,
+				StartTimeUnixNano: startTime,
+				TimeUnixNano:      now,
+				AsInt:             &value,
+			}},
+			AggregationTemporality: aggregationTemporalityCumulative,
+			IsMonotonic:            true,
+		}
+	case metrics.TypeGaugeInt:
+		value := strconv.FormatInt(m.ValueInt64, 10)
+		encoded.Gauge = &gauge{DataPoints: []numberDataPoint{{
+			Attributes:   attributes,
+			TimeUnixNano: now,
+			AsInt:        &value,
+		}}}
+	case metrics.TypeGaugeFloat:
+		if !isFinite(m.ValueFloat64) {
+			return metric{}, fmt.Errorf("cannot encode metric %q: value %v is not finite", m.Name, m.ValueFloat64)
+		}
+		value := m.ValueFloat64
+		encoded.Gauge = &gauge{DataPoints: []numberDataPoint{{
+			Attributes:   attributes,
+			TimeUnixNano: now,
+			AsDouble:     &value,
+		}}}
+	case metrics.TypeHistogram:
+		// OTLP bucket counts aren't cumulative, and have an extra bucket for
+		// values above the last bound.
+		bounds := make([]float64, len(m.Buckets))
+		counts := make([]string, len(m.Buckets)+1)
+		var previous uint64
+		for i, bucket := range m.Buckets {
+			bounds[i] = bucket.UpperBound
+			counts[i] = strconv.FormatUint(bucket.Count-previous, 10)
+			previous = bucket.Count
+		}
+		counts[len(m.Buckets)] = strconv.FormatUint(m.Count-previous, 10)
+		encoded.Histogram = &histogram{
+			DataPoints: []histogramDataPoint{{
+				Attributes:        attributes,
+				StartTimeUnixNano: startTime,
+				TimeUnixNano:      now,
+				Count:             strconv.FormatUint(m.Count, 10),
+				Sum:               m.Sum,
+				BucketCounts:      counts,
+				ExplicitBounds:    bounds,
+			}},
+			AggregationTemporality: aggregationTemporalityCumulative,
+		}
+	case metrics.TypeSummary:
+		var quantiles []quantileValue
+		for _, q := range m.Quantiles {
+			// Quantiles are NaN when there are no observations yet.
+			if isFinite(q.Value) {
+				quantiles = append(quantiles, quantileValue{Quantile: q.Quantile, Value: q.Value})
+			}
+		}
+		encoded.Summary = &summary{DataPoints: []summaryDataPoint{{
+			Attributes:        attributes,
+			StartTimeUnixNano: startTime,
+			TimeUnixNano:      now,
+			Count:             strconv.FormatUint(m.Count, 10),
+			Sum:               m.Sum,
+			QuantileValues:    quantiles,
+		}}}
+	default:
+		return metric{}, fmt.Errorf("cannot encode metric %q: invalid type %d", m.Name, m.Type)
+	}
+	return encoded, nil
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+type payload struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+// Flush sends the buffered metrics to the OpenTelemetry collector.
+func (c *Client) Flush(ctx context.Context) error {
+	if len(c.metrics) == 0 {
+		return nil // no-op
+	}
+
+	payload := payload{
+		ResourceMetrics: []resourceMetrics{{
+			Resource: resource{Attributes: c.resourceAttributes},
+			ScopeMetrics: []scopeMetrics{{
+				Scope:   instrumentationScope{Name: c.options.ScopeName},
+				Metrics: c.metrics,
+			}},
+		}},
+	}
+	return c.sendBatch(ctx, payload)
+}
+
+func (c *Client) sendBatch(ctx context.Context, payload payload) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal metric batch: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.options.Location+"/v1/metrics", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.options.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send metrics: %v", err)
+	}
+
+	return c.handleServerResponse(resp)
+}
+
+// resetBuffer drops all buffered metrics (in the case of a successful send,
+// or an unrecoverable error).
+func (c *Client) resetBuffer() {
+	c.metrics = nil
+}
+
+// handleServerResponse determines what to do based on the response from the
+// OpenTelemetry collector. 4xx and 5xx responses indicate errors, so in this
+// case, we will bubble up the error to the caller.
+func (c *Client) handleServerResponse(resp *http.Response) error {
+	defer func() {
+		// Drain request body to allow connection reuse.
+		// See https://pkg.go.dev/net/http#Response.Body
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+		_ = resp.Body.Close()
+	}()
+
+	code := resp.StatusCode
+	switch {
+	case code == http.StatusOK || code == http.StatusNoContent:
+		// Success - safe to drop metrics.
+		c.resetBuffer()
+		return nil
+
+	case code == http.StatusTooManyRequests:
+		// For 429, don't drop metrics - just retry later.
+		return errFromResponse(resp)
+
+	case 400 <= code && code < 500:
+		// Other 4xx codes indicate a client problem, so drop the metrics
+		// (retrying won't help).
+		logger.Noticef("Target %q: request failed with status %d, dropping %d metrics",
+			c.options.TargetName, code, len(c.metrics))
+		c.resetBuffer()
+		return errFromResponse(resp)
+
+	case 500 <= code && code < 600:
+		// 5xx indicates a problem with the server, so don't drop metrics
+		// (retry later).
+		return errFromResponse(resp)
+
+	default:
+		// Unexpected response - don't drop metrics to be safe.
+		return fmt.Errorf("unexpected response from server: %v", resp.Status)
+	}
+}
+
+// errFromResponse generates an error from a failed *http.Response.
+// Note: this function reads the response body.
+func errFromResponse(resp *http.Response) error {
+	// Read response body to get more context
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err == nil {
+		logger.Debugf("HTTP %d error, response %q", resp.StatusCode, body)
+	} else {
+		logger.Debugf("HTTP %d error, but cannot read response: %v", resp.StatusCode, err)
+	}
+
+	return fmt.Errorf("server returned HTTP %v", resp.Status)
+}
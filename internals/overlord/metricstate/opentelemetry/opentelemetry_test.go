@@ -0,0 +1,333 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package opentelemetry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/metricstate/opentelemetry"
+)
+
+type suite struct{}
+
+var _ = Suite(&suite{})
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+func (*suite) TestRequest(c *C) {
+	restore := opentelemetry.FakeTimeNow(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	defer restore()
+
+	expected := compactJSON(`
+{"resourceMetrics": [{
+	"resource": {
+		"attributes": [
+			{"key": "service.name", "value": {"stringValue": "pebble"}}
+		]
+	},
+	"scopeMetrics": [{
+		"scope": {"name": "pebble"},
+		"metrics": [
+			{
+				"name": "pebble_service_start_count",
+				"description": "Number of times the service has started",
+				"sum": {
+					"dataPoints": [{
+						"attributes": [{"key": "service", "value": {"stringValue": "svc1"}}],
+						"startTimeUnixNano": "1767323045000000000",
+						"timeUnixNano": "1767323045000000000",
+						"asInt": "3"
+					}],
+					"aggregationTemporality": 2,
+					"isMonotonic": true
+				}
+			},
+			{
+				"name": "pebble_service_active",
+				"gauge": {
+					"dataPoints": [{
+						"attributes": [{"key": "service", "value": {"stringValue": "svc1"}}],
+						"timeUnixNano": "1767323045000000000",
+						"asInt": "1"
+					}]
+				}
+			},
+			{
+				"name": "pebble_service_cpu_seconds",
+				"gauge": {
+					"dataPoints": [{
+						"timeUnixNano": "1767323045000000000",
+						"asDouble": 1.5
+					}]
+				}
+			},
+			{
+				"name": "pebble_check_duration_seconds",
+				"histogram": {
+					"dataPoints": [{
+						"attributes": [{"key": "check", "value": {"stringValue": "chk1"}}],
+						"startTimeUnixNano": "1767323045000000000",
+						"timeUnixNano": "1767323045000000000",
+						"count": "5",
+						"sum": 2.5,
+						"bucketCounts": ["1", "2", "2"],
+						"explicitBounds": [0.1, 1]
+					}],
+					"aggregationTemporality": 2
+				}
+			},
+			{
+				"name": "request_seconds",
+				"summary": {
+					"dataPoints": [{
+						"startTimeUnixNano": "1767323045000000000",
+						"timeUnixNano": "1767323045000000000",
+						"count": "4",
+						"sum": 10,
+						"quantileValues": [{"quantile": 0.5, "value": 2}]
+					}]
+				}
+			}
+		]
+	}]
+}]}`)
+	numRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, http.MethodPost)
+		c.Check(r.URL.Path, Equals, "/v1/metrics")
+		c.Check(r.Header.Get("Content-Type"), Equals, "application/json")
+		c.Check(r.Header.Get("User-Agent"), Equals, "pebble/1.23.0")
+		numRequests++
+		reqBody, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		c.Check(string(reqBody), Equals, string(expected))
+	}))
+	defer server.Close()
+
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		Location:  server.URL,
+		UserAgent: "pebble/1.23.0",
+		ScopeName: "pebble",
+	})
+	input := []metrics.Metric{{
+		Name:       "pebble_service_start_count",
+		Type:       metrics.TypeCounterInt,
+		Comment:    "Number of times the service has started",
+		Labels:     []metrics.Label{metrics.NewLabel("service", "svc1")},
+		ValueInt64: 3,
+	}, {
+		Name:       "pebble_service_active",
+		Type:       metrics.TypeGaugeInt,
+		Labels:     []metrics.Label{metrics.NewLabel("service", "svc1")},
+		ValueInt64: 1,
+	}, {
+		Name:         "pebble_service_cpu_seconds",
+		Type:         metrics.TypeGaugeFloat,
+		ValueFloat64: 1.5,
+	}, {
+		Name:    "pebble_check_duration_seconds",
+		Type:    metrics.TypeHistogram,
+		Labels:  []metrics.Label{metrics.NewLabel("check", "chk1")},
+		Buckets: []metrics.Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 3}},
+		Count:   5,
+		Sum:     2.5,
+	}, {
+		Name: "request_seconds",
+		Type: metrics.TypeSummary,
+		// Quantiles with no observations are NaN, and are omitted.
+		Quantiles: []metrics.Quantile{{Quantile: 0.5, Value: 2}, {Quantile: 0.9, Value: math.NaN()}},
+		Count:     4,
+		Sum:       10,
+	}}
+	for _, m := range input {
+		err := client.Write(m)
+		c.Assert(err, IsNil)
+	}
+
+	err := client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(numRequests, Equals, 1)
+
+	// The buffer is empty after a successful flush.
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(numRequests, Equals, 1)
+}
+
+func (*suite) TestWriteInvalid(c *C) {
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{Location: "fake"})
+	err := client.Write(metrics.Metric{Name: "m", Type: metrics.TypeGaugeFloat, ValueFloat64: math.Inf(1)})
+	c.Check(err, ErrorMatches, `cannot encode metric "m": value \+Inf is not finite`)
+	err = client.Write(metrics.Metric{Name: "m", Type: 42})
+	c.Check(err, ErrorMatches, `cannot encode metric "m": invalid type 42`)
+	c.Check(opentelemetry.BufferedNames(client), HasLen, 0)
+}
+
+func (*suite) TestRetry(c *C) {
+	status := http.StatusServiceUnavailable
+	var received [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceMetrics []struct {
+				ScopeMetrics []struct {
+					Metrics []struct {
+						Name string `json:"name"`
+					} `json:"metrics"`
+				} `json:"scopeMetrics"`
+			} `json:"resourceMetrics"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		c.Assert(err, IsNil)
+		var names []string
+		for _, m := range body.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			names = append(names, m.Name)
+		}
+		received = append(received, names)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		TargetName: "tgt1",
+		Location:   server.URL,
+	})
+	write := func(name string) {
+		err := client.Write(metrics.Metric{Name: name, Type: metrics.TypeGaugeInt})
+		c.Assert(err, IsNil)
+	}
+
+	// Server errors keep the metrics to send again later.
+	write("a")
+	err := client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 503 Service Unavailable")
+	status = http.StatusTooManyRequests
+	write("b")
+	err = client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 429 Too Many Requests")
+	c.Check(opentelemetry.BufferedNames(client), DeepEquals, []string{"a", "b"})
+
+	// Client errors drop them, as retrying won't help.
+	status = http.StatusBadRequest
+	err = client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, "server returned HTTP 400 Bad Request")
+	c.Check(opentelemetry.BufferedNames(client), HasLen, 0)
+
+	status = http.StatusOK
+	write("c")
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(opentelemetry.BufferedNames(client), HasLen, 0)
+
+	c.Check(received, DeepEquals, [][]string{{"a"}, {"a", "b"}, {"a", "b"}, {"c"}})
+}
+
+func (*suite) TestBufferFull(c *C) {
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		Location:           "fake",
+		MaxBufferedMetrics: 3,
+	})
+	for _, name := range []string{"1", "2", "3", "4", "5"} {
+		err := client.Write(metrics.Metric{Name: name, Type: metrics.TypeGaugeInt})
+		c.Assert(err, IsNil)
+	}
+	c.Check(opentelemetry.BufferedNames(client), DeepEquals, []string{"3", "4", "5"})
+}
+
+func (*suite) TestServerTimeout(c *C) {
+	stopRequest := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stopRequest
+	}))
+	defer server.Close()
+	defer close(stopRequest)
+
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		Location:       server.URL,
+		RequestTimeout: 1 * time.Microsecond,
+	})
+	err := client.Write(metrics.Metric{Name: "m", Type: metrics.TypeGaugeInt})
+	c.Assert(err, IsNil)
+
+	err = client.Flush(context.Background())
+	c.Assert(err, ErrorMatches, ".*context deadline exceeded.*")
+	c.Check(opentelemetry.BufferedNames(client), DeepEquals, []string{"m"})
+}
+
+func (*suite) TestLabels(c *C) {
+	restore := opentelemetry.FakeTimeNow(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	defer restore()
+
+	var reqBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		reqBody, err = io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+	}))
+	defer server.Close()
+
+	client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+		Location:  server.URL,
+		ScopeName: "pebble",
+	})
+	client.SetLabels(map[string]string{
+		"service.name": "my-app",
+		"env":          "prod",
+	})
+	err := client.Write(metrics.Metric{Name: "m", Type: metrics.TypeGaugeInt, ValueInt64: 7})
+	c.Assert(err, IsNil)
+	err = client.Flush(context.Background())
+	c.Assert(err, IsNil)
+
+	c.Check(string(reqBody), Equals, string(compactJSON(`
+{"resourceMetrics": [{
+	"resource": {
+		"attributes": [
+			{"key": "env", "value": {"stringValue": "prod"}},
+			{"key": "service.name", "value": {"stringValue": "my-app"}}
+		]
+	},
+	"scopeMetrics": [{
+		"scope": {"name": "pebble"},
+		"metrics": [{
+			"name": "m",
+			"gauge": {"dataPoints": [{"timeUnixNano": "1767323045000000000", "asInt": "7"}]}
+		}]
+	}]
+}]}`)))
+}
+
+// Strips all extraneous whitespace from JSON
+func compactJSON(s string) []byte {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, []byte(s))
+	if err != nil {
+		panic(fmt.Sprintf("error compacting JSON: %v", err))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metricstate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/canonical/pebble/internals/overlord/metricstate"
+	"github.com/canonical/pebble/internals/plan"
+)
+
+// Hook up check.v1 into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type metricSuite struct{}
+
+var _ = Suite(&metricSuite{})
+
+func (s *metricSuite) SetUpTest(c *C) {
+	plan.RegisterSectionExtension(metricstate.MetricTargetsField, &metricstate.SectionExtension{})
+}
+
+func (s *metricSuite) TearDownTest(c *C) {
+	plan.UnregisterSectionExtension(metricstate.MetricTargetsField)
+}
+
+// parsePlan parses and combines the given layers into a validated plan.
+func parsePlan(yamls ...string) (*plan.Plan, error) {
+	var layers []*plan.Layer
+	for i, yaml := range yamls {
+		layer, err := plan.ParseLayer(i, fmt.Sprintf("test-plan-layer-%v", i), []byte(yaml))
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	combined, err := plan.CombineLayers(layers...)
+	if err != nil {
+		return nil, err
+	}
+	p := &plan.Plan{
+		Layers:     layers,
+		Services:   combined.Services,
+		Checks:     combined.Checks,
+		LogTargets: combined.LogTargets,
+		Sections:   combined.Sections,
+	}
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// sectionYAML presents a plan's metric-targets section as a marshalled YAML
+// string.
+func sectionYAML(c *C, p *plan.Plan) string {
+	yml, err := yaml.Marshal(map[string]plan.Section{
+		metricstate.MetricTargetsField: p.Sections[metricstate.MetricTargetsField],
+	})
+	c.Assert(err, IsNil)
+	return strings.TrimSpace(string(yml))
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metricstate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/canonical/pebble/cmd"
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/metricstate/opentelemetry"
+)
+
+const (
+	defaultInterval = 60 * time.Second
+
+	// timeoutFinalFlush is the maximum time allowed to send metrics still
+	// buffered (after a failed push) when a pusher is stopped.
+	timeoutFinalFlush = 2 * time.Second
+)
+
+// metricClient encodes metrics in the format required by a type of metric
+// target, and sends them using the protocol required by that target.
+type metricClient interface {
+	// Write adds the given metric to the client's buffer.
+	metrics.Writer
+
+	// Flush sends buffered metrics (if any) to the remote target.
+	Flush(context.Context) error
+}
+
+// pusher periodically collects metrics from its sources and pushes them to
+// a single metric target. Its loop runs in its own goroutine until Stop is
+// called.
+type pusher struct {
+	target   *MetricTarget
+	interval time.Duration
+	sources  []Source
+	client   metricClient
+	tomb     tomb.Tomb
+}
+
+func newPusher(target *MetricTarget, sources []Source) (*pusher, error) {
+	client, err := newMetricClient(target)
+	if err != nil {
+		return nil, err
+	}
+	p := &pusher{
+		target:   target,
+		interval: defaultInterval,
+		sources:  sources,
+		client:   client,
+	}
+	if target.Interval.IsSet {
+		p.interval = target.Interval.Value
+	}
+	p.tomb.Go(p.loop)
+	return p, nil
+}
+
+func newMetricClient(target *MetricTarget) (metricClient, error) {
+	switch target.Type {
+	case OpenTelemetryTarget:
+		client := opentelemetry.NewClient(&opentelemetry.ClientOptions{
+			TargetName: target.Name,
+			Location:   target.Location,
+			UserAgent:  fmt.Sprintf("%s/%s", cmd.ProgramName, cmd.Version),
+			ScopeName:  cmd.ProgramName,
+		})
+		client.SetLabels(target.Labels)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q for metric target %q", target.Type, target.Name)
+	}
+}
+
+func (p *pusher) loop() error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.push(p.tomb.Context(nil))
+
+		case <-p.tomb.Dying():
+			// Try once more to send any metrics left over from a failed push.
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutFinalFlush)
+			defer cancel()
+			p.flush(ctx)
+			return nil
+		}
+	}
+}
+
+// push collects the current metrics from all sources and sends them, along
+// with any metrics left over from earlier failed pushes.
+func (p *pusher) push(ctx context.Context) {
+	for _, source := range p.sources {
+		err := source.WriteMetrics(p.client)
+		if err != nil {
+			logger.Noticef("Cannot collect metrics for target %q: %v", p.target.Name, err)
+		}
+	}
+	p.flush(ctx)
+}
+
+func (p *pusher) flush(ctx context.Context) {
+	err := p.client.Flush(ctx)
+	if err != nil {
+		logger.Noticef("Cannot push metrics to target %q: %v", p.target.Name, err)
+	}
+}
+
+// Stop stops the pusher, waiting for any push in progress to be cancelled
+// and a final flush to complete.
+func (p *pusher) Stop() {
+	p.tomb.Kill(nil)
+	err := p.tomb.Wait()
+	if err != nil {
+		logger.Noticef("Cannot shut down metric pusher: %v", err)
+	}
+}
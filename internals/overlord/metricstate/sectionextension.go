@@ -0,0 +1,190 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metricstate
+
+import (
+	"fmt"
+	"maps"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/canonical/pebble/internals/plan"
+)
+
+// MetricTargetsField is the top level string key used in the Pebble plan.
+const MetricTargetsField = "metric-targets"
+
+// MetricTarget specifies a remote server to push metrics to.
+type MetricTarget struct {
+	Name     string                `yaml:"-"`
+	Override plan.Override         `yaml:"override,omitempty"`
+	Type     MetricTargetType      `yaml:"type,omitempty"`
+	Location string                `yaml:"location,omitempty"`
+	Interval plan.OptionalDuration `yaml:"interval,omitempty"`
+	Labels   map[string]string     `yaml:"labels,omitempty"`
+}
+
+// MetricTargetType defines the protocol to use to push metrics.
+type MetricTargetType string
+
+const (
+	OpenTelemetryTarget MetricTargetType = "opentelemetry"
+	UnsetMetricTarget   MetricTargetType = ""
+)
+
+func (t *MetricTarget) copy() *MetricTarget {
+	copied := *t
+	copied.Labels = maps.Clone(t.Labels)
+	return &copied
+}
+
+func (t *MetricTarget) merge(other *MetricTarget) {
+	if other.Type != "" {
+		t.Type = other.Type
+	}
+	if other.Location != "" {
+		t.Location = other.Location
+	}
+	if other.Interval.IsSet {
+		t.Interval = other.Interval
+	}
+	if len(other.Labels) > 0 {
+		if t.Labels == nil {
+			t.Labels = make(map[string]string)
+		}
+		maps.Copy(t.Labels, other.Labels)
+	}
+}
+
+var _ plan.Section = (*MetricTargetsSection)(nil)
+
+// MetricTargetsSection is the metric-targets section of a layer or plan.
+type MetricTargetsSection struct {
+	Entries map[string]*MetricTarget `yaml:",inline"`
+}
+
+func (s *MetricTargetsSection) IsZero() bool {
+	return len(s.Entries) == 0
+}
+
+func (s *MetricTargetsSection) Validate() error {
+	for name, target := range s.Entries {
+		if target == nil {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("metric target object cannot be null for metric target %q", name),
+			}
+		}
+		switch target.Type {
+		case OpenTelemetryTarget:
+			// valid, continue
+		case UnsetMetricTarget:
+			// will be checked when the layers are combined
+		default:
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`metric target %q has unsupported type %q, must be %q`,
+					name, target.Type, OpenTelemetryTarget),
+			}
+		}
+		if target.Interval.IsSet && target.Interval.Value <= 0 {
+			return &plan.FormatError{
+				Message: fmt.Sprintf("metric target %q interval must be greater than zero", name),
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MetricTargetsSection) combine(other *MetricTargetsSection) error {
+	for name, target := range other.Entries {
+		if s.Entries == nil {
+			s.Entries = make(map[string]*MetricTarget)
+		}
+		switch target.Override {
+		case plan.MergeOverride:
+			if current, ok := s.Entries[name]; ok {
+				current.merge(target)
+			} else {
+				s.Entries[name] = target.copy()
+			}
+		case plan.ReplaceOverride:
+			s.Entries[name] = target.copy()
+		case plan.UnknownOverride:
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`metric target %q must define "override" policy`, name),
+			}
+		default:
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`metric target %q has invalid "override" policy: %q`, name, target.Override),
+			}
+		}
+	}
+	return nil
+}
+
+var _ plan.SectionExtension = (*SectionExtension)(nil)
+
+// SectionExtension implements the Pebble plan.SectionExtension interface
+// for the metric-targets section.
+type SectionExtension struct{}
+
+func (SectionExtension) ParseSection(data yaml.Node) (plan.Section, error) {
+	section := &MetricTargetsSection{}
+	if err := plan.SectionDecode(&data, section); err != nil {
+		return nil, &plan.FormatError{
+			Message: fmt.Sprintf(`cannot parse the "metric-targets" section: %v`, err),
+		}
+	}
+	for name, target := range section.Entries {
+		if target != nil {
+			target.Name = name
+		}
+	}
+	return section, nil
+}
+
+func (SectionExtension) CombineSections(sections ...plan.Section) (plan.Section, error) {
+	combined := &MetricTargetsSection{}
+	for _, section := range sections {
+		layer, ok := section.(*MetricTargetsSection)
+		if !ok {
+			return nil, fmt.Errorf("internal error: invalid section type %T", section)
+		}
+		if err := combined.combine(layer); err != nil {
+			return nil, err
+		}
+	}
+	return combined, nil
+}
+
+func (SectionExtension) ValidatePlan(p *plan.Plan) error {
+	section, ok := p.Sections[MetricTargetsField].(*MetricTargetsSection)
+	if !ok {
+		return nil
+	}
+	for name, target := range section.Entries {
+		if target.Type == UnsetMetricTarget {
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`plan must define "type" (%q) for metric target %q`,
+					OpenTelemetryTarget, name),
+			}
+		}
+		if target.Location == "" {
+			return &plan.FormatError{
+				Message: fmt.Sprintf(`plan must define "location" for metric target %q`, name),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metricstate_test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/overlord/metricstate"
+	"github.com/canonical/pebble/internals/plan"
+)
+
+var schemaTests = []struct {
+	summary      string
+	layers       []string
+	combined     map[string]*metricstate.MetricTarget
+	combinedYAML string
+	error        string
+}{{
+	summary:      "empty section",
+	combinedYAML: `metric-targets: {}`,
+}, {
+	summary: "simple target",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+`},
+	combined: map[string]*metricstate.MetricTarget{
+		"otel": {
+			Name:     "otel",
+			Override: plan.ReplaceOverride,
+			Type:     metricstate.OpenTelemetryTarget,
+			Location: "http://collector:4318",
+		},
+	},
+	combinedYAML: `
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+`,
+}, {
+	summary: "merged target",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+        interval: 30s
+        labels:
+            env: staging
+            team: a
+`, `
+metric-targets:
+    otel:
+        override: merge
+        location: https://collector:4318
+        labels:
+            env: prod
+`},
+	combined: map[string]*metricstate.MetricTarget{
+		"otel": {
+			Name:     "otel",
+			Override: plan.ReplaceOverride,
+			Type:     metricstate.OpenTelemetryTarget,
+			Location: "https://collector:4318",
+			Interval: plan.OptionalDuration{Value: 30 * time.Second, IsSet: true},
+			Labels:   map[string]string{"env": "prod", "team": "a"},
+		},
+	},
+	combinedYAML: `
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: https://collector:4318
+        interval: 30s
+        labels:
+            env: prod
+            team: a
+`,
+}, {
+	summary: "replaced target",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+        interval: 30s
+`, `
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://other:4318
+`},
+	combined: map[string]*metricstate.MetricTarget{
+		"otel": {
+			Name:     "otel",
+			Override: plan.ReplaceOverride,
+			Type:     metricstate.OpenTelemetryTarget,
+			Location: "http://other:4318",
+		},
+	},
+	combinedYAML: `
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://other:4318
+`,
+}, {
+	summary: "missing override",
+	layers: []string{`
+metric-targets:
+    otel:
+        type: opentelemetry
+        location: http://collector:4318
+`},
+	error: `metric target "otel" must define "override" policy`,
+}, {
+	summary: "invalid override",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: foo
+        type: opentelemetry
+        location: http://collector:4318
+`},
+	error: `metric target "otel" has invalid "override" policy: "foo"`,
+}, {
+	summary: "unsupported type",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: prometheus
+        location: http://collector:4318
+`},
+	error: `metric target "otel" has unsupported type "prometheus", must be "opentelemetry"`,
+}, {
+	summary: "missing type",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: merge
+        location: http://collector:4318
+`},
+	error: `plan must define "type" \("opentelemetry"\) for metric target "otel"`,
+}, {
+	summary: "missing location",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: merge
+        type: opentelemetry
+`},
+	error: `plan must define "location" for metric target "otel"`,
+}, {
+	summary: "invalid interval",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+        interval: 0s
+`},
+	error: `metric target "otel" interval must be greater than zero`,
+}, {
+	summary: "null target",
+	layers: []string{`
+metric-targets:
+    otel:
+`},
+	error: `metric target object cannot be null for metric target "otel"`,
+}, {
+	summary: "unknown field",
+	layers: []string{`
+metric-targets:
+    otel:
+        override: replace
+        type: opentelemetry
+        location: http://collector:4318
+        foo: bar
+`},
+	error: `cannot parse the "metric-targets" section: yaml: unmarshal errors:\n.*field foo not found.*`,
+}}
+
+func (s *metricSuite) TestSectionExtensionSchema(c *C) {
+	for i, t := range schemaTests {
+		c.Logf("Running TestSectionExtensionSchema %q test using test data index %d\n", t.summary, i)
+		p, err := parsePlan(t.layers...)
+		if t.error != "" {
+			c.Assert(err, ErrorMatches, t.error)
+			continue
+		}
+		c.Assert(err, IsNil)
+		section, ok := p.Sections[metricstate.MetricTargetsField].(*metricstate.MetricTargetsSection)
+		c.Assert(ok, Equals, true)
+		c.Assert(section.Entries, DeepEquals, t.combined)
+		c.Assert(sectionYAML(c, p), Equals, strings.TrimSpace(t.combinedYAML))
+	}
+}
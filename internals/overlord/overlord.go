@@ -29,11 +29,13 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/canonical/pebble/cmd"
+	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/osutil"
 	"github.com/canonical/pebble/internals/overlord/checkstate"
 	"github.com/canonical/pebble/internals/overlord/cmdstate"
 	"github.com/canonical/pebble/internals/overlord/identities"
 	"github.com/canonical/pebble/internals/overlord/logstate"
+	"github.com/canonical/pebble/internals/overlord/metricstate"
 	"github.com/canonical/pebble/internals/overlord/pairingstate"
 	"github.com/canonical/pebble/internals/overlord/patch"
 	"github.com/canonical/pebble/internals/overlord/planstate"
@@ -129,6 +131,7 @@ type Overlord struct {
 	commandMgr    *cmdstate.CommandManager
 	checkMgr      *checkstate.CheckManager
 	logMgr        *logstate.LogManager
+	metricMgr     *metricstate.MetricManager
 	tlsMgr        *tlsstate.TLSManager
 	identitiesMgr *identities.Manager
 	pairingMgr    *pairingstate.PairingManager
@@ -226,7 +229,7 @@ func New(opts *Options) (*Overlord, error) {
 	o.stateEng.AddManager(o.pairingMgr)
 	o.planMgr.AddChangeListener(o.pairingMgr.PlanChanged)
 
-	o.logMgr = logstate.NewLogManager()
+	o.logMgr = logstate.NewLogManager(filepath.Join(o.pebbleDir, "log-spool"))
 
 	o.serviceMgr, err = servstate.NewManager(
 		s,
@@ -259,9 +262,32 @@ func New(opts *Options) (*Overlord, error) {
 	// Tell log manager about plan updates.
 	o.planMgr.AddChangeListener(o.logMgr.PlanChanged)
 
+	// Let log targets forward Pebble's own logs and change events.
+	logger.SetForwarder(o.logMgr.Forwarder())
+	s.Lock()
+	s.AddChangeStatusChangedHandler(o.logMgr.ChangeStatusChanged)
+	s.AddTaskStatusChangedHandler(o.logMgr.TaskStatusChanged)
+	s.Unlock()
+
+	// Push the same metrics served by /v1/metrics to any metric targets.
+	o.metricMgr = metricstate.NewManager(o.serviceMgr, o.checkMgr, o.logMgr)
+	o.stateEng.AddManager(o.metricMgr)
+	o.planMgr.AddChangeListener(o.metricMgr.PlanChanged)
+
 	// Tell service manager about check failures.
 	o.checkMgr.NotifyCheckFailed(o.serviceMgr.CheckFailed)
 
+	// Let service manager wait for checks to be up before starting services.
+	o.serviceMgr.SetCheckReadyFunc(o.checkMgr.CheckReady)
+
+	// Let log checks read the output of services.
+	o.checkMgr.SetLogBufferFunc(o.serviceMgr.ServiceLogBuffer)
+
+	// Run checks bound to a service only while the service is running.
+	o.serviceMgr.NotifyServiceStatusChanged(func(name string, status servstate.ServiceStatus) {
+		o.checkMgr.ServiceStatusChanged(name, status == servstate.StatusActive)
+	})
+
 	if o.extension != nil {
 		extraManagers, err := o.extension.ExtraManagers(o)
 		if err != nil {
@@ -514,6 +540,9 @@ func (o *Overlord) Stop() error {
 	o.loopTomb.Kill(nil)
 	err := o.loopTomb.Wait()
 	o.stateEng.Stop()
+	if o.logMgr != nil {
+		logger.SetForwarder(nil)
+	}
 	return err
 }
 
@@ -629,6 +658,12 @@ func (o *Overlord) RestartManager() *restart.RestartManager {
 	return o.restartMgr
 }
 
+// LogManager returns the log manager responsible for forwarding logs to
+// log targets.
+func (o *Overlord) LogManager() *logstate.LogManager {
+	return o.logMgr
+}
+
 // ServiceManager returns the service manager responsible for services
 // under the overlord.
 func (o *Overlord) ServiceManager() *servstate.ServiceManager {
@@ -647,6 +682,12 @@ func (o *Overlord) CheckManager() *checkstate.CheckManager {
 	return o.checkMgr
 }
 
+// MetricManager returns the metric manager responsible for pushing metrics
+// to metric targets.
+func (o *Overlord) MetricManager() *metricstate.MetricManager {
+	return o.metricMgr
+}
+
 // PlanManager returns the plan manager responsible for managing the global
 // system configuration
 func (o *Overlord) PlanManager() *planstate.PlanManager {
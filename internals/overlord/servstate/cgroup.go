@@ -0,0 +1,205 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"syscall"
+
+	"github.com/canonical/pebble/internals/cgroup"
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/reaper"
+	"github.com/canonical/pebble/internals/workloads"
+)
+
+const (
+	// Names of the groups created under the daemon's own cgroup: the daemon
+	// moves itself into the first, as only leaf groups may have processes,
+	// and each service gets its own group under the second.
+	cgroupDaemonName   = "daemon"
+	cgroupServicesName = "services"
+)
+
+// cgroupControllers are the controllers enabled for services, if available.
+var cgroupControllers = []string{"cpu", "io", "memory", "pids"}
+
+// servicesCgroup returns the cgroup under which each service gets its own
+// group, setting it up the first time it's called. The services lock must be
+// held.
+func (m *ServiceManager) servicesCgroup() (*cgroup.Group, error) {
+	if !m.cgroupSetUp {
+		m.cgroupSetUp = true
+		m.cgroup, m.cgroupErr = setupServicesCgroup()
+		if m.cgroupErr != nil && !errors.Is(m.cgroupErr, cgroup.ErrUnavailable) {
+			logger.Noticef("Cannot set up cgroups for services: %v", m.cgroupErr)
+		}
+	}
+	return m.cgroup, m.cgroupErr
+}
+
+func setupServicesCgroup() (*cgroup.Group, error) {
+	self, err := cgroup.Self()
+	if err != nil {
+		return nil, err
+	}
+	daemon, err := self.Child(cgroupDaemonName)
+	if err != nil {
+		return nil, err
+	}
+	pids, err := self.Processes()
+	if err != nil {
+		return nil, err
+	}
+	for _, pid := range pids {
+		// Ignore processes that have exited since they were listed.
+		err := daemon.AddProcess(pid)
+		if err != nil && !errors.Is(err, syscall.ESRCH) {
+			return nil, err
+		}
+	}
+
+	available, err := self.Controllers()
+	if err != nil {
+		return nil, err
+	}
+	var controllers []string
+	for _, controller := range cgroupControllers {
+		if slices.Contains(available, controller) {
+			controllers = append(controllers, controller)
+		}
+	}
+	err = self.EnableControllers(controllers)
+	if err != nil {
+		return nil, err
+	}
+	services, err := self.Child(cgroupServicesName)
+	if err != nil {
+		return nil, err
+	}
+	err = services.EnableControllers(controllers)
+	if err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// serviceCgroup returns the service's cgroup with its resource limits
+// applied, or nil if cgroups aren't available. The services lock must be
+// held.
+func (m *ServiceManager) serviceCgroup(config *plan.Service, workload *workloads.Workload) *cgroup.Group {
+	resources := serviceResources(config, workload)
+	group, err := m.setupServiceCgroup(config.Name, resources)
+	if err == nil {
+		return group
+	}
+	if resources == nil {
+		// The service doesn't need its cgroup, except for kill-mode
+		// "cgroup", for which the service's start warns.
+		return nil
+	}
+	// Without cgroups the service still runs, just without its limits.
+	m.warnAsync("Cannot apply resource limits to service %q: %v", config.Name, err)
+	return nil
+}
+
+// startCommand starts the service's command. If the service has a cgroup,
+// the process is created in it, so that anything it forks is too. If the
+// kernel doesn't support that, the process is moved into the cgroup after it
+// starts, which misses anything it forks before then.
+func (s *serviceData) startCommand() error {
+	if s.cgroup == nil {
+		return reaper.StartCommand(s.cmd)
+	}
+	if !cgroup.CanCloneInto() {
+		err := reaper.StartCommand(s.cmd)
+		if err != nil {
+			return err
+		}
+		err = s.cgroup.AddProcess(s.cmd.Process.Pid)
+		if err != nil {
+			logger.Noticef("Cannot move service %q to its cgroup: %v", s.config.Name, err)
+			s.cgroup = nil
+		}
+		return nil
+	}
+	dir, err := s.cgroup.Open()
+	if err != nil {
+		return fmt.Errorf("cannot open cgroup: %w", err)
+	}
+	defer dir.Close()
+	s.cmd.SysProcAttr.UseCgroupFD = true
+	s.cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+	return reaper.StartCommand(s.cmd)
+}
+
+// warnAsync logs a notice and adds a warning. Taking the state lock while
+// holding the services lock could deadlock, so the warning is added
+// asynchronously.
+func (m *ServiceManager) warnAsync(format string, v ...any) {
+	logger.Noticef(format, v...)
+	go func() {
+		m.state.Lock()
+		defer m.state.Unlock()
+		m.state.Warnf(format, v...)
+	}()
+}
+
+func (m *ServiceManager) setupServiceCgroup(name string, resources *plan.Resources) (*cgroup.Group, error) {
+	parent, err := m.servicesCgroup()
+	if err != nil {
+		return nil, err
+	}
+	group, err := parent.Child(name)
+	if err != nil {
+		return nil, err
+	}
+	var limits cgroup.Limits
+	if resources != nil {
+		if resources.MemoryMax != "" {
+			limits.MemoryMax, err = resources.MemoryMaxBytes()
+			if err != nil {
+				return nil, err
+			}
+		}
+		limits.CPUMax = resources.CPUMax.Value
+		limits.PidsMax = resources.PidsMax
+		limits.IOWeight = resources.IOWeight
+	}
+	err = group.SetLimits(limits)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set limits: %w", err)
+	}
+	return group, nil
+}
+
+// serviceResources returns the service's effective resource limits: its
+// workload's limits, overridden by its own. It returns nil if neither sets
+// any.
+func serviceResources(config *plan.Service, workload *workloads.Workload) *plan.Resources {
+	if config.Resources == nil && (workload == nil || workload.Resources == nil) {
+		return nil
+	}
+	resources := &plan.Resources{}
+	if workload != nil && workload.Resources != nil {
+		resources.Merge(workload.Resources)
+	}
+	if config.Resources != nil {
+		resources.Merge(config.Resources)
+	}
+	return resources
+}
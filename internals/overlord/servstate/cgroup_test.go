@@ -0,0 +1,247 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/cgroup"
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/servstate"
+	"github.com/canonical/pebble/internals/overlord/state"
+)
+
+const resourcesPlanLayer = `
+services:
+    limited:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        workload: default
+        resources:
+            memory-max: 64M
+            pids-max: 50
+
+    unlimited:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+
+workloads:
+    default:
+        override: replace
+        resources:
+            cpu-max: 0.5
+            pids-max: 100
+`
+
+// fakeCgroupFS sets up a fake cgroup v2 filesystem in which the test process
+// is in the group "/pebble", and returns that group's path.
+func (s *S) fakeCgroupFS(c *C) string {
+	mount := filepath.Join(c.MkDir(), "cgroup")
+	self := filepath.Join(mount, "pebble")
+	err := os.MkdirAll(self, 0o755)
+	c.Assert(err, IsNil)
+	for _, dir := range []string{mount, self} {
+		err = os.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte("cpu memory pids\n"), 0o644)
+		c.Assert(err, IsNil)
+	}
+	err = os.WriteFile(filepath.Join(self, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+	c.Assert(err, IsNil)
+	selfCgroup := filepath.Join(c.MkDir(), "self-cgroup")
+	err = os.WriteFile(selfCgroup, []byte("0::/pebble\n"), 0o644)
+	c.Assert(err, IsNil)
+	s.AddCleanup(cgroup.FakePaths(mount, selfCgroup))
+	// Processes can't be created directly in a fake group.
+	s.AddCleanup(cgroup.FakeCloneInto(false))
+	return self
+}
+
+func (s *S) TestResourceLimits(c *C) {
+	self := s.fakeCgroupFS(c)
+	s.newServiceManager(c)
+	s.planAddLayer(c, resourcesPlanLayer)
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"limited"}})
+	s.st.Lock()
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	// The daemon moves itself out of its cgroup so that controllers can be
+	// enabled for the services' groups.
+	c.Check(readFile(c, filepath.Join(self, "daemon", "cgroup.procs")), Equals, strconv.Itoa(os.Getpid()))
+	c.Check(readFile(c, filepath.Join(self, "cgroup.subtree_control")), Equals, "+cpu +memory +pids")
+	c.Check(readFile(c, filepath.Join(self, "services", "cgroup.subtree_control")), Equals, "+cpu +memory +pids")
+
+	// Service limits override workload limits.
+	group := filepath.Join(self, "services", "limited")
+	c.Check(readFile(c, filepath.Join(group, "memory.max")), Equals, "67108864")
+	c.Check(readFile(c, filepath.Join(group, "cpu.max")), Equals, "50000 100000")
+	c.Check(readFile(c, filepath.Join(group, "pids.max")), Equals, "50")
+
+	pid := readFile(c, filepath.Join(group, "cgroup.procs"))
+	c.Check(pid, Not(Equals), "")
+	c.Check(pid, Not(Equals), strconv.Itoa(os.Getpid()))
+}
+
+func (s *S) TestNoResourceLimits(c *C) {
+	self := s.fakeCgroupFS(c)
+	s.newServiceManager(c)
+	s.planAddLayer(c, resourcesPlanLayer)
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"unlimited"}})
+	s.st.Lock()
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	// Every service gets its own cgroup, even without limits.
+	c.Check(readFile(c, filepath.Join(self, "daemon", "cgroup.procs")), Equals, strconv.Itoa(os.Getpid()))
+	group := filepath.Join(self, "services", "unlimited")
+	pid := readFile(c, filepath.Join(group, "cgroup.procs"))
+	c.Check(pid, Not(Equals), "")
+	c.Check(pid, Not(Equals), strconv.Itoa(os.Getpid()))
+}
+
+func (s *S) TestCloneIntoCgroup(c *C) {
+	if !cgroup.CanCloneInto() {
+		c.Skip("kernel can't create processes directly in a cgroup")
+	}
+	s.fakeCgroupFS(c)
+	s.AddCleanup(cgroup.FakeCloneInto(true))
+	s.newServiceManager(c)
+	s.planAddLayer(c, resourcesPlanLayer)
+	s.planChanged(c)
+
+	// The service is created in its cgroup rather than moved there after it
+	// starts, which the kernel refuses as the fake group isn't a cgroup.
+	chg := s.startServices(c, [][]string{{"unlimited"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	s.st.Unlock()
+	c.Check(s.serviceByName(c, "unlimited").Current, Not(Equals), servstate.StatusActive)
+}
+
+func (s *S) TestResourceLimitsUnavailable(c *C) {
+	// Cgroups aren't available, so the service runs without its limits and
+	// a warning is added.
+	s.newServiceManager(c)
+	s.planAddLayer(c, resourcesPlanLayer)
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"limited"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+	c.Check(s.serviceByName(c, "limited").Current, Equals, servstate.StatusActive)
+
+	// The warning is added asynchronously.
+	filter := &state.NoticeFilter{
+		Types: []state.NoticeType{state.WarningNotice},
+		Keys:  []string{`Cannot apply resource limits to service "limited": cgroup v2 not available`},
+	}
+	var notices []*state.Notice
+	for i := 0; i < 100 && len(notices) == 0; i++ {
+		s.st.Lock()
+		notices = s.st.Notices(filter)
+		s.st.Unlock()
+		if len(notices) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	c.Check(notices, HasLen, 1)
+}
+
+func (s *S) TestResourceMetrics(c *C) {
+	self := s.fakeCgroupFS(c)
+	s.newServiceManager(c)
+	s.planAddLayer(c, resourcesPlanLayer)
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"limited"}})
+	s.st.Lock()
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	group := filepath.Join(self, "services", "limited")
+	writeFile(c, filepath.Join(group, "memory.current"), "1048576\n")
+	writeFile(c, filepath.Join(group, "cpu.stat"), "usage_usec 2500\nuser_usec 2000\n")
+	writeFile(c, filepath.Join(group, "pids.current"), "2\n")
+
+	buf := new(bytes.Buffer)
+	writer := metrics.NewOpenTelemetryWriter(buf)
+	err := s.manager.WriteMetrics(writer)
+	c.Assert(err, IsNil)
+	c.Check(strings.Contains(buf.String(), `
+# HELP pebble_service_memory_current_bytes Memory currently used by the service's processes
+# TYPE pebble_service_memory_current_bytes gauge
+pebble_service_memory_current_bytes{service="limited"} 1048576
+
+# HELP pebble_service_pids_current Number of processes and threads in the service
+# TYPE pebble_service_pids_current gauge
+pebble_service_pids_current{service="limited"} 2
+`), Equals, true, Commentf("%s", buf.String()))
+}
+
+func readFile(c *C, path string) string {
+	data, err := os.ReadFile(path)
+	c.Assert(err, IsNil)
+	return string(data)
+}
+
+func writeFile(c *C, path, content string) {
+	err := os.WriteFile(path, []byte(content), 0o644)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestKillModeCgroupUnavailable(c *C) {
+	// Cgroups aren't available, so kill-mode "cgroup" falls back to "group"
+	// and a warning is added.
+	s.newServiceManager(c)
+	s.planAddLayer(c, `
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        kill-mode: cgroup
+`)
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"test1"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	filter := &state.NoticeFilter{
+		Types: []state.NoticeType{state.WarningNotice},
+		Keys:  []string{`Service "test1" is not in its own cgroup, using kill-mode "group" instead of "cgroup"`},
+	}
+	var notices []*state.Notice
+	for i := 0; i < 100 && len(notices) == 0; i++ {
+		s.st.Lock()
+		notices = s.st.Notices(filter)
+		s.st.Unlock()
+		if len(notices) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	c.Check(notices, HasLen, 1)
+}
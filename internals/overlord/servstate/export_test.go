@@ -15,6 +15,7 @@
 package servstate
 
 import (
+	"fmt"
 	"os/exec"
 	"syscall"
 	"time"
@@ -100,3 +101,24 @@ func FakeTimeNow(t time.Time) (restore func()) {
 		timeNow = old
 	}
 }
+
+// FireSchedule runs the named service's schedule as if its timer elapsed.
+func (m *ServiceManager) FireSchedule(name string) error {
+	m.servicesLock.Lock()
+	sched := m.schedules[name]
+	m.servicesLock.Unlock()
+	if sched == nil {
+		return fmt.Errorf("service %q is not scheduled", name)
+	}
+	return m.scheduleElapsed(sched)
+}
+
+// FakeProcRoot changes the directory the service manager reads process
+// information from, in place of /proc.
+func FakeProcRoot(root string) (restore func()) {
+	old := procRoot
+	procRoot = root
+	return func() {
+		procRoot = old
+	}
+}
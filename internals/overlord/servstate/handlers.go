@@ -8,7 +8,9 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"slices"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"golang.org/x/sys/unix"
 	"gopkg.in/tomb.v2"
 
+	"github.com/canonical/pebble/internals/cgroup"
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/metrics"
 	"github.com/canonical/pebble/internals/osutil"
@@ -70,6 +73,24 @@ var (
 	// failDelay is the duration given to services for shutting down when Pebble
 	// sends a SIGKILL signal.
 	failDelay = 5 * time.Second
+
+	// afterReadyTimeoutDefault is how long to wait for the checks in a
+	// service's after-ready list if the service hasn't specified its own
+	// timeout.
+	afterReadyTimeoutDefault = 5 * time.Minute
+
+	// checkReadyInterval is how often to poll the checks in a service's
+	// after-ready list.
+	checkReadyInterval = 100 * time.Millisecond
+
+	// startDurationBuckets are the bucket upper bounds, in seconds, of the
+	// service start duration histogram. Daemons take at least the okay delay
+	// to start.
+	startDurationBuckets = []float64{0.1, 0.5, 1, 1.5, 2, 5, 10, 30, 60, 120, 300}
+
+	// remainingCheckInterval is how often to check whether a stopping
+	// service's remaining processes have exited after its main process has.
+	remainingCheckInterval = 50 * time.Millisecond
 )
 
 const (
@@ -92,24 +113,28 @@ const (
 	stateStopped     serviceState = "stopped"
 	stateBackoff     serviceState = "backoff"
 	stateExited      serviceState = "exited"
+	stateExitedOK    serviceState = "exited-ok"
 )
 
 // serviceData holds the state and other data for a service under our control.
 type serviceData struct {
-	manager      *ServiceManager
-	state        serviceState
-	config       *plan.Service
-	workload     *workloads.Workload
-	logs         *servicelog.RingBuffer
-	started      chan error
-	stopped      chan error
-	cmd          *exec.Cmd
-	backoffNum   int
-	backoffTime  time.Duration
-	resetTimer   *time.Timer
-	restarting   bool
-	currentSince time.Time
-	startCount   atomic.Int64
+	manager        *ServiceManager
+	state          serviceState
+	config         *plan.Service
+	workload       *workloads.Workload
+	logs           *servicelog.RingBuffer
+	logFile        *servicelog.File
+	started        chan error
+	stopped        chan error
+	cmd            *exec.Cmd
+	cgroup         *cgroup.Group
+	backoffNum     int
+	backoffTime    time.Duration
+	resetTimer     *time.Timer
+	restarting     bool
+	currentSince   time.Time
+	startCount     atomic.Int64
+	startDurations *metrics.Histogram
 }
 
 func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
@@ -143,6 +168,15 @@ func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
 		}
 	}
 
+	// Wait for any checks the service is ordered after to be up, unless
+	// it's already running.
+	if len(config.AfterReady) > 0 && !m.serviceActive(config.Name) {
+		err := m.waitChecksReady(task, tomb, config)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create the service object (or reuse the existing one by name).
 	service, taskLog := m.serviceForStart(config, workload)
 	if taskLog != "" {
@@ -157,13 +191,15 @@ func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
 	m.state.Unlock()
 
 	// Start the service and transition to stateStarting.
+	startTime := time.Now()
 	err = service.start()
 	if err != nil {
 		return err
 	}
 
 	// Wait for a small amount of time, and if the service hasn't exited,
-	// consider it a success.
+	// consider it a success. Tasks are only considered a success when they
+	// run to completion.
 	select {
 	case err := <-service.started:
 		if err != nil {
@@ -172,7 +208,9 @@ func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
 			// and the logs are still accessible for failed services if the action is ignore.
 			return fmt.Errorf("service start attempt: %w", err)
 		}
-		// Started successfully (ran for small amount of time without exiting).
+		// Started successfully (ran for small amount of time without exiting,
+		// or completed if it's a task).
+		service.startDurations.Observe(time.Since(startTime).Seconds())
 		return nil
 	case <-tomb.Dying():
 		// User tried to abort the start, sending SIGKILL to process is about
@@ -180,7 +218,7 @@ func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
 		m.servicesLock.Lock()
 		defer m.servicesLock.Unlock()
 		service.transition(stateStopped)
-		err := syscall.Kill(-service.cmd.Process.Pid, syscall.SIGKILL)
+		err := service.signal(syscall.SIGKILL)
 		if err != nil {
 			return fmt.Errorf("start aborted, but cannot send SIGKILL to process: %v", err)
 		}
@@ -188,6 +226,61 @@ func (m *ServiceManager) doStart(task *state.Task, tomb *tomb.Tomb) error {
 	}
 }
 
+// serviceActive reports whether the named service is starting or running.
+func (m *ServiceManager) serviceActive(name string) bool {
+	m.servicesLock.Lock()
+	defer m.servicesLock.Unlock()
+
+	service := m.services[name]
+	return service != nil && stateToStatus(service.state) == StatusActive
+}
+
+// waitChecksReady waits for the health checks in the service's after-ready
+// list to be up, logging the wait to the task.
+func (m *ServiceManager) waitChecksReady(task *state.Task, tomb *tomb.Tomb, config *plan.Service) error {
+	if m.checkReady == nil {
+		return fmt.Errorf("cannot wait for checks: health checks not available")
+	}
+	timeout := afterReadyTimeoutDefault
+	if config.AfterReadyTimeout.IsSet {
+		timeout = config.AfterReadyTimeout.Value
+	}
+
+	pending := append([]string(nil), config.AfterReady...)
+	addTaskLog(task, fmt.Sprintf("Waiting up to %s for %s to be up.", timeout, checksDesc(pending)))
+	start := time.Now()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(checkReadyInterval)
+	defer ticker.Stop()
+	for {
+		pending = slices.DeleteFunc(pending, m.checkReady)
+		if len(pending) == 0 {
+			addTaskLog(task, fmt.Sprintf("Checks up after %s.", time.Since(start).Round(time.Millisecond)))
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return fmt.Errorf("timed out after %s waiting for %s to be up", timeout, checksDesc(pending))
+		case <-tomb.Dying():
+			return fmt.Errorf("start aborted while waiting for %s to be up", checksDesc(pending))
+		}
+	}
+}
+
+// checksDesc returns a human-readable description of the named checks.
+func checksDesc(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	if len(names) == 1 {
+		return "check " + quoted[0]
+	}
+	return "checks " + strings.Join(quoted, ", ")
+}
+
 // serviceForStart looks up the service by name in the services map; it
 // creates a new service object if one doesn't exist, returns the existing one
 // if it already exists but is stopped, or returns nil if it already exists
@@ -202,11 +295,12 @@ func (m *ServiceManager) serviceForStart(config *plan.Service, workload *workloa
 	if service == nil {
 		// Not already started, create a new service object.
 		service = &serviceData{
-			manager: m,
-			state:   stateInitial,
-			logs:    servicelog.NewRingBuffer(maxLogBytes),
-			started: make(chan error, 1),
-			stopped: make(chan error, 2), // enough for killTimeElapsed to send, and exit if it happens after
+			manager:        m,
+			state:          stateInitial,
+			logs:           servicelog.NewRingBuffer(maxLogBytes),
+			started:        make(chan error, 1),
+			stopped:        make(chan error, 2), // enough for killTimeElapsed to send, and exit if it happens after
+			startDurations: metrics.NewHistogram(startDurationBuckets),
 		}
 		service.config = config.Copy()
 		if workload != nil {
@@ -225,6 +319,10 @@ func (m *ServiceManager) serviceForStart(config *plan.Service, workload *workloa
 	switch service.state {
 	case stateInitial, stateStarting, stateRunning:
 		return nil, fmt.Sprintf("Service %q already started.", config.Name)
+	case stateExitedOK:
+		// A completed task satisfies the services that depend on it; it only
+		// runs again when restarted.
+		return nil, fmt.Sprintf("Service %q already completed.", config.Name)
 	case stateBackoff, stateStopped, stateExited:
 		// Start allowed when service is backing off, was stopped, or has exited.
 		service.backoffNum = 0
@@ -307,6 +405,9 @@ func (m *ServiceManager) serviceForStop(name string) (service *serviceData, task
 	case stateExited:
 		service.transition(stateStopped)
 		return nil, fmt.Sprintf("Service %q had already exited.", name)
+	case stateExitedOK:
+		service.transition(stateStopped)
+		return nil, fmt.Sprintf("Service %q had already completed.", name)
 	default:
 		return service, ""
 	}
@@ -323,12 +424,15 @@ func (s *serviceData) transitionRestarting(state serviceState, restarting bool)
 	// Update current-since time if derived status is changing.
 	oldStatus := stateToStatus(s.state)
 	newStatus := stateToStatus(state)
+	s.state = state
+	s.restarting = restarting
+
 	if oldStatus != newStatus {
 		s.currentSince = time.Now()
+		for _, f := range s.manager.statusHandlers {
+			f(s.config.Name, newStatus)
+		}
 	}
-
-	s.state = state
-	s.restarting = restarting
 }
 
 // start is called to transition from the initial state and start the service.
@@ -344,7 +448,10 @@ func (s *serviceData) start() error {
 			return err
 		}
 		s.transition(stateStarting)
-		time.AfterFunc(okayDelay, func() { logError(s.okayWaitElapsed()) })
+		if s.config.Type != plan.TypeTask {
+			// Tasks remain starting until they exit.
+			time.AfterFunc(okayDelay, func() { logError(s.okayWaitElapsed()) })
+		}
 
 	default:
 		return fmt.Errorf("cannot start service while %s", s.state)
@@ -448,7 +555,24 @@ func (s *serviceData) startInternal() error {
 		outputIterator = s.logs.HeadIterator(0)
 	}
 	serviceName := s.config.Name
-	logWriter := servicelog.NewFormatWriter(s.logs, serviceName)
+	var logDest io.Writer = s.logs
+	s.logFile = nil
+	if s.config.LogFile != nil {
+		logFile, err := servicelog.OpenFile(s.config.LogFile.Path, s.logs, servicelog.FileOptions{
+			MaxSize:  s.config.LogFile.MaxSizeBytes(),
+			MaxAge:   s.config.LogFile.MaxAge.Value,
+			Compress: s.config.LogFile.Compress != nil && *s.config.LogFile.Compress,
+			Retain:   s.config.LogFile.RetainCount(),
+		})
+		if err != nil {
+			// Output is still written to the log buffer.
+			logger.Noticef("Cannot open log file for service %q: %v", serviceName, err)
+		} else {
+			s.logFile = logFile
+			logDest = logFile
+		}
+	}
+	logWriter := servicelog.NewFormatWriter(logDest, serviceName)
 	s.cmd.Stdout = logWriter
 	s.cmd.Stderr = logWriter
 
@@ -467,21 +591,32 @@ func (s *serviceData) startInternal() error {
 	// process that has already exited).
 	s.cmd.WaitDelay = s.killDelay() * 9 / 10 // will only overflow if kill-delay is 32 years!
 
+	// Limits are applied on every start, so that plan changes take effect.
+	s.cgroup = s.manager.serviceCgroup(s.config, s.workload)
+
 	// Start the process!
 	logger.Noticef("Service %q starting: %s", serviceName, s.config.Command)
-	err = reaper.StartCommand(s.cmd)
+	err = s.startCommand()
 	if err != nil {
 		if outputIterator != nil {
 			_ = outputIterator.Close()
 		}
+		if s.logFile != nil {
+			_ = s.logFile.Close()
+		}
 		return fmt.Errorf("cannot start service: %w", err)
 	}
 	logger.Debugf("Service %q started with PID %d", serviceName, s.cmd.Process.Pid)
+	if s.cgroup == nil && s.config.KillMode == plan.KillModeCgroup {
+		s.manager.warnAsync("Service %q is not in its own cgroup, using kill-mode %q instead of %q",
+			serviceName, plan.KillModeGroup, plan.KillModeCgroup)
+	}
 	s.resetTimer = time.AfterFunc(s.config.BackoffLimit.Value, func() { logError(s.backoffResetElapsed()) })
 
 	// Start a goroutine to wait for the process to finish.
 	done := make(chan struct{})
 	cmd := s.cmd
+	logFile := s.logFile
 	go func() {
 		exitCode, waitErr := reaper.WaitCommand(cmd)
 		if waitErr != nil {
@@ -489,6 +624,13 @@ func (s *serviceData) startInternal() error {
 		} else {
 			logger.Debugf("Service %q exited with code %d.", serviceName, exitCode)
 		}
+		if logFile != nil {
+			// The output has been copied by the time WaitCommand returns.
+			err := logFile.Close()
+			if err != nil {
+				logger.Noticef("Cannot close log file for service %q: %v", serviceName, err)
+			}
+		}
 		close(done)
 		err := s.exited(exitCode)
 		if err != nil {
@@ -512,6 +654,9 @@ func (s *serviceData) startInternal() error {
 	// Pass buffer reference to logMgr to start log forwarding
 	s.manager.logMgr.ServiceStarted(s.config, s.logs)
 	s.startCount.Add(1)
+	if sched := s.manager.schedules[serviceName]; sched != nil {
+		sched.runStarted = timeNow()
+	}
 	return nil
 }
 
@@ -542,21 +687,48 @@ func (s *serviceData) exited(exitCode int) error {
 		s.resetTimer.Stop()
 	}
 
+	sched := s.manager.schedules[s.config.Name]
+
+	isTask := s.config.Type == plan.TypeTask
+
 	switch s.state {
 	case stateStarting:
-		// Send error to select waiting in doStart, then fall through to perform action.
-		action, _ := getAction(s.config, exitCode == 0)
-		s.started <- fmt.Errorf("exited quickly with code %d, will %s", exitCode, action)
+		switch {
+		case exitCode == 0 && (isTask || sched != nil):
+			// A task or scheduled run that finishes has simply done its
+			// work, however quickly.
+			s.started <- nil
+		case isTask:
+			action, _ := getAction(s.config, false)
+			s.started <- fmt.Errorf("exited with code %d, will %s", exitCode, action)
+		default:
+			// Send error to select waiting in doStart, then fall through to perform action.
+			action, _ := getAction(s.config, exitCode == 0)
+			s.started <- fmt.Errorf("exited quickly with code %d, will %s", exitCode, action)
+		}
 		fallthrough
 
 	case stateRunning:
-		logger.Noticef("Service %q stopped unexpectedly with code %d", s.config.Name, exitCode)
+		switch {
+		case sched != nil:
+			logger.Noticef("Service %q scheduled run finished with code %d", s.config.Name, exitCode)
+			s.manager.scheduledRunExited(sched, exitCode)
+		case isTask:
+			logger.Noticef("Service %q exited with code %d", s.config.Name, exitCode)
+		default:
+			logger.Noticef("Service %q stopped unexpectedly with code %d", s.config.Name, exitCode)
+		}
 		action, onType := getAction(s.config, exitCode == 0)
 		switch action {
 		case plan.ActionIgnore:
 			logger.Noticef("Service %q %s action is %q, not doing anything further", s.config.Name, onType, action)
-			// On success we transition to state stopped.
+			// On success we transition to state stopped, or exited-ok for
+			// tasks, which have completed.
 			if exitCode == 0 {
+				if isTask {
+					s.transition(stateExitedOK)
+					break
+				}
 				s.transition(stateStopped)
 				break
 			}
@@ -592,14 +764,14 @@ func (s *serviceData) exited(exitCode int) error {
 		}
 
 	case stateTerminating, stateKilling:
-		if s.restarting {
-			logger.Noticef("Service %q exited after check failure, restarting", s.config.Name)
-			s.doBackoff(plan.ActionRestart, "on-check-failure")
-		} else {
-			logger.Noticef("Service %q stopped", s.config.Name)
-			s.stopped <- nil
-			s.transition(stateStopped)
+		if s.processesRemain() {
+			// Wait for the rest of the service's processes to exit (or for
+			// the kill timers to give up on them).
+			logger.Debugf("Service %q main process exited, waiting for its remaining processes", s.config.Name)
+			time.AfterFunc(remainingCheckInterval, func() { logError(s.remainingCheckElapsed()) })
+			break
 		}
+		s.stopFinished()
 
 	case stateStopped:
 		// This can happen when we send SIGKILL after the doStart tomb is killed, ignore.
@@ -672,7 +844,16 @@ func getAction(config *plan.Service, success bool) (action plan.ServiceAction, o
 		onType = "on-failure"
 	}
 	if action == plan.ActionUnset {
-		action = plan.ActionRestart // default for "on-success" and "on-failure"
+		switch {
+		case config.Schedule != "":
+			// Scheduled services run again at their next window.
+			action = plan.ActionIgnore
+		case config.Type == plan.TypeTask && success:
+			// A task that succeeded has done its job.
+			action = plan.ActionIgnore
+		default:
+			action = plan.ActionRestart // default for "on-success" and "on-failure"
+		}
 	}
 	return action, onType
 }
@@ -692,7 +873,7 @@ func (s *serviceData) sendSignal(signal string) error {
 			return err
 		}
 
-	case stateBackoff, stateTerminating, stateKilling, stateStopped, stateExited:
+	case stateBackoff, stateTerminating, stateKilling, stateStopped, stateExited, stateExitedOK:
 		return fmt.Errorf("service is not running")
 
 	default:
@@ -701,6 +882,56 @@ func (s *serviceData) sendSignal(signal string) error {
 	return nil
 }
 
+// killMode returns the service's effective kill mode: "cgroup" falls back
+// to "group" if the service isn't in its own cgroup.
+func (s *serviceData) killMode() plan.KillMode {
+	switch s.config.KillMode {
+	case plan.KillModeProcess:
+		return plan.KillModeProcess
+	case plan.KillModeCgroup:
+		if s.cgroup != nil {
+			return plan.KillModeCgroup
+		}
+	}
+	return plan.KillModeGroup
+}
+
+// signal sends the signal to the service's processes according to its kill
+// mode: only the main process, the main process's process group (the
+// default), or every process in the service's cgroup.
+func (s *serviceData) signal(sig syscall.Signal) error {
+	switch s.killMode() {
+	case plan.KillModeProcess:
+		return syscall.Kill(s.cmd.Process.Pid, sig)
+	case plan.KillModeCgroup:
+		return s.cgroup.Signal(sig)
+	default:
+		return syscall.Kill(-s.cmd.Process.Pid, sig)
+	}
+}
+
+// processesRemain reports whether any of the processes that signal reaches
+// are still running, once the service's main process has exited. Services
+// only wait for their remaining processes if kill-mode is set explicitly.
+func (s *serviceData) processesRemain() bool {
+	if s.config.KillMode == plan.KillModeUnset {
+		return false
+	}
+	switch s.killMode() {
+	case plan.KillModeProcess:
+		return false
+	case plan.KillModeCgroup:
+		populated, err := s.cgroup.Populated()
+		if err != nil {
+			logger.Noticef("Cannot check for remaining processes of service %q: %v", s.config.Name, err)
+			return false
+		}
+		return populated
+	default:
+		return reaper.GroupRunning(s.cmd.Process.Pid)
+	}
+}
+
 // killDelay reports the duration that this service should be given when being
 // asked to shut down gracefully before being force-terminated. The value
 // returned will either be the service's pre-configured value, or the default
@@ -719,13 +950,17 @@ func (s *serviceData) stop() error {
 
 	switch s.state {
 	case stateStarting:
-		s.started <- fmt.Errorf("stopped before the %s okay delay", okayDelay)
+		if s.config.Type == plan.TypeTask {
+			s.started <- fmt.Errorf("stopped before completing")
+		} else {
+			s.started <- fmt.Errorf("stopped before the %s okay delay", okayDelay)
+		}
 		fallthrough
 
 	case stateRunning:
 		logger.Debugf("Attempting to stop service %q by sending SIGTERM", s.config.Name)
 		// First send SIGTERM to try to terminate it gracefully.
-		err := syscall.Kill(-s.cmd.Process.Pid, syscall.SIGTERM)
+		err := s.signal(syscall.SIGTERM)
 		if err != nil {
 			logger.Noticef("Cannot send SIGTERM to process: %v", err)
 		}
@@ -743,6 +978,41 @@ func (s *serviceData) stop() error {
 	return nil
 }
 
+// stopFinished is called when all of a terminating service's processes have
+// exited.
+func (s *serviceData) stopFinished() {
+	if s.restarting {
+		logger.Noticef("Service %q exited after check failure, restarting", s.config.Name)
+		s.doBackoff(plan.ActionRestart, "on-check-failure")
+	} else {
+		logger.Noticef("Service %q stopped", s.config.Name)
+		s.stopped <- nil
+		s.transition(stateStopped)
+	}
+}
+
+// remainingCheckElapsed is called periodically after a terminating service's
+// main process has exited, until its remaining processes have also exited.
+func (s *serviceData) remainingCheckElapsed() error {
+	s.manager.servicesLock.Lock()
+	defer s.manager.servicesLock.Unlock()
+
+	switch s.state {
+	case stateTerminating, stateKilling:
+		if s.processesRemain() {
+			time.AfterFunc(remainingCheckInterval, func() { logError(s.remainingCheckElapsed()) })
+			return nil
+		}
+		s.stopFinished()
+
+	default:
+		// Ignore if timer elapsed in any other state (for example, if
+		// killTimeElapsed gave up on the processes).
+		return nil
+	}
+	return nil
+}
+
 // backoffTimeElapsed is called when the current backoff's timer has elapsed,
 // to restart the service.
 func (s *serviceData) backoffTimeElapsed() error {
@@ -774,7 +1044,7 @@ func (s *serviceData) terminateTimeElapsed() error {
 	case stateTerminating:
 		logger.Debugf("Attempting to stop service %q again by sending SIGKILL", s.config.Name)
 		// Process hasn't exited after SIGTERM, try SIGKILL.
-		err := syscall.Kill(-s.cmd.Process.Pid, syscall.SIGKILL)
+		err := s.signal(syscall.SIGKILL)
 		if err != nil {
 			logger.Noticef("Cannot send SIGKILL to process: %v", err)
 		}
@@ -856,7 +1126,7 @@ func (s *serviceData) checkFailed(action plan.ServiceAction) {
 			case stateRunning:
 				logger.Noticef("Service %q %s action is %q, terminating process before restarting",
 					s.config.Name, onType, action)
-				err := syscall.Kill(-s.cmd.Process.Pid, syscall.SIGTERM)
+				err := s.signal(syscall.SIGTERM)
 				if err != nil {
 					logger.Noticef("Cannot send SIGTERM to process: %v", err)
 				}
@@ -882,7 +1152,7 @@ func (s *serviceData) checkFailed(action plan.ServiceAction) {
 }
 
 // writeMetric writes the service's metrics.
-func (d *serviceData) writeMetric(writer metrics.Writer) error {
+func (d *serviceData) writeMetric(writer metrics.Writer, procs map[int]*procStat) error {
 	active := 0
 	if stateToStatus(d.state) == StatusActive {
 		active = 1
@@ -909,9 +1179,96 @@ func (d *serviceData) writeMetric(writer metrics.Writer) error {
 		return err
 	}
 
+	err = writer.Write(d.startDurations.Metric(
+		"pebble_service_start_duration_seconds",
+		"Time taken for the service to start, from starting its process until it is running",
+		metrics.NewLabel("service", d.config.Name),
+	))
+	if err != nil {
+		return err
+	}
+
+	if usage := d.usage(procs); usage != nil {
+		err = d.writeUsageMetrics(writer, usage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.cgroup == nil {
+		return nil
+	}
+	stats, err := d.cgroup.Stats()
+	if err != nil {
+		logger.Debugf("Cannot read cgroup statistics for service %q: %v", d.config.Name, err)
+		return nil
+	}
+	err = writer.Write(metrics.Metric{
+		Name:       "pebble_service_memory_current_bytes",
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: stats.MemoryCurrent,
+		Comment:    "Memory currently used by the service's processes",
+		Labels:     []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Write(metrics.Metric{
+		Name:       "pebble_service_pids_current",
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: stats.PidsCurrent,
+		Comment:    "Number of processes and threads in the service",
+		Labels:     []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// writeUsageMetrics writes the metrics for the resources used by the
+// service's process tree.
+func (d *serviceData) writeUsageMetrics(writer metrics.Writer, usage *ServiceUsage) error {
+	err := writer.Write(metrics.Metric{
+		Name:         "pebble_service_cpu_seconds",
+		Type:         metrics.TypeGaugeFloat,
+		ValueFloat64: usage.CPUTime.Seconds(),
+		Comment:      "CPU time used by the service's running processes",
+		Labels:       []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Write(metrics.Metric{
+		Name:       "pebble_service_rss_bytes",
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: usage.RSS,
+		Comment:    "Resident set size of the service's processes",
+		Labels:     []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Write(metrics.Metric{
+		Name:       "pebble_service_open_fds",
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: int64(usage.OpenFDs),
+		Comment:    "Number of file descriptors open in the service's processes",
+		Labels:     []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Write(metrics.Metric{
+		Name:       "pebble_service_threads",
+		Type:       metrics.TypeGaugeInt,
+		ValueInt64: int64(usage.Threads),
+		Comment:    "Number of threads in the service's processes",
+		Labels:     []metrics.Label{metrics.NewLabel("service", d.config.Name)},
+	})
+}
+
 var setCmdCredential = func(cmd *exec.Cmd, credential *syscall.Credential) {
 	cmd.SysProcAttr.Credential = credential
 }
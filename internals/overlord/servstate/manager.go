@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/canonical/pebble/internals/cgroup"
+	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/metrics"
 	"github.com/canonical/pebble/internals/overlord/restart"
 	"github.com/canonical/pebble/internals/overlord/state"
@@ -29,6 +31,7 @@ type ServiceManager struct {
 
 	servicesLock sync.Mutex
 	services     map[string]*serviceData
+	schedules    map[string]*scheduleData
 
 	serviceOutput io.Writer
 	restarter     Restarter
@@ -37,8 +40,25 @@ type ServiceManager struct {
 	rand     *rand.Rand
 
 	logMgr LogManager
+
+	checkReady     CheckReadyFunc
+	statusHandlers []ServiceStatusFunc
+
+	// Set up on first use and protected by the services lock.
+	cgroupSetUp bool
+	cgroup      *cgroup.Group
+	cgroupErr   error
 }
 
+// CheckReadyFunc is the type of function used to determine whether the named
+// health check is up and passing.
+type CheckReadyFunc func(name string) bool
+
+// ServiceStatusFunc is the type of function called when the status of a
+// service changes. It's called with the services lock held, so it must not
+// block or call back into the service manager.
+type ServiceStatusFunc func(name string, status ServiceStatus)
+
 type LogManager interface {
 	ServiceStarted(service *plan.Service, logs *servicelog.RingBuffer)
 }
@@ -51,6 +71,7 @@ func NewManager(s *state.State, runner *state.TaskRunner, serviceOutput io.Write
 	manager := &ServiceManager{
 		state:         s,
 		services:      make(map[string]*serviceData),
+		schedules:     make(map[string]*scheduleData),
 		serviceOutput: serviceOutput,
 		restarter:     restarter,
 		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
@@ -66,8 +87,11 @@ func NewManager(s *state.State, runner *state.TaskRunner, serviceOutput io.Write
 // PlanChanged informs the service manager that the plan has been updated.
 func (m *ServiceManager) PlanChanged(plan *plan.Plan) {
 	m.planLock.Lock()
-	defer m.planLock.Unlock()
 	m.plan = plan
+	m.planLock.Unlock()
+
+	m.updateSchedules(plan)
+	m.saveSchedules()
 }
 
 // getPlan returns the current plan pointer in a concurrency-safe way. The
@@ -91,11 +115,23 @@ func (m *ServiceManager) Ensure() error {
 	return nil
 }
 
+// Stop implements StateStopper. It disarms the timers of scheduled services;
+// running services are stopped separately by the daemon.
+func (m *ServiceManager) Stop() {
+	m.servicesLock.Lock()
+	defer m.servicesLock.Unlock()
+
+	for _, sched := range m.schedules {
+		sched.timer.Stop()
+	}
+}
+
 type ServiceInfo struct {
 	Name         string
 	Startup      ServiceStartup
 	Current      ServiceStatus
 	CurrentSince time.Time
+	Schedule     *ScheduleInfo // nil if the service isn't scheduled
 }
 
 type ServiceStartup string
@@ -112,6 +148,7 @@ const (
 	StatusBackoff  ServiceStatus = "backoff"
 	StatusError    ServiceStatus = "error"
 	StatusInactive ServiceStatus = "inactive"
+	StatusExitedOK ServiceStatus = "exited-ok"
 )
 
 // Services returns the list of configured services and their status, sorted
@@ -144,6 +181,9 @@ func (m *ServiceManager) Services(names []string) ([]*ServiceInfo, error) {
 			info.Current = stateToStatus(s.state)
 			info.CurrentSince = s.currentSince
 		}
+		if sched, ok := m.schedules[name]; ok {
+			info.Schedule = scheduleDataToInfo(sched)
+		}
 		services = append(services, info)
 	}
 	sort.Slice(services, func(i, j int) bool {
@@ -184,6 +224,8 @@ func stateToStatus(state serviceState) ServiceStatus {
 		return StatusInactive
 	case stateBackoff:
 		return StatusBackoff
+	case stateExitedOK:
+		return StatusExitedOK
 	default: // stateInitial (should never happen) and stateExited
 		return StatusError
 	}
@@ -229,11 +271,22 @@ func (m *ServiceManager) StopOrder(services []string) ([][]string, error) {
 // ServiceLogs returns iterators to the provided services. If last is negative,
 // return tail iterators; if last is zero or positive, return head iterators
 // going back last elements. Each iterator must be closed via the Close method.
-func (m *ServiceManager) ServiceLogs(services []string, last int) (map[string]servicelog.Iterator, error) {
+//
+// If since is non-zero, the iterators start from the tail (so that the caller
+// can count the last elements after filtering out older logs). For services
+// with a log file, the iterators first read the logs in the file, including
+// the rotated files, if history is requested (last is negative or since is
+// non-zero); otherwise the logs of running services are read from their ring
+// buffers only. Services that haven't been started since the daemon started
+// have no ring buffer, so unless last is zero their logs are read from the
+// current file. Rotated files last written before since are skipped.
+func (m *ServiceManager) ServiceLogs(services []string, last int, since time.Time) (map[string]servicelog.Iterator, error) {
 	requested := make(map[string]bool, len(services))
 	for _, name := range services {
 		requested[name] = true
 	}
+	history := last < 0 || !since.IsZero()
+	currentPlan := m.getPlan()
 
 	m.servicesLock.Lock()
 	defer m.servicesLock.Unlock()
@@ -246,16 +299,44 @@ func (m *ServiceManager) ServiceLogs(services []string, last int) (map[string]se
 		if service == nil || service.logs == nil {
 			continue
 		}
-		if last >= 0 {
+		switch {
+		case history && service.logFile != nil:
+			iterators[name] = service.logFile.Iterator(true, since)
+		case last >= 0 && since.IsZero():
 			iterators[name] = service.logs.HeadIterator(last)
-		} else {
+		default:
 			iterators[name] = service.logs.TailIterator()
 		}
 	}
 
+	// Services that haven't been started since the daemon started may
+	// still have logs on disk.
+	if last != 0 {
+		for name, config := range currentPlan.Services {
+			if !requested[name] || iterators[name] != nil || config.LogFile == nil {
+				continue
+			}
+			iterators[name] = servicelog.FileIterator(config.LogFile.Path, history, since)
+		}
+	}
+
 	return iterators, nil
 }
 
+// ServiceLogBuffer returns the log buffer of the named service, or nil if
+// the service hasn't been started. The same buffer is used when a service
+// is restarted.
+func (m *ServiceManager) ServiceLogBuffer(name string) *servicelog.RingBuffer {
+	m.servicesLock.Lock()
+	defer m.servicesLock.Unlock()
+
+	service := m.services[name]
+	if service == nil {
+		return nil
+	}
+	return service.logs
+}
+
 // Replan returns a list of services in lanes to stop and services to start
 // because their plans had changed between when they started and this call.
 func (m *ServiceManager) Replan() ([][]string, [][]string, error) {
@@ -289,6 +370,10 @@ func (m *ServiceManager) Replan() ([][]string, [][]string, error) {
 
 	var start []string
 	for name, config := range currentPlan.Services {
+		if config.Schedule != "" {
+			// Scheduled services only run when their schedule says so.
+			continue
+		}
 		if needsRestart[name] || config.Startup == plan.StartupEnabled {
 			start = append(start, name)
 		}
@@ -351,8 +436,29 @@ func (m *ServiceManager) CheckFailed(name string) {
 	}
 }
 
+// SetCheckReadyFunc sets the function used to wait for the health checks in
+// a service's after-ready list. It must be called before any services are
+// started.
+func (m *ServiceManager) SetCheckReadyFunc(f CheckReadyFunc) {
+	m.checkReady = f
+}
+
+// NotifyServiceStatusChanged adds f to the list of functions that are called
+// whenever the status of a service changes. It must be called before any
+// services are started.
+func (m *ServiceManager) NotifyServiceStatusChanged(f ServiceStatusFunc) {
+	m.statusHandlers = append(m.statusHandlers, f)
+}
+
 // WriteMetrics collects and writes metrics for all services to the provided writer.
 func (m *ServiceManager) WriteMetrics(writer metrics.Writer) error {
+	// Read /proc before taking the lock, as it can take a while on a busy
+	// system. Usage metrics are omitted if it can't be read.
+	procs, err := readProcs()
+	if err != nil {
+		logger.Debugf("Cannot read processes for service metrics: %v", err)
+	}
+
 	m.servicesLock.Lock()
 	defer m.servicesLock.Unlock()
 
@@ -364,7 +470,7 @@ func (m *ServiceManager) WriteMetrics(writer metrics.Writer) error {
 
 	for _, name := range names {
 		service := m.services[name]
-		err := service.writeMetric(writer)
+		err := service.writeMetric(writer, procs)
 		if err != nil {
 			return err
 		}
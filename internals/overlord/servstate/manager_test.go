@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
@@ -34,8 +35,10 @@ import (
 	"golang.org/x/sys/unix"
 	. "gopkg.in/check.v1"
 
+	"github.com/canonical/pebble/internals/cgroup"
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/osutil"
 	"github.com/canonical/pebble/internals/overlord/checkstate"
 	"github.com/canonical/pebble/internals/overlord/restart"
 	"github.com/canonical/pebble/internals/overlord/servstate"
@@ -165,6 +168,12 @@ func (s *S) SetUpTest(c *C) {
 	s.AddCleanup(restore)
 	restore = func() { plan.UnregisterSectionExtension(workloads.WorkloadsField) }
 	s.AddCleanup(restore)
+	// Don't touch the real cgroup filesystem.
+	restore = cgroup.FakePaths(filepath.Join(s.dir, "no-cgroup"), filepath.Join(s.dir, "no-self-cgroup"))
+	s.AddCleanup(restore)
+	// Process usage is tested with a fake /proc.
+	restore = servstate.FakeProcRoot(filepath.Join(s.dir, "no-proc"))
+	s.AddCleanup(restore)
 
 	s.plan = plan.NewPlan()
 	s.planPropagated = false
@@ -174,6 +183,7 @@ func (s *S) SetUpTest(c *C) {
 func (s *S) TearDownTest(c *C) {
 	// Not all tests starts a service manager
 	if s.manager != nil {
+		s.manager.Stop()
 		if s.planPropagated {
 			// Only stop if PlanChanged was ever called on the
 			// service manager.
@@ -388,6 +398,91 @@ services:
 	})
 }
 
+func (s *S) TestKillModeGroupWaitsForDescendants(c *C) {
+	s.newServiceManager(c)
+	donePath := filepath.Join(c.MkDir(), "done")
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "sh -c 'trap \"\" TERM; sleep 0.5; touch %s' >/dev/null 2>&1 & sleep 10"
+        kill-delay: 5s
+        kill-mode: group
+`, donePath))
+	s.planChanged(c)
+
+	s.startServices(c, [][]string{{"test1"}})
+	s.waitUntilService(c, "test1", func(service *servstate.ServiceInfo) bool {
+		return service.Current == servstate.StatusActive
+	})
+
+	// The main process exits on SIGTERM, but the service isn't stopped until
+	// the descendant that ignores SIGTERM has exited too.
+	chg := s.stopServices(c, [][]string{{"test1"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+	c.Check(donePath, testutil.FilePresent)
+	c.Check(s.serviceByName(c, "test1").Current, Equals, servstate.StatusInactive)
+}
+
+func (s *S) TestKillModeDefaultDoesNotWait(c *C) {
+	s.newServiceManager(c)
+	donePath := filepath.Join(c.MkDir(), "done")
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "sh -c 'trap \"\" TERM; sleep 0.5; touch %s' >/dev/null 2>&1 & sleep 10"
+        kill-delay: 5s
+`, donePath))
+	s.planChanged(c)
+
+	s.startServices(c, [][]string{{"test1"}})
+	s.waitUntilService(c, "test1", func(service *servstate.ServiceInfo) bool {
+		return service.Current == servstate.StatusActive
+	})
+
+	// The process group is signalled, but without an explicit kill-mode the
+	// service is stopped as soon as its main process has exited.
+	chg := s.stopServices(c, [][]string{{"test1"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+	c.Check(donePath, testutil.FileAbsent)
+	c.Check(s.serviceByName(c, "test1").Current, Equals, servstate.StatusInactive)
+}
+
+func (s *S) TestKillModeProcess(c *C) {
+	s.newServiceManager(c)
+	pidPath := filepath.Join(c.MkDir(), "pid")
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "sleep 10 >/dev/null 2>&1 & echo $! > %s; wait"
+        kill-mode: process
+`, pidPath))
+	s.planChanged(c)
+
+	s.startServices(c, [][]string{{"test1"}})
+	s.waitUntilService(c, "test1", func(service *servstate.ServiceInfo) bool {
+		return service.Current == servstate.StatusActive
+	})
+	data, err := os.ReadFile(pidPath)
+	c.Assert(err, IsNil)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	c.Assert(err, IsNil)
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	// Only the main process is signalled, so its child keeps running.
+	chg := s.stopServices(c, [][]string{{"test1"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+	c.Check(syscall.Kill(pid, 0), IsNil)
+}
+
 func (s *S) TestReplanServices(c *C) {
 	s.newServiceManager(c)
 	s.planAddLayer(c, testPlanLayer)
@@ -562,6 +657,95 @@ func (s *S) TestServiceLogs(c *C) {
 	s.testServiceLogs(c, outputs)
 }
 
+func (s *S) TestServiceLogBuffer(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, testPlanLayer)
+	s.planChanged(c)
+
+	c.Check(s.manager.ServiceLogBuffer("test1"), IsNil)
+
+	s.startTestServices(c, true)
+	buffer := s.manager.ServiceLogBuffer("test1")
+	c.Assert(buffer, NotNil)
+	c.Check(s.manager.ServiceLogBuffer("test3"), IsNil)
+
+	// The buffer is kept when the service is restarted.
+	s.stopTestServices(c)
+	s.startTestServices(c, true)
+	c.Check(s.manager.ServiceLogBuffer("test1"), Equals, buffer)
+	s.stopTestServices(c)
+}
+
+func (s *S) TestNotifyServiceStatusChanged(c *C) {
+	s.newServiceManager(c)
+	var mu sync.Mutex
+	var changes []string
+	s.manager.NotifyServiceStatusChanged(func(name string, status servstate.ServiceStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, name+":"+string(status))
+	})
+	s.planAddLayer(c, testPlanLayer)
+	s.planChanged(c)
+
+	s.startServices(c, [][]string{{"test1"}})
+	s.stopServices(c, [][]string{{"test1"}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Check(changes, DeepEquals, []string{"test1:active", "test1:inactive"})
+}
+
+func (s *S) TestServiceLogsFile(c *C) {
+	s.newServiceManager(c)
+	logPath := filepath.Join(s.dir, "logs", "test1.log")
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "echo first; echo second; {{.NotifyDoneCheck}}; sleep 10"
+        log-file:
+            path: %s
+`, logPath))
+	s.planChanged(c)
+
+	s.startServices(c, [][]string{{"test1"}})
+	s.waitForDoneCheck(c, "test1")
+	s.stopServices(c, [][]string{{"test1"}})
+	c.Assert(logPath, testutil.FileMatches, `2.* \[test1\] first\n2.* \[test1\] second\n`)
+
+	readLogs := func(last int) string {
+		iterators, err := s.manager.ServiceLogs([]string{"test1"}, last, time.Time{})
+		c.Assert(err, IsNil)
+		c.Assert(iterators, HasLen, 1)
+		it := iterators["test1"]
+		defer it.Close()
+		buf := &bytes.Buffer{}
+		for it.Next(nil) {
+			_, err = io.Copy(buf, it)
+			c.Assert(err, IsNil)
+		}
+		return buf.String()
+	}
+	c.Check(readLogs(-1), Matches, `2.* \[test1\] first\n2.* \[test1\] second\n`)
+
+	// After a restart the logs are read from the file, even before the
+	// service is started.
+	s.newServiceManager(c)
+	s.planChanged(c)
+	c.Check(readLogs(-1), Matches, `2.* \[test1\] first\n2.* \[test1\] second\n`)
+
+	// Logs aren't repeated when the file and buffer have the same output.
+	s.startServices(c, [][]string{{"test1"}})
+	s.waitForDoneCheck(c, "test1")
+	s.stopServices(c, [][]string{{"test1"}})
+	c.Check(readLogs(-1), Matches, `(2.* \[test1\] first\n2.* \[test1\] second\n){2}`)
+
+	// Without history being requested, only the last logs in the buffer
+	// are read.
+	c.Check(readLogs(1), Matches, `2.* \[test1\] second\n`)
+}
+
 func (s *S) TestStartBadCommand(c *C) {
 	s.newServiceManager(c)
 	s.planAddLayer(c, testPlanLayer)
@@ -756,6 +940,148 @@ services:
 	c.Assert(svc.Current, Equals, servstate.StatusError)
 }
 
+func (s *S) TestTaskService(c *C) {
+	s.newServiceManager(c)
+	markerPath := filepath.Join(c.MkDir(), "migrated")
+	layer := fmt.Sprintf(`
+services:
+    migrate:
+        override: replace
+        type: task
+        command: /bin/sh -c "sleep %g; touch %s"
+
+    app:
+        override: replace
+        command: /bin/sh -c "test -f %s && sleep 10"
+        requires:
+            - migrate
+        after:
+            - migrate
+`, shortOkayDelay.Seconds()*3, markerPath, markerPath)
+	s.planAddLayer(c, layer)
+	s.planChanged(c)
+
+	lanes, err := s.manager.StartOrder([]string{"app"})
+	c.Assert(err, IsNil)
+	chg := s.startServices(c, lanes)
+
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	c.Check(s.serviceByName(c, "migrate").Current, Equals, servstate.StatusExitedOK)
+	c.Check(s.serviceByName(c, "app").Current, Equals, servstate.StatusActive)
+
+	// A completed task isn't run again when started.
+	err = os.Remove(markerPath)
+	c.Assert(err, IsNil)
+	chg = s.startServices(c, [][]string{{"migrate"}})
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Tasks()[0].Log(), HasLen, 1)
+	c.Check(chg.Tasks()[0].Log()[0], Matches, `.* INFO Service "migrate" already completed.`)
+	s.st.Unlock()
+	c.Check(osutil.CanStat(markerPath), Equals, false)
+
+	// Stopping it resets it, so it runs when started again.
+	s.stopServices(c, [][]string{{"migrate"}})
+	c.Check(s.serviceByName(c, "migrate").Current, Equals, servstate.StatusInactive)
+	s.startServices(c, [][]string{{"migrate"}})
+	c.Check(s.serviceByName(c, "migrate").Current, Equals, servstate.StatusExitedOK)
+	c.Check(osutil.CanStat(markerPath), Equals, true)
+}
+
+func (s *S) TestTaskServiceFailure(c *C) {
+	s.newServiceManager(c)
+	layer := `
+services:
+    migrate:
+        override: replace
+        type: task
+        command: /bin/sh -c "exit 3"
+        on-failure: ignore
+
+    app:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        requires:
+            - migrate
+        after:
+            - migrate
+`
+	s.planAddLayer(c, layer)
+	s.planChanged(c)
+
+	lanes, err := s.manager.StartOrder([]string{"app"})
+	c.Assert(err, IsNil)
+	chg := s.startServices(c, lanes)
+
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*\n- Start service "migrate" \(service start attempt: exited with code 3, will ignore\)`)
+	s.st.Unlock()
+
+	c.Check(s.serviceByName(c, "migrate").Current, Equals, servstate.StatusError)
+	c.Check(s.serviceByName(c, "app").Current, Equals, servstate.StatusInactive)
+}
+
+var afterReadyLayer = `
+services:
+    test1:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        after-ready:
+            - chk1
+        after-ready-timeout: %s
+
+checks:
+    chk1:
+        override: replace
+        exec:
+            command: "true"
+`
+
+func (s *S) TestAfterReady(c *C) {
+	s.newServiceManager(c)
+	var ready atomic.Bool
+	s.manager.SetCheckReadyFunc(func(name string) bool {
+		return name == "chk1" && ready.Load()
+	})
+	s.planAddLayer(c, fmt.Sprintf(afterReadyLayer, "10s"))
+	s.planChanged(c)
+
+	time.AfterFunc(200*time.Millisecond, func() { ready.Store(true) })
+	chg := s.startServices(c, [][]string{{"test1"}})
+
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	logs := chg.Tasks()[0].Log()
+	c.Assert(logs, HasLen, 2)
+	c.Check(logs[0], Matches, `.* INFO Waiting up to 10s for check "chk1" to be up.`)
+	c.Check(logs[1], Matches, `.* INFO Checks up after .*s.`)
+	s.st.Unlock()
+
+	c.Check(s.serviceByName(c, "test1").Current, Equals, servstate.StatusActive)
+}
+
+func (s *S) TestAfterReadyTimeout(c *C) {
+	s.newServiceManager(c)
+	s.manager.SetCheckReadyFunc(func(name string) bool {
+		return false
+	})
+	s.planAddLayer(c, fmt.Sprintf(afterReadyLayer, "100ms"))
+	s.planChanged(c)
+
+	chg := s.startServices(c, [][]string{{"test1"}})
+
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*\n- Start service "test1" \(timed out after 100ms waiting for check "chk1" to be up\)`)
+	s.st.Unlock()
+
+	c.Check(s.serviceByName(c, "test1").Current, Equals, servstate.StatusInactive)
+}
+
 func (s *S) TestServices(c *C) {
 	s.newServiceManager(c)
 	s.planAddLayer(c, testPlanLayer)
@@ -1527,6 +1853,15 @@ func (s *S) TestGetAction(c *C) {
 		c.Check(onType, Equals, test.onType, Commentf("onSuccess=%q, onFailure=%q, success=%v",
 			test.onSuccess, test.onFailure, test.success))
 	}
+
+	// Tasks do nothing further by default once they've succeeded.
+	task := &plan.Service{Type: plan.TypeTask}
+	action, onType := servstate.GetAction(task, true)
+	c.Check(action, Equals, plan.ActionIgnore)
+	c.Check(onType, Equals, "on-success")
+	action, onType = servstate.GetAction(task, false)
+	c.Check(action, Equals, plan.ActionRestart)
+	c.Check(onType, Equals, "on-failure")
 }
 
 func (s *S) TestGetJitter(c *C) {
@@ -2078,7 +2413,7 @@ func (s *S) testServiceLogs(c *C, outputs map[string]string) {
 		return
 	}
 
-	iterators, err := s.manager.ServiceLogs([]string{"test1", "test2"}, -1)
+	iterators, err := s.manager.ServiceLogs([]string{"test1", "test2"}, -1, time.Time{})
 	c.Assert(err, IsNil)
 	c.Assert(iterators, HasLen, 2)
 
@@ -2302,7 +2637,11 @@ func (s *S) TestMetrics(c *C) {
 		return
 	}
 	buf := new(bytes.Buffer)
-	writer := metrics.NewOpenTelemetryWriter(buf)
+	// The start duration histogram is tested separately, as its values vary.
+	writer := skipMetricWriter{
+		Writer: metrics.NewOpenTelemetryWriter(buf),
+		skip:   "pebble_service_start_duration_seconds",
+	}
 	s.manager.WriteMetrics(writer)
 	expected := `
 # HELP pebble_service_active Whether the service is currently active (1) or not (0)
@@ -2397,6 +2736,44 @@ pebble_service_start_count{service="test2"} 2
 	c.Assert(buf.String(), Equals, expected)
 }
 
+func (s *S) TestStartDurationMetric(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, testPlanLayer)
+	s.planChanged(c)
+
+	s.startTestServices(c, true)
+	if c.Failed() {
+		return
+	}
+	buf := new(bytes.Buffer)
+	writer := metrics.NewOpenTelemetryWriter(buf)
+	err := s.manager.WriteMetrics(writer)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Matches, `(?s).*
+# HELP pebble_service_start_duration_seconds Time taken for the service to start, from starting its process until it is running
+# TYPE pebble_service_start_duration_seconds histogram
+pebble_service_start_duration_seconds_bucket{service="test1",le="0.1"} [01]
+.*
+pebble_service_start_duration_seconds_bucket{service="test1",le="300"} 1
+pebble_service_start_duration_seconds_bucket{service="test1",le="\+Inf"} 1
+pebble_service_start_duration_seconds_sum{service="test1"} 0\.\d+
+pebble_service_start_duration_seconds_count{service="test1"} 1
+.*`)
+}
+
+// skipMetricWriter is a metrics.Writer that skips the named metric.
+type skipMetricWriter struct {
+	metrics.Writer
+	skip string
+}
+
+func (w skipMetricWriter) Write(m metrics.Metric) error {
+	if m.Name == w.skip {
+		return nil
+	}
+	return w.Writer.Write(m)
+}
+
 // getTestTime helps generate a time for testing purposes.
 func getTestTime(year int, month time.Month, day int) time.Time {
 	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,353 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/canonical/pebble/internals/logger"
+	"github.com/canonical/pebble/internals/overlord/state"
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/timeutil"
+)
+
+const (
+	scheduledRunKind  = "scheduled-run"
+	schedulesStateKey = "service-schedules"
+)
+
+// scheduleMisfireGrace is how long after the end of its window a scheduled
+// run may still start before it's considered to have misfired.
+var scheduleMisfireGrace = time.Minute
+
+// RunResult is the outcome of a scheduled service's most recent run.
+type RunResult string
+
+const (
+	RunSucceeded RunResult = "succeeded"
+	RunFailed    RunResult = "failed"
+	RunSkipped   RunResult = "skipped"
+	RunMisfired  RunResult = "misfired"
+	RunKilled    RunResult = "killed"
+)
+
+// ScheduleInfo holds status information about a scheduled service.
+type ScheduleInfo struct {
+	Schedule   string
+	Next       time.Time
+	LastRun    time.Time
+	LastResult RunResult
+	Queued     bool
+}
+
+// scheduleData holds the timer and run history for a scheduled service. It
+// is protected by the services lock.
+type scheduleData struct {
+	name       string
+	spec       string
+	schedules  []*timeutil.Schedule
+	window     timeutil.ScheduleWindow
+	next       time.Time
+	timer      *time.Timer
+	queued     bool
+	runStarted time.Time
+	lastRun    time.Time
+	lastResult RunResult
+}
+
+// scheduleState is the persisted form of scheduleData, used to detect runs
+// that were missed while Pebble wasn't running.
+type scheduleState struct {
+	Spec        string    `json:"spec"`
+	WindowStart time.Time `json:"window-start"`
+	WindowEnd   time.Time `json:"window-end"`
+	Next        time.Time `json:"next"`
+	LastRun     time.Time `json:"last-run"`
+	LastResult  RunResult `json:"last-result,omitempty"`
+}
+
+// updateSchedules arms the timers of new or modified scheduled services and
+// disarms those of services that are no longer scheduled.
+func (m *ServiceManager) updateSchedules(p *plan.Plan) {
+	m.state.Lock()
+	var saved map[string]*scheduleState
+	err := m.state.Get(schedulesStateKey, &saved)
+	m.state.Unlock()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		logger.Noticef("Cannot load service schedules from state: %v", err)
+	}
+
+	m.servicesLock.Lock()
+	defer m.servicesLock.Unlock()
+
+	for name, sched := range m.schedules {
+		config, ok := p.Services[name]
+		if ok && config.Schedule == sched.spec {
+			continue
+		}
+		sched.timer.Stop()
+		delete(m.schedules, name)
+	}
+
+	now := timeNow()
+	for name, config := range p.Services {
+		if config.Schedule == "" || m.schedules[name] != nil {
+			continue
+		}
+		schedules, err := timeutil.ParseSchedule(config.Schedule)
+		if err != nil {
+			// Should never happen, as the schedule is validated with the plan.
+			logger.Noticef("Cannot parse schedule for service %q: %v", name, err)
+			continue
+		}
+		sched := &scheduleData{
+			name:      name,
+			spec:      config.Schedule,
+			schedules: schedules,
+		}
+		m.schedules[name] = sched
+
+		saved := saved[name]
+		if saved == nil {
+			m.scheduleNext(sched, now)
+			continue
+		}
+		sched.lastRun = saved.LastRun
+		sched.lastResult = saved.LastResult
+		window := timeutil.ScheduleWindow{Start: saved.WindowStart, End: saved.WindowEnd}
+		if window.IsZero() || saved.Spec != sched.spec {
+			// The saved window doesn't apply if the schedule has changed.
+			m.scheduleNext(sched, now)
+			continue
+		}
+		// Keep the window that was pending when Pebble last stopped: if it
+		// has already passed, the timer fires right away and the run is
+		// handled according to the misfire policy.
+		m.armSchedule(sched, window, saved.Next)
+	}
+}
+
+// scheduleNext arms the service's timer for the earliest window after the
+// given time.
+func (m *ServiceManager) scheduleNext(sched *scheduleData, after time.Time) {
+	var window timeutil.ScheduleWindow
+	for _, s := range sched.schedules {
+		w := s.Next(after)
+		if window.IsZero() || w.Start.Before(window.Start) {
+			window = w
+		}
+	}
+	next := window.Start
+	if window.Spread {
+		next = next.Add(m.randDuration(window.End.Sub(window.Start)))
+	}
+	m.armSchedule(sched, window, next)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (m *ServiceManager) armSchedule(sched *scheduleData, window timeutil.ScheduleWindow, next time.Time) {
+	if sched.timer != nil {
+		sched.timer.Stop()
+	}
+	sched.window = window
+	sched.next = next
+	sched.timer = time.AfterFunc(next.Sub(timeNow()), func() { logError(m.scheduleElapsed(sched)) })
+}
+
+// randDuration returns a random duration between 0 and d.
+func (m *ServiceManager) randDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	m.randLock.Lock()
+	defer m.randLock.Unlock()
+	return time.Duration(m.rand.Int63n(int64(d)))
+}
+
+// scheduleElapsed is called when a scheduled service is due to run. It
+// applies the service's misfire and overlap policies, starts the run if
+// appropriate, and arms the timer for the next window.
+func (m *ServiceManager) scheduleElapsed(sched *scheduleData) error {
+	config, ok := m.getPlan().Services[sched.name]
+	if !ok {
+		return nil
+	}
+
+	m.servicesLock.Lock()
+	if m.schedules[sched.name] != sched {
+		// The schedule was removed or replaced by a plan change.
+		m.servicesLock.Unlock()
+		return nil
+	}
+
+	now := timeNow()
+	due := sched.next
+	misfired := now.After(sched.window.End.Add(scheduleMisfireGrace))
+	// Schedule the next window after now rather than after the due time, so
+	// that the windows missed while Pebble wasn't running are handled as a
+	// single misfire rather than firing one after the other.
+	m.scheduleNext(sched, maxTime(due, now))
+
+	running := false
+	if service := m.services[sched.name]; service != nil {
+		running = stateToStatus(service.state) == StatusActive
+	}
+
+	start, stopFirst := true, false
+	switch {
+	case misfired && config.ScheduleMisfire != plan.MisfireRun:
+		logger.Noticef("Service %q missed its scheduled run at %s, skipping", sched.name, due.Format(time.RFC3339))
+		sched.lastRun = due
+		sched.lastResult = RunMisfired
+		start = false
+
+	case running:
+		switch config.ScheduleOverlap {
+		case plan.OverlapQueue:
+			logger.Noticef("Service %q is still running, queueing scheduled run", sched.name)
+			sched.queued = true
+			start = false
+		case plan.OverlapKill:
+			logger.Noticef("Service %q is still running, stopping it before scheduled run", sched.name)
+			sched.lastRun = sched.runStarted
+			sched.lastResult = RunKilled
+			stopFirst = true
+		default:
+			logger.Noticef("Service %q is still running, skipping scheduled run", sched.name)
+			sched.lastRun = due
+			sched.lastResult = RunSkipped
+			start = false
+		}
+
+	case misfired:
+		logger.Noticef("Service %q missed its scheduled run at %s, running it now", sched.name, due.Format(time.RFC3339))
+	}
+	m.servicesLock.Unlock()
+
+	m.saveSchedules()
+	if !start {
+		return nil
+	}
+	return m.startScheduledRun(sched.name, stopFirst)
+}
+
+// scheduledRunExited records the result of a scheduled service's run, and
+// starts the next run if one was queued. The services lock must be held.
+func (m *ServiceManager) scheduledRunExited(sched *scheduleData, exitCode int) {
+	sched.lastRun = sched.runStarted
+	sched.lastResult = RunSucceeded
+	if exitCode != 0 {
+		sched.lastResult = RunFailed
+	}
+	queued := sched.queued
+	sched.queued = false
+
+	// Taking the state lock while holding the services lock could deadlock,
+	// so update the state asynchronously.
+	go func() {
+		m.saveSchedules()
+		if queued {
+			logError(m.startScheduledRun(sched.name, false))
+		}
+	}()
+}
+
+// startScheduledRun creates a change to run the named scheduled service,
+// first stopping it if stopFirst is true.
+func (m *ServiceManager) startScheduledRun(name string, stopFirst bool) error {
+	lanes, err := m.StartOrder([]string{name})
+	if err != nil {
+		return fmt.Errorf("cannot run scheduled service %q: %w", name, err)
+	}
+
+	// A task that completed its previous run would otherwise not be started
+	// again.
+	m.servicesLock.Lock()
+	if service := m.services[name]; service != nil && service.state == stateExitedOK {
+		service.transition(stateStopped)
+	}
+	m.servicesLock.Unlock()
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	taskSet, err := Start(m.state, lanes)
+	if err != nil {
+		return fmt.Errorf("cannot run scheduled service %q: %w", name, err)
+	}
+	if stopFirst {
+		stopTasks, err := Stop(m.state, [][]string{{name}})
+		if err != nil {
+			return fmt.Errorf("cannot run scheduled service %q: %w", name, err)
+		}
+		taskSet.WaitAll(stopTasks)
+		taskSet.AddAll(stopTasks)
+	}
+	change := m.state.NewChange(scheduledRunKind, fmt.Sprintf("Run scheduled service %q", name))
+	change.AddAll(taskSet)
+	change.Set("service-names", []string{name})
+	m.state.EnsureBefore(0)
+	return nil
+}
+
+// saveSchedules persists the scheduled services' pending windows and last
+// results to the state.
+func (m *ServiceManager) saveSchedules() {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	m.servicesLock.Lock()
+	saved := make(map[string]*scheduleState, len(m.schedules))
+	for name, sched := range m.schedules {
+		saved[name] = &scheduleState{
+			Spec:        sched.spec,
+			WindowStart: sched.window.Start,
+			WindowEnd:   sched.window.End,
+			Next:        sched.next,
+			LastRun:     sched.lastRun,
+			LastResult:  sched.lastResult,
+		}
+	}
+	m.servicesLock.Unlock()
+
+	if len(saved) == 0 {
+		// Avoid needlessly writing state when there are no schedules.
+		var existing map[string]*scheduleState
+		if errors.Is(m.state.Get(schedulesStateKey, &existing), state.ErrNoState) {
+			return
+		}
+		m.state.Set(schedulesStateKey, nil)
+		return
+	}
+	m.state.Set(schedulesStateKey, saved)
+}
+
+func scheduleDataToInfo(sched *scheduleData) *ScheduleInfo {
+	return &ScheduleInfo{
+		Schedule:   sched.spec,
+		Next:       sched.next,
+		LastRun:    sched.lastRun,
+		LastResult: sched.lastResult,
+		Queued:     sched.queued,
+	}
+}
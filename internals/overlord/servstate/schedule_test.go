@@ -0,0 +1,278 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate_test
+
+import (
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/overlord/servstate"
+	"github.com/canonical/pebble/internals/overlord/state"
+)
+
+// scheduleWindow returns a daily schedule window from start to end, both
+// relative to now.
+func scheduleWindow(start, end time.Duration) string {
+	now := time.Now()
+	return fmt.Sprintf("%s-%s", now.Add(start).Format("15:04"), now.Add(end).Format("15:04"))
+}
+
+// pastScheduleWindow is a daily schedule window that ended a couple of hours
+// ago, so that timers for it don't fire during the tests.
+var pastScheduleWindow = scheduleWindow(-3*time.Hour, -2*time.Hour)
+
+// scheduledPlanLayer returns a layer with scheduled services using
+// pastScheduleWindow.
+func scheduledPlanLayer() string {
+	return fmt.Sprintf(`
+services:
+    quick:
+        override: replace
+        command: /bin/sh -c "echo quick"
+        schedule: %[1]q
+
+    slow:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        schedule: %[1]q
+
+    queued:
+        override: replace
+        command: /bin/sh -c "sleep 10"
+        schedule: %[1]q
+        schedule-overlap: queue
+`, pastScheduleWindow)
+}
+
+func (s *S) scheduledRunChange(c *C, name string) *state.Change {
+	s.st.Lock()
+	defer s.st.Unlock()
+	var found *state.Change
+	for _, chg := range s.st.Changes() {
+		var names []string
+		if chg.Kind() != "scheduled-run" || chg.Get("service-names", &names) != nil {
+			continue
+		}
+		if len(names) == 1 && names[0] == name && !chg.IsReady() {
+			found = chg
+		}
+	}
+	c.Assert(found, NotNil)
+	return found
+}
+
+func (s *S) TestScheduledServiceInfo(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+
+	svc := s.serviceByName(c, "quick")
+	c.Assert(svc.Schedule, NotNil)
+	c.Check(svc.Schedule.Schedule, Equals, pastScheduleWindow)
+	c.Check(svc.Schedule.Next.After(time.Now()), Equals, true)
+	c.Check(svc.Schedule.LastRun.IsZero(), Equals, true)
+	c.Check(svc.Current, Equals, servstate.StatusInactive)
+
+	// Scheduled services aren't started by replan.
+	_, startLanes, err := s.manager.Replan()
+	c.Assert(err, IsNil)
+	for _, lane := range startLanes {
+		c.Check(lane, HasLen, 0)
+	}
+}
+
+func (s *S) TestScheduledRun(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+
+	err := s.manager.FireSchedule("quick")
+	c.Assert(err, IsNil)
+	chg := s.scheduledRunChange(c, "quick")
+	waitChangeReady(c, s.runner, chg, "scheduled run")
+	s.st.Lock()
+	c.Check(chg.Status(), Equals, state.DoneStatus, Commentf("Error: %v", chg.Err()))
+	s.st.Unlock()
+
+	s.waitUntilService(c, "quick", func(svc *servstate.ServiceInfo) bool {
+		return svc.Schedule.LastResult == servstate.RunSucceeded
+	})
+	svc := s.serviceByName(c, "quick")
+	c.Check(svc.Current, Equals, servstate.StatusInactive)
+	c.Check(svc.Schedule.LastRun.IsZero(), Equals, false)
+}
+
+func (s *S) TestScheduledRunOverlapSkip(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+
+	err := s.manager.FireSchedule("slow")
+	c.Assert(err, IsNil)
+	waitChangeReady(c, s.runner, s.scheduledRunChange(c, "slow"), "scheduled run")
+	c.Assert(s.serviceByName(c, "slow").Current, Equals, servstate.StatusActive)
+
+	err = s.manager.FireSchedule("slow")
+	c.Assert(err, IsNil)
+	svc := s.serviceByName(c, "slow")
+	c.Check(svc.Current, Equals, servstate.StatusActive)
+	c.Check(svc.Schedule.LastResult, Equals, servstate.RunSkipped)
+	c.Check(svc.Schedule.Queued, Equals, false)
+}
+
+func (s *S) TestScheduledRunOverlapQueue(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+
+	err := s.manager.FireSchedule("queued")
+	c.Assert(err, IsNil)
+	waitChangeReady(c, s.runner, s.scheduledRunChange(c, "queued"), "scheduled run")
+
+	err = s.manager.FireSchedule("queued")
+	c.Assert(err, IsNil)
+	svc := s.serviceByName(c, "queued")
+	c.Check(svc.Current, Equals, servstate.StatusActive)
+	c.Check(svc.Schedule.Queued, Equals, true)
+	c.Check(svc.Schedule.LastResult, Equals, servstate.RunResult(""))
+}
+
+func (s *S) TestScheduledRunMisfire(c *C) {
+	// Pretend Pebble was down during the service's last window.
+	start := time.Now().Add(-3 * time.Hour)
+	s.st.Lock()
+	s.st.Set("service-schedules", map[string]any{
+		"quick": map[string]any{
+			"spec":         pastScheduleWindow,
+			"window-start": start,
+			"window-end":   start.Add(time.Hour),
+			"next":         start,
+		},
+	})
+	s.st.Unlock()
+
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+
+	s.waitUntilService(c, "quick", func(svc *servstate.ServiceInfo) bool {
+		return svc.Schedule.LastResult == servstate.RunMisfired
+	})
+	svc := s.serviceByName(c, "quick")
+	c.Check(svc.Current, Equals, servstate.StatusInactive)
+	c.Check(svc.Schedule.LastRun.Equal(start), Equals, true)
+	c.Check(svc.Schedule.Next.After(start), Equals, true)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	for _, chg := range s.st.Changes() {
+		c.Check(chg.Kind(), Not(Equals), "scheduled-run")
+	}
+}
+
+func (s *S) TestScheduledRunMisfireStale(c *C) {
+	// Pretend Pebble was down for a week, and has been restarted in the
+	// middle of the service's current window.
+	start := time.Now().Add(-7*24*time.Hour - time.Hour)
+	spec := scheduleWindow(-time.Hour, time.Hour)
+	s.st.Lock()
+	s.st.Set("service-schedules", map[string]any{
+		"current": map[string]any{
+			"spec":         spec,
+			"window-start": start,
+			"window-end":   start.Add(2 * time.Hour),
+			"next":         start,
+		},
+	})
+	s.st.Unlock()
+
+	s.newServiceManager(c)
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    current:
+        override: replace
+        command: /bin/sh -c "echo current"
+        schedule: %q
+`, spec))
+	s.planChanged(c)
+
+	s.waitUntilService(c, "current", func(svc *servstate.ServiceInfo) bool {
+		return svc.Schedule.LastResult == servstate.RunMisfired
+	})
+
+	// The missed windows are handled as a single misfire, and the next run
+	// isn't in the current window, which the misfire already covered.
+	time.Sleep(50 * time.Millisecond)
+	svc := s.serviceByName(c, "current")
+	c.Check(svc.Current, Equals, servstate.StatusInactive)
+	c.Check(svc.Schedule.LastRun.Equal(start), Equals, true)
+	c.Check(svc.Schedule.Next.After(time.Now().Add(time.Hour)), Equals, true)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	for _, chg := range s.st.Changes() {
+		c.Check(chg.Kind(), Not(Equals), "scheduled-run")
+	}
+}
+
+func (s *S) TestScheduleChanged(c *C) {
+	// Pretend Pebble was down during the window of a previous schedule.
+	start := time.Now().Add(-3 * time.Hour)
+	s.st.Lock()
+	s.st.Set("service-schedules", map[string]any{
+		"quick": map[string]any{
+			"spec":         scheduleWindow(-5*time.Hour, -4*time.Hour),
+			"window-start": start,
+			"window-end":   start.Add(time.Hour),
+			"next":         start,
+			"last-result":  "succeeded",
+		},
+	})
+	s.st.Unlock()
+
+	// The saved window isn't used, as the schedule has changed.
+	s.newServiceManager(c)
+	s.planAddLayer(c, scheduledPlanLayer())
+	s.planChanged(c)
+	time.Sleep(50 * time.Millisecond)
+	svc := s.serviceByName(c, "quick")
+	c.Check(svc.Schedule.LastResult, Equals, servstate.RunSucceeded)
+	c.Check(svc.Schedule.Next.After(time.Now()), Equals, true)
+
+	// Nor is the window pending for the schedule before a replan.
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    quick:
+        override: merge
+        schedule: %q
+`, scheduleWindow(time.Hour, 2*time.Hour)))
+	s.planChanged(c)
+	svc = s.serviceByName(c, "quick")
+	c.Check(svc.Schedule.Next.Before(time.Now().Add(2*time.Hour)), Equals, true)
+
+	later := scheduleWindow(4*time.Hour, 5*time.Hour)
+	s.planAddLayer(c, fmt.Sprintf(`
+services:
+    quick:
+        override: merge
+        schedule: %q
+`, later))
+	s.planChanged(c)
+	svc = s.serviceByName(c, "quick")
+	c.Check(svc.Schedule.Schedule, Equals, later)
+	c.Check(svc.Schedule.Next.After(time.Now().Add(3*time.Hour)), Equals, true)
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var procRoot = "/proc"
+
+// clockTicks is the unit of the CPU times in /proc/<pid>/stat. The kernel
+// always reports these in USER_HZ, which is 100 on all Linux architectures.
+const clockTicks = 100
+
+// ServiceUsage is a snapshot of the resources used by a service's process
+// tree: its main process, that process's descendants, and any other
+// processes in the service's process group.
+type ServiceUsage struct {
+	// CPUTime is the user and system CPU time used by the processes that
+	// are still running, including the time used by children they have
+	// waited for.
+	CPUTime time.Duration
+
+	// RSS is the total resident set size in bytes.
+	RSS int64
+
+	// OpenFDs is the total number of open file descriptors. Processes
+	// whose descriptors can't be read are not counted.
+	OpenFDs int
+
+	// Threads is the total number of threads.
+	Threads int
+}
+
+// procStat holds the fields of /proc/<pid>/stat used to calculate usage.
+type procStat struct {
+	ppid     int
+	pgrp     int
+	cpuTicks int64
+	threads  int
+	rssPages int64
+}
+
+// readProcs reads the stat file of every process in /proc. Processes that
+// exit while being read are skipped.
+func readProcs() (map[int]*procStat, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	procs := make(map[int]*procStat)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		stat, err := readProcStat(pid)
+		if err != nil {
+			continue
+		}
+		procs[pid] = stat
+	}
+	return procs, nil
+}
+
+func readProcStat(pid int) (*procStat, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	// The command name (field 2) is in parentheses and may itself contain
+	// spaces and parentheses, so parse the fields after the last one.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid stat for PID %d", pid)
+	}
+	fields := bytes.Fields(data[i+1:])
+	// fields[0] is field 3 (state), so field N is fields[N-3].
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("invalid stat for PID %d", pid)
+	}
+	field := func(n int) int64 {
+		if err != nil {
+			return 0
+		}
+		var v int64
+		v, err = strconv.ParseInt(string(fields[n-3]), 10, 64)
+		return v
+	}
+	stat := &procStat{
+		ppid:     int(field(4)),
+		pgrp:     int(field(5)),
+		cpuTicks: field(14) + field(15) + field(16) + field(17),
+		threads:  int(field(20)),
+		rssPages: field(24),
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid stat for PID %d: %w", pid, err)
+	}
+	return stat, nil
+}
+
+// processTree returns the PIDs in the process tree rooted at pid, including
+// processes in pid's process group that have been reparented, sorted.
+func processTree(procs map[int]*procStat, pid int) []int {
+	if procs[pid] == nil {
+		return nil
+	}
+	children := make(map[int][]int)
+	for p, stat := range procs {
+		children[stat.ppid] = append(children[stat.ppid], p)
+	}
+	seen := map[int]bool{pid: true}
+	queue := []int{pid}
+	for p, stat := range procs {
+		if stat.pgrp == pid && !seen[p] {
+			seen[p] = true
+			queue = append(queue, p)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, child := range children[queue[i]] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	sort.Ints(queue)
+	return queue
+}
+
+// processTreeUsage calculates the usage of the process tree rooted at pid,
+// or returns nil if that process isn't in procs.
+func processTreeUsage(procs map[int]*procStat, pid int) *ServiceUsage {
+	pids := processTree(procs, pid)
+	if len(pids) == 0 {
+		return nil
+	}
+	pageSize := int64(os.Getpagesize())
+	var usage ServiceUsage
+	var ticks int64
+	for _, p := range pids {
+		stat := procs[p]
+		ticks += stat.cpuTicks
+		usage.RSS += stat.rssPages * pageSize
+		usage.Threads += stat.threads
+		fds, err := os.ReadDir(filepath.Join(procRoot, strconv.Itoa(p), "fd"))
+		if err == nil {
+			usage.OpenFDs += len(fds)
+		}
+	}
+	usage.CPUTime = time.Duration(ticks) * time.Second / clockTicks
+	return &usage
+}
+
+// usage returns the service's current resource usage, or nil if it has no
+// running process.
+func (s *serviceData) usage(procs map[int]*procStat) *ServiceUsage {
+	switch s.state {
+	case stateStarting, stateRunning, stateTerminating, stateKilling:
+	default:
+		return nil
+	}
+	if s.cmd == nil || s.cmd.Process == nil {
+		return nil
+	}
+	return processTreeUsage(procs, s.cmd.Process.Pid)
+}
+
+// ServiceUsage returns the resource usage of the named services (or all
+// services if no names are given), sampled from /proc. Services without a
+// running process are omitted from the result.
+func (m *ServiceManager) ServiceUsage(names []string) (map[string]*ServiceUsage, error) {
+	procs, err := readProcs()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read processes: %w", err)
+	}
+
+	m.servicesLock.Lock()
+	defer m.servicesLock.Unlock()
+
+	usages := make(map[string]*ServiceUsage)
+	for name, s := range m.services {
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		if usage := s.usage(procs); usage != nil {
+			usages[name] = usage
+		}
+	}
+	return usages, nil
+}
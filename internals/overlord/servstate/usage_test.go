@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servstate_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/metrics"
+	"github.com/canonical/pebble/internals/overlord/servstate"
+)
+
+// fakeProc adds a process to a fake /proc, with the given parent, process
+// group, CPU ticks (utime, stime, cutime, cstime), threads, RSS pages and
+// number of open file descriptors.
+func fakeProc(c *C, root string, pid, ppid, pgrp int, ticks [4]int, threads, rssPages, fds int) {
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	err := os.MkdirAll(filepath.Join(dir, "fd"), 0o755)
+	c.Assert(err, IsNil)
+	stat := fmt.Sprintf("%d (my (weird) cmd) S %d %d 1 0 -1 4194304 100 0 0 0 %d %d %d %d 20 0 %d 0 12345 1000000 %d 0 0\n",
+		pid, ppid, pgrp, ticks[0], ticks[1], ticks[2], ticks[3], threads, rssPages)
+	writeFile(c, filepath.Join(dir, "stat"), stat)
+	for i := 0; i < fds; i++ {
+		writeFile(c, filepath.Join(dir, "fd", strconv.Itoa(i)), "")
+	}
+}
+
+func (s *S) TestServiceUsage(c *C) {
+	s.newServiceManager(c)
+	s.planAddLayer(c, testPlanLayer)
+	s.planChanged(c)
+	s.startServices(c, [][]string{{"test2"}})
+	pid := s.manager.RunningCmds()["test2"].Process.Pid
+
+	root := c.MkDir()
+	s.AddCleanup(servstate.FakeProcRoot(root))
+	fakeProc(c, root, 1, 0, 1, [4]int{1000, 1000, 0, 0}, 1, 1000, 10)
+	fakeProc(c, root, pid, 1, pid, [4]int{100, 50, 25, 25}, 2, 10, 3)
+	// A child and grandchild of the service.
+	fakeProc(c, root, 100001, pid, pid, [4]int{10, 10, 0, 0}, 1, 5, 1)
+	fakeProc(c, root, 100002, 100001, 100002, [4]int{30, 0, 0, 0}, 4, 5, 2)
+	// A process in the service's process group that has been reparented.
+	fakeProc(c, root, 100003, 1, pid, [4]int{0, 0, 0, 0}, 1, 1, 0)
+	// An unrelated process.
+	fakeProc(c, root, 100004, 1, 100004, [4]int{500, 0, 0, 0}, 1, 100, 5)
+
+	usages, err := s.manager.ServiceUsage(nil)
+	c.Assert(err, IsNil)
+	pageSize := int64(os.Getpagesize())
+	c.Assert(usages, DeepEquals, map[string]*servstate.ServiceUsage{
+		"test2": {
+			CPUTime: 2500 * time.Millisecond,
+			RSS:     21 * pageSize,
+			OpenFDs: 6,
+			Threads: 8,
+		},
+	})
+
+	usages, err = s.manager.ServiceUsage([]string{"test1"})
+	c.Assert(err, IsNil)
+	c.Check(usages, HasLen, 0)
+
+	buf := new(bytes.Buffer)
+	writer := skipMetricWriter{
+		Writer: metrics.NewOpenTelemetryWriter(buf),
+		skip:   "pebble_service_start_duration_seconds",
+	}
+	err = s.manager.WriteMetrics(writer)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, fmt.Sprintf(`
+# HELP pebble_service_active Whether the service is currently active (1) or not (0)
+# TYPE pebble_service_active gauge
+pebble_service_active{service="test2"} 1
+
+# HELP pebble_service_start_count Number of times the service has started
+# TYPE pebble_service_start_count counter
+pebble_service_start_count{service="test2"} 1
+
+# HELP pebble_service_cpu_seconds CPU time used by the service's running processes
+# TYPE pebble_service_cpu_seconds gauge
+pebble_service_cpu_seconds{service="test2"} 2.5
+
+# HELP pebble_service_rss_bytes Resident set size of the service's processes
+# TYPE pebble_service_rss_bytes gauge
+pebble_service_rss_bytes{service="test2"} %d
+
+# HELP pebble_service_open_fds Number of file descriptors open in the service's processes
+# TYPE pebble_service_open_fds gauge
+pebble_service_open_fds{service="test2"} 6
+
+# HELP pebble_service_threads Number of threads in the service's processes
+# TYPE pebble_service_threads gauge
+pebble_service_threads{service="test2"} 8
+
+`[1:], 21*pageSize))
+}
+
+func (s *S) TestServiceUsageRealProc(c *C) {
+	s.AddCleanup(servstate.FakeProcRoot("/proc"))
+	s.newServiceManager(c)
+	s.planAddLayer(c, testPlanLayer)
+	s.planChanged(c)
+	s.startServices(c, [][]string{{"test2"}})
+
+	usages, err := s.manager.ServiceUsage([]string{"test2"})
+	c.Assert(err, IsNil)
+	c.Assert(usages["test2"], NotNil)
+	c.Check(usages["test2"].RSS > 0, Equals, true)
+	c.Check(usages["test2"].Threads >= 1, Equals, true)
+	c.Check(usages["test2"].OpenFDs >= 1, Equals, true)
+}
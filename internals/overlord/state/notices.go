@@ -198,11 +198,15 @@ const (
 	// Warnings are a subset of notices where the key is a human-readable
 	// warning message.
 	WarningNotice NoticeType = "warning"
+
+	// Recorded whenever a health check's status changes between "up", "down"
+	// and "inactive". The key for check-status notices is the check name.
+	CheckStatusNotice NoticeType = "check-status"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, CustomNotice, WarningNotice:
+	case ChangeUpdateNotice, CustomNotice, WarningNotice, CheckStatusNotice:
 		return true
 	}
 	return false
@@ -514,6 +514,28 @@ func (s *S) TestSectionOrderExt(c *C) {
 				b: b`)))
 }
 
+// TestCheckLevelExtension ensures custom check levels are only accepted by
+// layer validation when an extension defining them is registered.
+func (s *S) TestCheckLevelExtension(c *C) {
+	layerYAML := reindent(`
+		checks:
+			chk1:
+				override: replace
+				level: custom
+				exec:
+					command: ping 8.8.8.8`)
+
+	_, err := plan.ParseLayer(1, "label", layerYAML)
+	c.Assert(err, ErrorMatches, `plan check "chk1" level must be "alive" or "ready"`)
+
+	plan.RegisterSectionExtension("y-field", &levelExtension{})
+	defer plan.UnregisterSectionExtension("y-field")
+
+	layer, err := plan.ParseLayer(1, "label", layerYAML)
+	c.Assert(err, IsNil)
+	c.Assert(layer.Checks["chk1"].Level, Equals, plan.CheckLevel("custom"))
+}
+
 // writeLayerFiles writes layer files of a test to disk.
 func (s *S) writeLayerFiles(c *C, layersDir string, inputs []*inputLayer) {
 	err := os.MkdirAll(layersDir, 0755)
@@ -790,3 +812,12 @@ func makeMapIfNil[K comparable, V any](m map[K]V) map[K]V {
 	}
 	return m
 }
+
+// levelExtension implements the CheckLevelExtension interface.
+type levelExtension struct {
+	yExtension
+}
+
+func (levelExtension) DefinesCheckLevels() bool {
+	return true
+}
@@ -18,6 +18,9 @@ import (
 	"bytes"
 	"fmt"
 	"maps"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -32,6 +35,7 @@ import (
 
 	"github.com/canonical/pebble/internals/logger"
 	"github.com/canonical/pebble/internals/osutil"
+	"github.com/canonical/pebble/internals/timeutil"
 )
 
 // SectionExtension allows the plan layer schema to be extended without
@@ -51,6 +55,17 @@ type SectionExtension interface {
 	ValidatePlan(plan *Plan) error
 }
 
+// CheckLevelExtension is an optional interface implemented by section
+// extensions that define custom check levels. If such an extension is
+// registered, layer validation accepts levels other than "alive" and "ready",
+// and the extension's ValidatePlan must check that they are defined.
+type CheckLevelExtension interface {
+	SectionExtension
+
+	// DefinesCheckLevels reports whether the extension defines custom levels.
+	DefinesCheckLevels() bool
+}
+
 type Section interface {
 	// Validate checks whether the section is valid, returning an error if not.
 	Validate() error
@@ -97,6 +112,17 @@ func RegisterSectionExtension(field string, ext SectionExtension) {
 	sectionExtensionsOrder = append(sectionExtensionsOrder, field)
 }
 
+// customCheckLevels reports whether a registered extension defines custom
+// check levels.
+func customCheckLevels() bool {
+	for _, ext := range sectionExtensions {
+		if levelExt, ok := ext.(CheckLevelExtension); ok && levelExt.DefinesCheckLevels() {
+			return true
+		}
+	}
+	return false
+}
+
 // UnregisterSectionExtension removes a plan schema extension. This is only
 // intended for use by tests during cleanup.
 func UnregisterSectionExtension(field string) {
@@ -178,6 +204,17 @@ func (p *Plan) MarshalYAML() (any, error) {
 	return plan, nil
 }
 
+// Redacted returns a shallow copy of the plan with the secrets in its log
+// targets redacted, for displaying the plan.
+func (p *Plan) Redacted() *Plan {
+	redacted := *p
+	redacted.LogTargets = make(map[string]*LogTarget, len(p.LogTargets))
+	for name, target := range p.LogTargets {
+		redacted.LogTargets[name] = target.Redacted()
+	}
+	return &redacted
+}
+
 // Layer represents an unmarshalled YAML layer configuration file. Layer files
 // are maintained as part of the Plan, ordered by their respective order
 // number. Each layer configuration also has a unique label, used for locating
@@ -208,11 +245,14 @@ type Service struct {
 	Startup     ServiceStartup `yaml:"startup,omitempty"`
 	Override    Override       `yaml:"override,omitempty"`
 	Command     string         `yaml:"command,omitempty"`
+	Type        ServiceType    `yaml:"type,omitempty"`
 
 	// Service dependencies
-	After    []string `yaml:"after,omitempty"`
-	Before   []string `yaml:"before,omitempty"`
-	Requires []string `yaml:"requires,omitempty"`
+	After             []string         `yaml:"after,omitempty"`
+	Before            []string         `yaml:"before,omitempty"`
+	Requires          []string         `yaml:"requires,omitempty"`
+	AfterReady        []string         `yaml:"after-ready,omitempty"`
+	AfterReadyTimeout OptionalDuration `yaml:"after-ready-timeout,omitempty"`
 
 	// Options for command execution
 	Workload    string            `yaml:"workload,omitempty"`
@@ -222,6 +262,9 @@ type Service struct {
 	GroupID     *int              `yaml:"group-id,omitempty"`
 	Group       string            `yaml:"group,omitempty"`
 	WorkingDir  string            `yaml:"working-dir,omitempty"`
+	Resources   *Resources        `yaml:"resources,omitempty"`
+	LogFile     *LogFile          `yaml:"log-file,omitempty"`
+	LogFormat   LogFormat         `yaml:"log-format,omitempty"`
 
 	// Auto-restart and backoff functionality
 	OnSuccess      ServiceAction            `yaml:"on-success,omitempty"`
@@ -231,6 +274,12 @@ type Service struct {
 	BackoffFactor  OptionalFloat            `yaml:"backoff-factor,omitempty"`
 	BackoffLimit   OptionalDuration         `yaml:"backoff-limit,omitempty"`
 	KillDelay      OptionalDuration         `yaml:"kill-delay,omitempty"`
+	KillMode       KillMode                 `yaml:"kill-mode,omitempty"`
+
+	// Scheduled runs
+	Schedule        string          `yaml:"schedule,omitempty"`
+	ScheduleOverlap ScheduleOverlap `yaml:"schedule-overlap,omitempty"`
+	ScheduleMisfire ScheduleMisfire `yaml:"schedule-misfire,omitempty"`
 }
 
 // Copy returns a deep copy of the service.
@@ -239,6 +288,7 @@ func (s *Service) Copy() *Service {
 	copied.After = append([]string(nil), s.After...)
 	copied.Before = append([]string(nil), s.Before...)
 	copied.Requires = append([]string(nil), s.Requires...)
+	copied.AfterReady = append([]string(nil), s.AfterReady...)
 	copied.Environment = maps.Clone(s.Environment)
 	if s.UserID != nil {
 		copied.UserID = copyIntPtr(s.UserID)
@@ -247,6 +297,8 @@ func (s *Service) Copy() *Service {
 		copied.GroupID = copyIntPtr(s.GroupID)
 	}
 	copied.OnCheckFailure = maps.Clone(s.OnCheckFailure)
+	copied.Resources = s.Resources.Copy()
+	copied.LogFile = s.LogFile.Copy()
 	return &copied
 }
 
@@ -264,9 +316,15 @@ func (s *Service) Merge(other *Service) {
 	if other.Command != "" {
 		s.Command = other.Command
 	}
+	if other.Type != TypeUnset {
+		s.Type = other.Type
+	}
 	if other.KillDelay.IsSet {
 		s.KillDelay = other.KillDelay
 	}
+	if other.KillMode != KillModeUnset {
+		s.KillMode = other.KillMode
+	}
 	if other.UserID != nil {
 		s.UserID = copyIntPtr(other.UserID)
 	}
@@ -282,9 +340,28 @@ func (s *Service) Merge(other *Service) {
 	if other.WorkingDir != "" {
 		s.WorkingDir = other.WorkingDir
 	}
+	if other.LogFormat != LogFormatUnset {
+		s.LogFormat = other.LogFormat
+	}
 	s.After = append(s.After, other.After...)
 	s.Before = append(s.Before, other.Before...)
 	s.Requires = append(s.Requires, other.Requires...)
+	s.AfterReady = append(s.AfterReady, other.AfterReady...)
+	if other.Resources != nil {
+		if s.Resources == nil {
+			s.Resources = &Resources{}
+		}
+		s.Resources.Merge(other.Resources)
+	}
+	if other.LogFile != nil {
+		if s.LogFile == nil {
+			s.LogFile = &LogFile{}
+		}
+		s.LogFile.Merge(other.LogFile)
+	}
+	if other.AfterReadyTimeout.IsSet {
+		s.AfterReadyTimeout = other.AfterReadyTimeout
+	}
 	if other.Workload != "" {
 		s.Workload = other.Workload
 	}
@@ -315,6 +392,15 @@ func (s *Service) Merge(other *Service) {
 	if other.BackoffLimit.IsSet {
 		s.BackoffLimit = other.BackoffLimit
 	}
+	if other.Schedule != "" {
+		s.Schedule = other.Schedule
+	}
+	if other.ScheduleOverlap != "" {
+		s.ScheduleOverlap = other.ScheduleOverlap
+	}
+	if other.ScheduleMisfire != "" {
+		s.ScheduleMisfire = other.ScheduleMisfire
+	}
 }
 
 // Equal returns true when the two services are equal in value.
@@ -404,6 +490,32 @@ func (s *Service) LogsTo(t *LogTarget) bool {
 	return false
 }
 
+// Pebble's own log sources, which log targets can select in "services" to
+// forward Pebble's logs along with those of the services. Unlike services,
+// they're not selected by "all".
+const (
+	DaemonLogSource   = "pebble:daemon"
+	SecurityLogSource = "pebble:security"
+	ChangesLogSource  = "pebble:changes"
+)
+
+// LogSources lists Pebble's own log sources.
+var LogSources = []string{DaemonLogSource, SecurityLogSource, ChangesLogSource}
+
+// LogsFromSource returns true if the logs from the given Pebble log source
+// (one of LogSources) should be forwarded to target t.
+func (t *LogTarget) LogsFromSource(source string) bool {
+	for i := len(t.Services) - 1; i >= 0; i-- {
+		switch t.Services[i] {
+		case source:
+			return true
+		case "-" + source, "-all":
+			return false
+		}
+	}
+	return false
+}
+
 type ServiceStartup string
 
 const (
@@ -412,6 +524,26 @@ const (
 	StartupDisabled ServiceStartup = "disabled"
 )
 
+// ServiceType defines whether a service is a long-running daemon or a
+// one-shot task that runs to completion.
+type ServiceType string
+
+const (
+	TypeUnset  ServiceType = ""
+	TypeDaemon ServiceType = "daemon"
+	TypeTask   ServiceType = "task"
+)
+
+// KillMode defines which processes are signalled when a service is stopped.
+type KillMode string
+
+const (
+	KillModeUnset   KillMode = ""
+	KillModeProcess KillMode = "process"
+	KillModeGroup   KillMode = "group"
+	KillModeCgroup  KillMode = "cgroup"
+)
+
 // Override specifies the layer override mechanism for an object.
 type Override string
 
@@ -435,6 +567,240 @@ const (
 	ActionSuccessShutdown ServiceAction = "success-shutdown"
 )
 
+// ScheduleOverlap defines what happens when a scheduled service is due to run
+// while its previous run is still in progress.
+type ScheduleOverlap string
+
+const (
+	OverlapUnset ScheduleOverlap = ""
+	OverlapSkip  ScheduleOverlap = "skip"
+	OverlapQueue ScheduleOverlap = "queue"
+	OverlapKill  ScheduleOverlap = "kill"
+)
+
+// ScheduleMisfire defines what happens when a scheduled service could not be
+// run during its window, for example because Pebble wasn't running.
+type ScheduleMisfire string
+
+const (
+	MisfireUnset ScheduleMisfire = ""
+	MisfireSkip  ScheduleMisfire = "skip"
+	MisfireRun   ScheduleMisfire = "run"
+)
+
+// LogFormat defines how a service's log lines are parsed to extract a level,
+// message and attributes.
+type LogFormat string
+
+const (
+	LogFormatUnset  LogFormat = ""
+	LogFormatPlain  LogFormat = "plain"
+	LogFormatJSON   LogFormat = "json"
+	LogFormatLogfmt LogFormat = "logfmt"
+)
+
+// Resources specifies the cgroup v2 resource limits for a service's
+// processes.
+type Resources struct {
+	// MemoryMax is the memory limit in bytes, with an optional K, M, G or T
+	// suffix (powers of 1024).
+	MemoryMax string `yaml:"memory-max,omitempty"`
+
+	// CPUMax is how many CPUs' worth of time the processes may use, for
+	// example 0.5 or 2.
+	CPUMax OptionalFloat `yaml:"cpu-max,omitempty"`
+
+	// PidsMax is the maximum number of processes and threads.
+	PidsMax int `yaml:"pids-max,omitempty"`
+
+	// IOWeight is the relative IO weight, from 1 to 10000.
+	IOWeight int `yaml:"io-weight,omitempty"`
+}
+
+// Copy returns a copy of the resources, or nil if r is nil.
+func (r *Resources) Copy() *Resources {
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	return &copied
+}
+
+// Merge merges the fields set in other into r.
+func (r *Resources) Merge(other *Resources) {
+	if other.MemoryMax != "" {
+		r.MemoryMax = other.MemoryMax
+	}
+	if other.CPUMax.IsSet {
+		r.CPUMax = other.CPUMax
+	}
+	if other.PidsMax != 0 {
+		r.PidsMax = other.PidsMax
+	}
+	if other.IOWeight != 0 {
+		r.IOWeight = other.IOWeight
+	}
+}
+
+// Validate checks that the resource limits are valid.
+func (r *Resources) Validate() error {
+	if r.MemoryMax != "" {
+		if _, err := r.MemoryMaxBytes(); err != nil {
+			return err
+		}
+	}
+	if r.CPUMax.IsSet && r.CPUMax.Value <= 0 {
+		return fmt.Errorf("cpu-max must be greater than zero")
+	}
+	if r.PidsMax < 0 {
+		return fmt.Errorf("pids-max must be greater than zero")
+	}
+	if r.IOWeight != 0 && (r.IOWeight < 1 || r.IOWeight > 10000) {
+		return fmt.Errorf("io-weight must be between 1 and 10000")
+	}
+	return nil
+}
+
+// MemoryMaxBytes returns the memory limit in bytes.
+func (r *Resources) MemoryMaxBytes() (int64, error) {
+	n, err := parseByteSize(r.MemoryMax)
+	if err != nil {
+		return 0, fmt.Errorf("memory-max %q invalid", r.MemoryMax)
+	}
+	return n, nil
+}
+
+// parseByteSize parses a positive size in bytes, with an optional K, M, G
+// or T suffix (powers of 1024).
+func parseByteSize(s string) (int64, error) {
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * multiplier, nil
+}
+
+const (
+	defaultLogFileMaxSize = 10 << 20
+	defaultLogFileRetain  = 5
+
+	defaultSpoolMaxSize      = 100 << 20
+	defaultSpoolBackoffLimit = 5 * time.Minute
+)
+
+// LogFile specifies an on-disk log file for a service's output, in
+// addition to the in-memory log buffer.
+type LogFile struct {
+	// Path is the absolute path of the log file. Rotated files are written
+	// alongside it.
+	Path string `yaml:"path,omitempty"`
+
+	// MaxSize is the size in bytes after which the file is rotated, with an
+	// optional K, M, G or T suffix (powers of 1024). Defaults to 10M.
+	MaxSize string `yaml:"max-size,omitempty"`
+
+	// MaxAge is the age of the oldest log in the file after which the file
+	// is rotated. By default files aren't rotated based on age.
+	MaxAge OptionalDuration `yaml:"max-age,omitempty"`
+
+	// Compress enables gzip compression of rotated files.
+	Compress *bool `yaml:"compress,omitempty"`
+
+	// Retain is the number of rotated files to keep. Defaults to 5.
+	Retain *int `yaml:"retain,omitempty"`
+}
+
+// Copy returns a copy of the log file configuration, or nil if l is nil.
+func (l *LogFile) Copy() *LogFile {
+	if l == nil {
+		return nil
+	}
+	copied := *l
+	if l.Compress != nil {
+		compress := *l.Compress
+		copied.Compress = &compress
+	}
+	if l.Retain != nil {
+		copied.Retain = copyIntPtr(l.Retain)
+	}
+	return &copied
+}
+
+// Merge merges the fields set in other into l.
+func (l *LogFile) Merge(other *LogFile) {
+	if other.Path != "" {
+		l.Path = other.Path
+	}
+	if other.MaxSize != "" {
+		l.MaxSize = other.MaxSize
+	}
+	if other.MaxAge.IsSet {
+		l.MaxAge = other.MaxAge
+	}
+	if other.Compress != nil {
+		compress := *other.Compress
+		l.Compress = &compress
+	}
+	if other.Retain != nil {
+		l.Retain = copyIntPtr(other.Retain)
+	}
+}
+
+// Validate checks that the log file configuration is valid.
+func (l *LogFile) Validate() error {
+	if l.Path != "" && !filepath.IsAbs(l.Path) {
+		return fmt.Errorf("log-file path %q must be absolute", l.Path)
+	}
+	if l.MaxSize != "" {
+		if _, err := parseByteSize(l.MaxSize); err != nil {
+			return fmt.Errorf("log-file max-size %q invalid", l.MaxSize)
+		}
+	}
+	if l.MaxAge.IsSet && l.MaxAge.Value <= 0 {
+		return fmt.Errorf("log-file max-age must be greater than zero")
+	}
+	if l.Retain != nil && *l.Retain < 0 {
+		return fmt.Errorf("log-file retain must not be negative")
+	}
+	return nil
+}
+
+// MaxSizeBytes returns the size in bytes after which the file is rotated.
+func (l *LogFile) MaxSizeBytes() int64 {
+	if l.MaxSize == "" {
+		return defaultLogFileMaxSize
+	}
+	n, err := parseByteSize(l.MaxSize)
+	if err != nil {
+		return defaultLogFileMaxSize
+	}
+	return n
+}
+
+// RetainCount returns the number of rotated files to keep.
+func (l *LogFile) RetainCount() int {
+	if l.Retain == nil {
+		return defaultLogFileRetain
+	}
+	return *l.Retain
+}
+
 // Check specifies configuration for a single health check.
 type Check struct {
 	// Basic details
@@ -448,15 +814,28 @@ type Check struct {
 	Timeout   OptionalDuration `yaml:"timeout,omitempty"`
 	Threshold int              `yaml:"threshold,omitempty"`
 
+	// Service binds the check to a service: the check only runs while the
+	// service is running, and StartPeriod is counted from when it started.
+	Service     string           `yaml:"service,omitempty"`
+	StartPeriod OptionalDuration `yaml:"start-period,omitempty"`
+
+	// OnStatusChange is the action taken when the check's status changes.
+	OnStatusChange *CheckStatusAction `yaml:"on-status-change,omitempty"`
+
 	// Type-specific check settings (only one of these can be set)
 	HTTP *HTTPCheck `yaml:"http,omitempty"`
 	TCP  *TCPCheck  `yaml:"tcp,omitempty"`
 	Exec *ExecCheck `yaml:"exec,omitempty"`
+	GRPC *GRPCCheck `yaml:"grpc,omitempty"`
+	Log  *LogCheck  `yaml:"log,omitempty"`
 }
 
 // Copy returns a deep copy of the check configuration.
 func (c *Check) Copy() *Check {
 	copied := *c
+	if c.OnStatusChange != nil {
+		copied.OnStatusChange = c.OnStatusChange.Copy()
+	}
 	if c.HTTP != nil {
 		copied.HTTP = c.HTTP.Copy()
 	}
@@ -466,6 +845,12 @@ func (c *Check) Copy() *Check {
 	if c.Exec != nil {
 		copied.Exec = c.Exec.Copy()
 	}
+	if c.GRPC != nil {
+		copied.GRPC = c.GRPC.Copy()
+	}
+	if c.Log != nil {
+		copied.Log = c.Log.Copy()
+	}
 	return &copied
 }
 
@@ -486,6 +871,18 @@ func (c *Check) Merge(other *Check) {
 	if other.Threshold != 0 {
 		c.Threshold = other.Threshold
 	}
+	if other.Service != "" {
+		c.Service = other.Service
+	}
+	if other.StartPeriod.IsSet {
+		c.StartPeriod = other.StartPeriod
+	}
+	if other.OnStatusChange != nil {
+		if c.OnStatusChange == nil {
+			c.OnStatusChange = &CheckStatusAction{}
+		}
+		c.OnStatusChange.Merge(other.OnStatusChange)
+	}
 	if other.HTTP != nil {
 		if c.HTTP == nil {
 			c.HTTP = &HTTPCheck{}
@@ -504,9 +901,63 @@ func (c *Check) Merge(other *Check) {
 		}
 		c.Exec.Merge(other.Exec)
 	}
+	if other.GRPC != nil {
+		if c.GRPC == nil {
+			c.GRPC = &GRPCCheck{}
+		}
+		c.GRPC.Merge(other.GRPC)
+	}
+	if other.Log != nil {
+		if c.Log == nil {
+			c.Log = &LogCheck{}
+		}
+		c.Log.Merge(other.Log)
+	}
 }
 
-// CheckLevel specifies the optional check level.
+// CheckStatusAction holds the configuration of the action taken when a
+// check's status changes.
+type CheckStatusAction struct {
+	// Webhook is the URL that a JSON description of the change is POSTed to.
+	Webhook string `yaml:"webhook,omitempty"`
+
+	// Headers are the HTTP headers sent with the webhook request.
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Copy returns a deep copy of the status change action.
+func (a *CheckStatusAction) Copy() *CheckStatusAction {
+	copied := *a
+	copied.Headers = maps.Clone(a.Headers)
+	return &copied
+}
+
+// Merge merges the fields set in other into a.
+func (a *CheckStatusAction) Merge(other *CheckStatusAction) {
+	if other.Webhook != "" {
+		a.Webhook = other.Webhook
+	}
+	for k, v := range other.Headers {
+		if a.Headers == nil {
+			a.Headers = make(map[string]string)
+		}
+		a.Headers[k] = v
+	}
+}
+
+// Validate checks that the status change action is valid.
+func (a *CheckStatusAction) Validate() error {
+	if a.Webhook != "" {
+		u, err := url.Parse(a.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("on-status-change webhook %q invalid, must be an http or https URL", a.Webhook)
+		}
+	}
+	return nil
+}
+
+// CheckLevel specifies the optional check level. Besides the built-in levels
+// below, custom levels may be defined in the plan by a CheckLevelExtension.
 type CheckLevel string
 
 const (
@@ -528,12 +979,43 @@ const (
 type HTTPCheck struct {
 	URL     string            `yaml:"url,omitempty"`
 	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Method is the request method. Defaults to GET.
+	Method string `yaml:"method,omitempty"`
+
+	// Body is the request body.
+	Body string `yaml:"body,omitempty"`
+
+	// Status is the list of status codes that are successful. If empty,
+	// any 2xx status code is successful.
+	Status []int `yaml:"status,omitempty"`
+
+	// ResponseMatch is a regular expression the response body must match.
+	ResponseMatch string `yaml:"response-match,omitempty"`
+
+	// ResponseJSON maps paths in the JSON response body (such as
+	// "checks.db.status") to the values expected at those paths.
+	ResponseJSON map[string]string `yaml:"response-json,omitempty"`
+
+	// FollowRedirects determines whether redirects are followed (the
+	// default), or the redirect response is checked.
+	FollowRedirects *bool `yaml:"follow-redirects,omitempty"`
+
+	// TLS holds the options for checking HTTPS URLs.
+	TLS *CheckTLS `yaml:"tls,omitempty"`
 }
 
 // Copy returns a deep copy of the HTTP check configuration.
 func (c *HTTPCheck) Copy() *HTTPCheck {
 	copied := *c
 	copied.Headers = maps.Clone(c.Headers)
+	copied.Status = append([]int(nil), c.Status...)
+	copied.ResponseJSON = maps.Clone(c.ResponseJSON)
+	if c.FollowRedirects != nil {
+		followRedirects := *c.FollowRedirects
+		copied.FollowRedirects = &followRedirects
+	}
+	copied.TLS = c.TLS.Copy()
 	return &copied
 }
 
@@ -548,6 +1030,292 @@ func (c *HTTPCheck) Merge(other *HTTPCheck) {
 		}
 		c.Headers[k] = v
 	}
+	if other.Method != "" {
+		c.Method = other.Method
+	}
+	if other.Body != "" {
+		c.Body = other.Body
+	}
+	if len(other.Status) > 0 {
+		c.Status = append([]int(nil), other.Status...)
+	}
+	if other.ResponseMatch != "" {
+		c.ResponseMatch = other.ResponseMatch
+	}
+	for k, v := range other.ResponseJSON {
+		if c.ResponseJSON == nil {
+			c.ResponseJSON = make(map[string]string)
+		}
+		c.ResponseJSON[k] = v
+	}
+	if other.FollowRedirects != nil {
+		followRedirects := *other.FollowRedirects
+		c.FollowRedirects = &followRedirects
+	}
+	if other.TLS != nil {
+		if c.TLS == nil {
+			c.TLS = &CheckTLS{}
+		}
+		c.TLS.Merge(other.TLS)
+	}
+}
+
+var httpMethodRegexp = regexp.MustCompile(`^[A-Z]+$`)
+
+// Validate checks that the HTTP check configuration is valid.
+func (c *HTTPCheck) Validate() error {
+	if c.Method != "" && !httpMethodRegexp.MatchString(c.Method) {
+		return fmt.Errorf("method %q invalid", c.Method)
+	}
+	for _, status := range c.Status {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("status %d invalid", status)
+		}
+	}
+	if c.ResponseMatch != "" {
+		if _, err := regexp.Compile(c.ResponseMatch); err != nil {
+			return fmt.Errorf("response-match invalid: %v", err)
+		}
+	}
+	for path := range c.ResponseJSON {
+		if slices.Contains(strings.Split(path, "."), "") {
+			return fmt.Errorf("response-json path %q invalid", path)
+		}
+	}
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TLSOptions holds the options for connecting to a server using TLS, used
+// by health checks and log targets.
+type TLSOptions struct {
+	// CACert is the path of a PEM file with the CA certificates used to
+	// verify the server, instead of the system's CA certificates.
+	CACert string `yaml:"ca-cert,omitempty"`
+
+	// ClientCert and ClientKey are the paths of the PEM files with the
+	// client certificate and private key, for servers that require mutual
+	// TLS. Both or neither must be set.
+	ClientCert string `yaml:"client-cert,omitempty"`
+	ClientKey  string `yaml:"client-key,omitempty"`
+
+	// ServerName overrides the host name used to verify the server's
+	// certificate (and sent using SNI).
+	ServerName string `yaml:"server-name,omitempty"`
+
+	// InsecureSkipVerify disables verification of the server's certificate.
+	InsecureSkipVerify *bool `yaml:"insecure-skip-verify,omitempty"`
+}
+
+// Copy returns a copy of the TLS options, or nil if t is nil.
+func (t *TLSOptions) Copy() *TLSOptions {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	if t.InsecureSkipVerify != nil {
+		insecureSkipVerify := *t.InsecureSkipVerify
+		copied.InsecureSkipVerify = &insecureSkipVerify
+	}
+	return &copied
+}
+
+// Merge merges the fields set in other into t.
+func (t *TLSOptions) Merge(other *TLSOptions) {
+	if other.CACert != "" {
+		t.CACert = other.CACert
+	}
+	if other.ClientCert != "" {
+		t.ClientCert = other.ClientCert
+	}
+	if other.ClientKey != "" {
+		t.ClientKey = other.ClientKey
+	}
+	if other.ServerName != "" {
+		t.ServerName = other.ServerName
+	}
+	if other.InsecureSkipVerify != nil {
+		insecureSkipVerify := *other.InsecureSkipVerify
+		t.InsecureSkipVerify = &insecureSkipVerify
+	}
+}
+
+// Validate checks that the TLS options are valid.
+func (t *TLSOptions) Validate() error {
+	for _, path := range []struct{ field, value string }{
+		{"ca-cert", t.CACert},
+		{"client-cert", t.ClientCert},
+		{"client-key", t.ClientKey},
+	} {
+		if path.value != "" && !filepath.IsAbs(path.value) {
+			return fmt.Errorf("tls %s path %q must be absolute", path.field, path.value)
+		}
+	}
+	return nil
+}
+
+// CheckTLS holds the TLS options for an HTTP or gRPC health check.
+type CheckTLS struct {
+	TLSOptions `yaml:",inline"`
+
+	// ExpiryWarning is the time before the server's certificate expires at
+	// which a warning is recorded.
+	ExpiryWarning OptionalDuration `yaml:"expiry-warning,omitempty"`
+
+	// ExpiryFailure is the time before the server's certificate expires at
+	// which the check fails.
+	ExpiryFailure OptionalDuration `yaml:"expiry-failure,omitempty"`
+}
+
+// Copy returns a copy of the TLS options, or nil if t is nil.
+func (t *CheckTLS) Copy() *CheckTLS {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	copied.TLSOptions = *t.TLSOptions.Copy()
+	return &copied
+}
+
+// Merge merges the fields set in other into t.
+func (t *CheckTLS) Merge(other *CheckTLS) {
+	t.TLSOptions.Merge(&other.TLSOptions)
+	if other.ExpiryWarning.IsSet {
+		t.ExpiryWarning = other.ExpiryWarning
+	}
+	if other.ExpiryFailure.IsSet {
+		t.ExpiryFailure = other.ExpiryFailure
+	}
+}
+
+// Validate checks that the TLS options are valid.
+func (t *CheckTLS) Validate() error {
+	if err := t.TLSOptions.Validate(); err != nil {
+		return err
+	}
+	if t.ExpiryWarning.IsSet && t.ExpiryWarning.Value <= 0 {
+		return fmt.Errorf("tls expiry-warning must be greater than zero")
+	}
+	if t.ExpiryFailure.IsSet && t.ExpiryFailure.Value <= 0 {
+		return fmt.Errorf("tls expiry-failure must be greater than zero")
+	}
+	return nil
+}
+
+// GRPCCheck holds the configuration for a gRPC health check, which calls
+// the standard grpc.health.v1.Health/Check method.
+type GRPCCheck struct {
+	// Address is the host and port of the gRPC server, for example
+	// "localhost:50051".
+	Address string `yaml:"address,omitempty"`
+
+	// Service is the name of the service to check. If empty, the overall
+	// health of the server is checked.
+	Service string `yaml:"service,omitempty"`
+
+	// TLS, if set, makes the check connect to the server using TLS.
+	TLS *CheckTLS `yaml:"tls,omitempty"`
+}
+
+// Copy returns a deep copy of the gRPC check configuration.
+func (c *GRPCCheck) Copy() *GRPCCheck {
+	copied := *c
+	copied.TLS = c.TLS.Copy()
+	return &copied
+}
+
+// Merge merges the fields set in other into c.
+func (c *GRPCCheck) Merge(other *GRPCCheck) {
+	if other.Address != "" {
+		c.Address = other.Address
+	}
+	if other.Service != "" {
+		c.Service = other.Service
+	}
+	if other.TLS != nil {
+		if c.TLS == nil {
+			c.TLS = &CheckTLS{}
+		}
+		c.TLS.Merge(other.TLS)
+	}
+}
+
+// Validate checks that the gRPC check configuration is valid.
+func (c *GRPCCheck) Validate() error {
+	if c.Address != "" {
+		host, port, err := net.SplitHostPort(c.Address)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("grpc address %q invalid, must be host:port", c.Address)
+		}
+	}
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const defaultLogCheckWindow = time.Minute
+
+// LogCheck holds the configuration for a log health check, which fails
+// while a service's output matches a pattern.
+type LogCheck struct {
+	// Service is the name of the service whose output is checked.
+	Service string `yaml:"service,omitempty"`
+
+	// Pattern is the regular expression (RE2 syntax) matched against each
+	// line of the service's output.
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Window is how long a match makes the check fail. The check succeeds
+	// again once there have been no matches for this long. Defaults to one
+	// minute.
+	Window OptionalDuration `yaml:"window,omitempty"`
+}
+
+// Copy returns a deep copy of the log check configuration.
+func (c *LogCheck) Copy() *LogCheck {
+	copied := *c
+	return &copied
+}
+
+// Merge merges the fields set in other into c.
+func (c *LogCheck) Merge(other *LogCheck) {
+	if other.Service != "" {
+		c.Service = other.Service
+	}
+	if other.Pattern != "" {
+		c.Pattern = other.Pattern
+	}
+	if other.Window.IsSet {
+		c.Window = other.Window
+	}
+}
+
+// Validate checks that the log check configuration is valid.
+func (c *LogCheck) Validate() error {
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("log pattern invalid: %v", err)
+		}
+	}
+	if c.Window.IsSet && c.Window.Value <= 0 {
+		return fmt.Errorf("log window must be greater than zero")
+	}
+	return nil
+}
+
+// WindowDuration returns the window, or the default if it isn't set.
+func (c *LogCheck) WindowDuration() time.Duration {
+	if !c.Window.IsSet {
+		return defaultLogCheckWindow
+	}
+	return c.Window.Value
 }
 
 // TCPCheck holds the configuration for an HTTP health check.
@@ -636,6 +1404,125 @@ type LogTarget struct {
 	Services []string          `yaml:"services"`
 	Override Override          `yaml:"override,omitempty"`
 	Labels   map[string]string `yaml:"labels,omitempty"`
+
+	// Headers are extra HTTP headers sent with each request. Not supported
+	// by syslog targets.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// BasicAuth, if set, is used to authenticate each request with HTTP
+	// basic authentication. Not supported by syslog targets.
+	BasicAuth *LogTargetBasicAuth `yaml:"basic-auth,omitempty"`
+
+	// BearerTokenFile is the path of a file containing a bearer token which
+	// is sent in the Authorization header of each request. The file is read
+	// before each request, so the token can be rotated. Not supported by
+	// syslog targets.
+	BearerTokenFile string `yaml:"bearer-token-file,omitempty"`
+
+	// TLS holds the options for connecting to the target using TLS: HTTPS
+	// locations, or syslog over TCP.
+	TLS *TLSOptions `yaml:"tls,omitempty"`
+
+	// Spool, if set, queues logs on disk while the target is unreachable,
+	// so they can be sent once it's back.
+	Spool *LogTargetSpool `yaml:"spool,omitempty"`
+
+	// The following fields are only supported by http-json targets.
+
+	// Gzip enables gzip compression of request bodies.
+	Gzip *bool `yaml:"gzip,omitempty"`
+
+	// BatchSize is the maximum number of logs sent in each request.
+	// Defaults to 100.
+	BatchSize int `yaml:"batch-size,omitempty"`
+}
+
+// LogTargetBasicAuth holds the credentials for HTTP basic authentication.
+type LogTargetBasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// LogTargetSpool holds the options for a log target's on-disk queue.
+type LogTargetSpool struct {
+	// MaxSize is the maximum size of the queue on disk, with an optional K,
+	// M, G or T suffix (powers of 1024). When it's full, the oldest logs are
+	// dropped. Defaults to 100M.
+	MaxSize string `yaml:"max-size,omitempty"`
+
+	// BackoffLimit is the maximum delay between attempts to send queued
+	// logs. The delay starts at one second and doubles after each failed
+	// attempt. Defaults to 5 minutes.
+	BackoffLimit OptionalDuration `yaml:"backoff-limit,omitempty"`
+}
+
+// Copy returns a copy of the spool options, or nil if s is nil.
+func (s *LogTargetSpool) Copy() *LogTargetSpool {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	return &copied
+}
+
+// Merge merges the fields set in other into s.
+func (s *LogTargetSpool) Merge(other *LogTargetSpool) {
+	if other.MaxSize != "" {
+		s.MaxSize = other.MaxSize
+	}
+	if other.BackoffLimit.IsSet {
+		s.BackoffLimit = other.BackoffLimit
+	}
+}
+
+// Validate checks that the spool options are valid.
+func (s *LogTargetSpool) Validate() error {
+	if s.MaxSize != "" {
+		if _, err := parseByteSize(s.MaxSize); err != nil {
+			return fmt.Errorf("spool max-size %q invalid", s.MaxSize)
+		}
+	}
+	if s.BackoffLimit.IsSet && s.BackoffLimit.Value <= 0 {
+		return fmt.Errorf("spool backoff-limit must be greater than zero")
+	}
+	return nil
+}
+
+// MaxSizeBytes returns the maximum size of the queue in bytes.
+func (s *LogTargetSpool) MaxSizeBytes() int64 {
+	if s.MaxSize == "" {
+		return defaultSpoolMaxSize
+	}
+	n, err := parseByteSize(s.MaxSize)
+	if err != nil {
+		return defaultSpoolMaxSize
+	}
+	return n
+}
+
+// BackoffLimitDuration returns the maximum delay between attempts to send
+// queued logs.
+func (s *LogTargetSpool) BackoffLimitDuration() time.Duration {
+	if !s.BackoffLimit.IsSet {
+		return defaultSpoolBackoffLimit
+	}
+	return s.BackoffLimit.Value
+}
+
+// redactedSecret replaces secrets in the output of Redacted.
+const redactedSecret = "*****"
+
+// Redacted returns a copy of the log target with its secrets (the basic
+// auth password and the header values) redacted, for displaying the plan.
+func (t *LogTarget) Redacted() *LogTarget {
+	redacted := t.Copy()
+	if redacted.BasicAuth != nil && redacted.BasicAuth.Password != "" {
+		redacted.BasicAuth.Password = redactedSecret
+	}
+	for k := range redacted.Headers {
+		redacted.Headers[k] = redactedSecret
+	}
+	return redacted
 }
 
 // LogTargetType defines the protocol to use to forward logs.
@@ -645,6 +1532,7 @@ const (
 	LokiTarget          LogTargetType = "loki"
 	OpenTelemetryTarget LogTargetType = "opentelemetry"
 	SyslogTarget        LogTargetType = "syslog"
+	HTTPJSONTarget      LogTargetType = "http-json"
 	UnsetLogTarget      LogTargetType = ""
 )
 
@@ -653,6 +1541,17 @@ func (t *LogTarget) Copy() *LogTarget {
 	copied := *t
 	copied.Services = append([]string(nil), t.Services...)
 	copied.Labels = maps.Clone(t.Labels)
+	copied.Headers = maps.Clone(t.Headers)
+	if t.BasicAuth != nil {
+		basicAuth := *t.BasicAuth
+		copied.BasicAuth = &basicAuth
+	}
+	copied.TLS = t.TLS.Copy()
+	copied.Spool = t.Spool.Copy()
+	if t.Gzip != nil {
+		gzip := *t.Gzip
+		copied.Gzip = &gzip
+	}
 	return &copied
 }
 
@@ -671,6 +1570,38 @@ func (t *LogTarget) Merge(other *LogTarget) {
 		}
 		t.Labels[k] = v
 	}
+	for k, v := range other.Headers {
+		if t.Headers == nil {
+			t.Headers = make(map[string]string)
+		}
+		t.Headers[k] = v
+	}
+	if other.BasicAuth != nil {
+		basicAuth := *other.BasicAuth
+		t.BasicAuth = &basicAuth
+	}
+	if other.BearerTokenFile != "" {
+		t.BearerTokenFile = other.BearerTokenFile
+	}
+	if other.TLS != nil {
+		if t.TLS == nil {
+			t.TLS = &TLSOptions{}
+		}
+		t.TLS.Merge(other.TLS)
+	}
+	if other.Spool != nil {
+		if t.Spool == nil {
+			t.Spool = &LogTargetSpool{}
+		}
+		t.Spool.Merge(other.Spool)
+	}
+	if other.Gzip != nil {
+		gzip := *other.Gzip
+		t.Gzip = &gzip
+	}
+	if other.BatchSize != 0 {
+		t.BatchSize = other.BatchSize
+	}
 }
 
 // FormatError is the error returned when a layer has a format error, such as
@@ -874,6 +1805,20 @@ func (layer *Layer) Validate() error {
 				Message: fmt.Sprintf("plan service %q command invalid: %v", name, err),
 			}
 		}
+		switch service.Type {
+		case TypeUnset, TypeDaemon, TypeTask:
+		default:
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q type must be "daemon" or "task"`, name),
+			}
+		}
+		switch service.KillMode {
+		case KillModeUnset, KillModeProcess, KillModeGroup, KillModeCgroup:
+		default:
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q kill-mode must be "process", "group" or "cgroup"`, name),
+			}
+		}
 		if !validServiceAction(service.OnSuccess, ActionFailureShutdown) {
 			return &FormatError{
 				Message: fmt.Sprintf("plan service %q on-success action %q invalid", name, service.OnSuccess),
@@ -896,6 +1841,54 @@ func (layer *Layer) Validate() error {
 				Message: fmt.Sprintf("plan service %q backoff-factor must be 1.0 or greater, not %g", name, service.BackoffFactor.Value),
 			}
 		}
+		if service.Resources != nil {
+			if err := service.Resources.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan service %q %v", name, err),
+				}
+			}
+		}
+		if service.LogFile != nil {
+			if err := service.LogFile.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan service %q %v", name, err),
+				}
+			}
+		}
+		switch service.LogFormat {
+		case LogFormatUnset, LogFormatPlain, LogFormatJSON, LogFormatLogfmt:
+		default:
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q log-format must be "plain", "json" or "logfmt"`, name),
+			}
+		}
+		if service.AfterReadyTimeout.IsSet && service.AfterReadyTimeout.Value <= 0 {
+			return &FormatError{
+				Message: fmt.Sprintf("plan service %q after-ready-timeout must be greater than zero", name),
+			}
+		}
+		if service.Schedule != "" {
+			_, err := timeutil.ParseSchedule(service.Schedule)
+			if err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan service %q schedule invalid: %v", name, err),
+				}
+			}
+		}
+		switch service.ScheduleOverlap {
+		case OverlapUnset, OverlapSkip, OverlapQueue, OverlapKill:
+		default:
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q schedule-overlap must be "skip", "queue" or "kill"`, name),
+			}
+		}
+		switch service.ScheduleMisfire {
+		case MisfireUnset, MisfireSkip, MisfireRun:
+		default:
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q schedule-misfire must be "skip" or "run"`, name),
+			}
+		}
 	}
 
 	for name, check := range layer.Checks {
@@ -914,7 +1907,7 @@ func (layer *Layer) Validate() error {
 				Message: "cannot use empty string as log target name",
 			}
 		}
-		if check.Level != UnsetLevel && check.Level != AliveLevel && check.Level != ReadyLevel {
+		if check.Level != UnsetLevel && check.Level != AliveLevel && check.Level != ReadyLevel && !customCheckLevels() {
 			return &FormatError{
 				Message: fmt.Sprintf(`plan check %q level must be "alive" or "ready"`, name),
 			}
@@ -934,7 +1927,40 @@ func (layer *Layer) Validate() error {
 				Message: fmt.Sprintf("plan check %q timeout must not be zero", name),
 			}
 		}
+		if check.StartPeriod.Value < 0 {
+			return &FormatError{
+				Message: fmt.Sprintf("plan check %q start-period must not be negative", name),
+			}
+		}
 
+		if check.OnStatusChange != nil {
+			if err := check.OnStatusChange.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan check %q %v", name, err),
+				}
+			}
+		}
+		if check.HTTP != nil {
+			if err := check.HTTP.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan check %q %v", name, err),
+				}
+			}
+		}
+		if check.GRPC != nil {
+			if err := check.GRPC.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan check %q %v", name, err),
+				}
+			}
+		}
+		if check.Log != nil {
+			if err := check.Log.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("plan check %q %v", name, err),
+				}
+			}
+		}
 		if check.Exec != nil {
 			_, err := shlex.Split(check.Exec.Command)
 			if err != nil {
@@ -966,14 +1992,38 @@ func (layer *Layer) Validate() error {
 			}
 		}
 		switch target.Type {
-		case LokiTarget, OpenTelemetryTarget, SyslogTarget:
+		case LokiTarget, OpenTelemetryTarget, SyslogTarget, HTTPJSONTarget:
 			// valid, continue
 		case UnsetLogTarget:
 			// will be checked when the layers are combined
 		default:
 			return &FormatError{
-				Message: fmt.Sprintf(`log target %q has unsupported type %q, must be %q, %q or %q`,
-					name, target.Type, LokiTarget, OpenTelemetryTarget, SyslogTarget),
+				Message: fmt.Sprintf(`log target %q has unsupported type %q, must be %q, %q, %q or %q`,
+					name, target.Type, LokiTarget, OpenTelemetryTarget, SyslogTarget, HTTPJSONTarget),
+			}
+		}
+		if target.BasicAuth != nil && target.BasicAuth.Username == "" {
+			return &FormatError{
+				Message: fmt.Sprintf(`log target %q basic-auth must specify "username"`, name),
+			}
+		}
+		if target.BatchSize < 0 {
+			return &FormatError{
+				Message: fmt.Sprintf("log target %q batch-size must not be negative", name),
+			}
+		}
+		if target.TLS != nil {
+			if err := target.TLS.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("log target %q %v", name, err),
+				}
+			}
+		}
+		if target.Spool != nil {
+			if err := target.Spool.Validate(); err != nil {
+				return &FormatError{
+					Message: fmt.Sprintf("log target %q %v", name, err),
+				}
 			}
 		}
 	}
@@ -997,9 +2047,44 @@ func (p *Plan) Validate() error {
 				Message: fmt.Sprintf(`plan must define "command" for service %q`, name),
 			}
 		}
+		if service.LogFile != nil && service.LogFile.Path == "" {
+			return &FormatError{
+				Message: fmt.Sprintf(`plan must define "path" for service %q log-file`, name),
+			}
+		}
+		if service.Schedule != "" && service.Startup == StartupEnabled {
+			// Scheduled services are started by the scheduler, not when
+			// Pebble starts up or replans.
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q cannot have a schedule and "startup: enabled"`, name),
+			}
+		}
+		if service.Type == TypeTask && service.OnSuccess == ActionRestart {
+			// A task that succeeded is done; use a schedule to run it again.
+			return &FormatError{
+				Message: fmt.Sprintf(`plan service %q of type "task" cannot have "on-success: restart"`, name),
+			}
+		}
+		for _, checkName := range service.AfterReady {
+			if _, ok := p.Checks[checkName]; !ok {
+				return &FormatError{
+					Message: fmt.Sprintf("plan service %q after-ready specifies non-existent check %q", name, checkName),
+				}
+			}
+		}
 	}
 
 	for name, check := range p.Checks {
+		if _, ok := p.Services[check.Service]; check.Service != "" && !ok {
+			return &FormatError{
+				Message: fmt.Sprintf("plan check %q specifies non-existent service %q", name, check.Service),
+			}
+		}
+		if check.OnStatusChange != nil && check.OnStatusChange.Webhook == "" {
+			return &FormatError{
+				Message: fmt.Sprintf(`plan must set "webhook" for check %q on-status-change`, name),
+			}
+		}
 		numTypes := 0
 		if check.HTTP != nil {
 			if check.HTTP.URL == "" {
@@ -1007,6 +2092,43 @@ func (p *Plan) Validate() error {
 					Message: fmt.Sprintf(`plan must set "url" for http check %q`, name),
 				}
 			}
+			if tls := check.HTTP.TLS; tls != nil && (tls.ClientCert == "") != (tls.ClientKey == "") {
+				return &FormatError{
+					Message: fmt.Sprintf(`plan must set both "client-cert" and "client-key" for http check %q`, name),
+				}
+			}
+			numTypes++
+		}
+		if check.GRPC != nil {
+			if check.GRPC.Address == "" {
+				return &FormatError{
+					Message: fmt.Sprintf(`plan must set "address" for grpc check %q`, name),
+				}
+			}
+			if tls := check.GRPC.TLS; tls != nil && (tls.ClientCert == "") != (tls.ClientKey == "") {
+				return &FormatError{
+					Message: fmt.Sprintf(`plan must set both "client-cert" and "client-key" for grpc check %q`, name),
+				}
+			}
+			numTypes++
+		}
+		if check.Log != nil {
+			if check.Log.Service == "" {
+				return &FormatError{
+					Message: fmt.Sprintf(`plan must set "service" for log check %q`, name),
+				}
+			}
+			if check.Log.Pattern == "" {
+				return &FormatError{
+					Message: fmt.Sprintf(`plan must set "pattern" for log check %q`, name),
+				}
+			}
+			if _, ok := p.Services[check.Log.Service]; !ok {
+				return &FormatError{
+					Message: fmt.Sprintf("plan check %q log service specifies non-existent service %q",
+						name, check.Log.Service),
+				}
+			}
 			numTypes++
 		}
 		if check.TCP != nil {
@@ -1034,26 +2156,73 @@ func (p *Plan) Validate() error {
 		}
 		if numTypes != 1 {
 			return &FormatError{
-				Message: fmt.Sprintf(`plan must specify one of "http", "tcp", or "exec" for check %q`, name),
+				Message: fmt.Sprintf(`plan must specify one of "http", "tcp", "exec", "grpc", or "log" for check %q`, name),
 			}
 		}
 	}
 
 	for name, target := range p.LogTargets {
 		switch target.Type {
-		case LokiTarget, OpenTelemetryTarget, SyslogTarget:
+		case LokiTarget, OpenTelemetryTarget, SyslogTarget, HTTPJSONTarget:
 			// valid, continue
 		case UnsetLogTarget:
 			return &FormatError{
-				Message: fmt.Sprintf(`plan must define "type" (%q, %q or %q) for log target %q`,
-					LokiTarget, OpenTelemetryTarget, SyslogTarget, name),
+				Message: fmt.Sprintf(`plan must define "type" (%q, %q, %q or %q) for log target %q`,
+					LokiTarget, OpenTelemetryTarget, SyslogTarget, HTTPJSONTarget, name),
+			}
+		}
+
+		if target.Type == SyslogTarget {
+			var field string
+			switch {
+			case len(target.Headers) > 0:
+				field = "headers"
+			case target.BasicAuth != nil:
+				field = "basic-auth"
+			case target.BearerTokenFile != "":
+				field = "bearer-token-file"
+			}
+			if field != "" {
+				return &FormatError{
+					Message: fmt.Sprintf(`log target %q of type %q cannot set %q`, name, target.Type, field),
+				}
+			}
+			if target.TLS != nil && strings.HasPrefix(target.Location, "udp:") {
+				return &FormatError{
+					Message: fmt.Sprintf(`log target %q cannot set "tls" for a UDP syslog location`, name),
+				}
+			}
+		}
+		if target.Type != HTTPJSONTarget {
+			var field string
+			switch {
+			case target.Gzip != nil:
+				field = "gzip"
+			case target.BatchSize != 0:
+				field = "batch-size"
+			}
+			if field != "" {
+				return &FormatError{
+					Message: fmt.Sprintf(`log target %q of type %q cannot set %q (only supported by %q targets)`,
+						name, target.Type, field, HTTPJSONTarget),
+				}
+			}
+		}
+		if tls := target.TLS; tls != nil && (tls.ClientCert == "") != (tls.ClientKey == "") {
+			return &FormatError{
+				Message: fmt.Sprintf(`plan must set both "client-cert" and "client-key" for log target %q`, name),
+			}
+		}
+		if target.BasicAuth != nil && target.BearerTokenFile != "" {
+			return &FormatError{
+				Message: fmt.Sprintf(`log target %q cannot set both "basic-auth" and "bearer-token-file"`, name),
 			}
 		}
 
 		// Validate service names specified in log target.
 		for _, serviceName := range target.Services {
 			serviceName = strings.TrimPrefix(serviceName, "-")
-			if serviceName == "all" {
+			if serviceName == "all" || slices.Contains(LogSources, serviceName) {
 				continue
 			}
 			if _, ok := p.Services[serviceName]; ok {
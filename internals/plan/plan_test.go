@@ -581,92 +581,1045 @@ var planTests = []planTest{{
 				override: replace
 				command: cmd -v [ foo [ --bar ] ]
 	`},
+}, {
+	summary: "Scheduled service fields parse and merge correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				schedule: "02:00"
+				schedule-overlap: queue
+			"svc2":
+				override: replace
+				command: cmd
+	`, `
+		services:
+			"svc1":
+				override: merge
+				schedule-misfire: run
+			"svc2":
+				override: merge
+				schedule: "mon-fri,09:00-17:00/8"
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:            "svc1",
+				Override:        "replace",
+				Command:         "cmd",
+				Schedule:        "02:00",
+				ScheduleOverlap: plan.OverlapQueue,
+				ScheduleMisfire: plan.MisfireRun,
+				BackoffDelay:    plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor:   plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:    plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+			"svc2": {
+				Name:          "svc2",
+				Override:      "replace",
+				Command:       "cmd",
+				Schedule:      "mon-fri,09:00-17:00/8",
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Invalid service schedule`,
+	error:   `plan service "svc1" schedule invalid: cannot parse "25:00": not a valid time`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				schedule: "25:00"
+	`},
+}, {
+	summary: `Invalid service schedule-overlap`,
+	error:   `plan service "svc1" schedule-overlap must be "skip", "queue" or "kill"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				schedule: "02:00"
+				schedule-overlap: foo
+	`},
+}, {
+	summary: `Invalid service schedule-misfire`,
+	error:   `plan service "svc1" schedule-misfire must be "skip" or "run"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				schedule: "02:00"
+				schedule-misfire: foo
+	`},
+}, {
+	summary: "Service log-format parses and merges correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-format: json
+			"svc2":
+				override: replace
+				command: cmd
+				log-format: json
+	`, `
+		services:
+			"svc2":
+				override: merge
+				log-format: logfmt
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:          "svc1",
+				Override:      "replace",
+				Command:       "cmd",
+				LogFormat:     plan.LogFormatJSON,
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+			"svc2": {
+				Name:          "svc2",
+				Override:      "replace",
+				Command:       "cmd",
+				LogFormat:     plan.LogFormatLogfmt,
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Invalid service log-format`,
+	error:   `plan service "svc1" log-format must be "plain", "json" or "logfmt"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-format: xml
+	`},
+}, {
+	summary: `Scheduled service cannot be started on startup`,
+	error:   `plan service "svc1" cannot have a schedule and "startup: enabled"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				schedule: "02:00"
+				startup: enabled
+	`},
+}, {
+	summary: "Service type parses and merges correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				type: task
+	`, `
+		services:
+			"svc1":
+				override: merge
+				on-failure: ignore
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:          "svc1",
+				Override:      "replace",
+				Command:       "cmd",
+				Type:          plan.TypeTask,
+				OnFailure:     plan.ActionIgnore,
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Invalid service type`,
+	error:   `plan service "svc1" type must be "daemon" or "task"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				type: oneshot
+	`},
+}, {
+	summary: "Service kill-mode parses and merges correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				kill-mode: process
+	`, `
+		services:
+			"svc1":
+				override: merge
+				kill-mode: cgroup
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:          "svc1",
+				Override:      "replace",
+				Command:       "cmd",
+				KillMode:      plan.KillModeCgroup,
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Invalid service kill-mode`,
+	error:   `plan service "svc1" kill-mode must be "process", "group" or "cgroup"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				kill-mode: mixed
+	`},
+}, {
+	summary: `Task service cannot restart on success`,
+	error:   `plan service "svc1" of type "task" cannot have "on-success: restart"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				type: task
+				on-success: restart
+	`},
+}, {
+	summary: "Service after-ready fields parse and merge correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				after-ready:
+					- chk1
+				after-ready-timeout: 30s
+		checks:
+			chk1:
+				override: replace
+				exec:
+					command: check1
+			chk2:
+				override: replace
+				exec:
+					command: check2
+	`, `
+		services:
+			"svc1":
+				override: merge
+				after-ready:
+					- chk2
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:              "svc1",
+				Override:          "replace",
+				Command:           "cmd",
+				AfterReady:        []string{"chk1", "chk2"},
+				AfterReadyTimeout: plan.OptionalDuration{Value: 30 * time.Second, IsSet: true},
+				BackoffDelay:      plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor:     plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:      plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks: map[string]*plan.Check{
+			"chk1": {
+				Name:      "chk1",
+				Override:  "replace",
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				Exec:      &plan.ExecCheck{Command: "check1"},
+			},
+			"chk2": {
+				Name:      "chk2",
+				Override:  "replace",
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				Exec:      &plan.ExecCheck{Command: "check2"},
+			},
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Service after-ready must refer to an existing check`,
+	error:   `plan service "svc1" after-ready specifies non-existent check "chk1"`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				after-ready:
+					- chk1
+	`},
+}, {
+	summary: `Service after-ready-timeout must be positive`,
+	error:   `plan service "svc1" after-ready-timeout must be greater than zero`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				after-ready-timeout: 0s
+	`},
+}, {
+	summary: "Service resources parse and merge correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				resources:
+					memory-max: 512M
+					cpu-max: 0.5
+	`, `
+		services:
+			"svc1":
+				override: merge
+				resources:
+					memory-max: 1G
+					pids-max: 100
+					io-weight: 200
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:     "svc1",
+				Override: "replace",
+				Command:  "cmd",
+				Resources: &plan.Resources{
+					MemoryMax: "1G",
+					CPUMax:    plan.OptionalFloat{Value: 0.5, IsSet: true},
+					PidsMax:   100,
+					IOWeight:  200,
+				},
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Invalid service memory-max`,
+	error:   `plan service "svc1" memory-max "12X" invalid`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				resources:
+					memory-max: 12X
+	`},
+}, {
+	summary: `Invalid service cpu-max`,
+	error:   `plan service "svc1" cpu-max must be greater than zero`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				resources:
+					cpu-max: 0
+	`},
+}, {
+	summary: `Invalid service io-weight`,
+	error:   `plan service "svc1" io-weight must be between 1 and 10000`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				resources:
+					io-weight: 20000
+	`},
+}, {
+	summary: "Service log-file parses and merges correctly",
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-file:
+					path: /var/log/svc1.log
+					max-size: 1M
+					retain: 3
+	`, `
+		services:
+			"svc1":
+				override: merge
+				log-file:
+					max-age: 24h
+					compress: true
+					retain: 0
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:     "svc1",
+				Override: "replace",
+				Command:  "cmd",
+				LogFile: &plan.LogFile{
+					Path:     "/var/log/svc1.log",
+					MaxSize:  "1M",
+					MaxAge:   plan.OptionalDuration{Value: 24 * time.Hour, IsSet: true},
+					Compress: newBool(true),
+					Retain:   newInt(0),
+				},
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks:     map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: `Service log-file without path`,
+	error:   `plan must define "path" for service "svc1" log-file`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-file:
+					max-size: 1M
+	`},
+}, {
+	summary: `Service log-file with relative path`,
+	error:   `plan service "svc1" log-file path "svc1.log" must be absolute`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-file:
+					path: svc1.log
+	`},
+}, {
+	summary: `Invalid service log-file max-size`,
+	error:   `plan service "svc1" log-file max-size "10Q" invalid`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-file:
+					path: /var/log/svc1.log
+					max-size: 10Q
+	`},
+}, {
+	summary: `Invalid service log-file retain`,
+	error:   `plan service "svc1" log-file retain must not be negative`,
+	input: []string{`
+		services:
+			"svc1":
+				override: replace
+				command: cmd
+				log-file:
+					path: /var/log/svc1.log
+					retain: -1
+	`},
 }, {
 	summary: "Checks fields parse correctly and defaults are correct",
 	input: []string{`
 		checks:
 			chk-http:
 				override: replace
-				level: alive
-				period: 20s
-				timeout: 500ms
-				threshold: 7
+				level: alive
+				period: 20s
+				timeout: 500ms
+				threshold: 7
+				http:
+					url: https://example.com/foo
+					headers:
+						Foo: bar
+						Authorization: Basic password
+		
+			chk-tcp:
+				override: merge
+				level: ready
+				startup: enabled
+				tcp:
+					port: 7777
+					host: somehost
+		
+			chk-exec:
+				override: replace
+				startup: disabled
+				exec:
+					command: sleep 1
+					environment:
+						FOO: bar
+						BAZ: buzz
+					working-dir: /root
+`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks: map[string]*plan.Check{
+			"chk-http": {
+				Name:      "chk-http",
+				Override:  plan.ReplaceOverride,
+				Level:     plan.AliveLevel,
+				Period:    plan.OptionalDuration{Value: 20 * time.Second, IsSet: true},
+				Timeout:   plan.OptionalDuration{Value: 500 * time.Millisecond, IsSet: true},
+				Threshold: 7,
+				HTTP: &plan.HTTPCheck{
+					URL: "https://example.com/foo",
+					Headers: map[string]string{
+						"Foo":           "bar",
+						"Authorization": "Basic password",
+					},
+				},
+			},
+			"chk-tcp": {
+				Name:      "chk-tcp",
+				Override:  plan.MergeOverride,
+				Level:     plan.ReadyLevel,
+				Startup:   plan.CheckStartupEnabled,
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				TCP: &plan.TCPCheck{
+					Port: 7777,
+					Host: "somehost",
+				},
+			},
+			"chk-exec": {
+				Name:      "chk-exec",
+				Override:  plan.ReplaceOverride,
+				Level:     plan.UnsetLevel,
+				Startup:   plan.CheckStartupDisabled,
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				Exec: &plan.ExecCheck{
+					Command: "sleep 1",
+					Environment: map[string]string{
+						"FOO": "bar",
+						"BAZ": "buzz",
+					},
+					WorkingDir: "/root",
+				},
+			},
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "HTTP check request and response options parse and merge correctly",
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+					method: POST
+					body: '{"probe": true}'
+					status: [200, 202]
+					response-json:
+						status: ok
+	`, `
+		checks:
+			chk-http:
+				override: merge
+				http:
+					status: [204]
+					response-match: "^OK"
+					response-json:
+						checks.db: up
+					follow-redirects: false
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks: map[string]*plan.Check{
+			"chk-http": {
+				Name:      "chk-http",
+				Override:  plan.ReplaceOverride,
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				HTTP: &plan.HTTPCheck{
+					URL:           "https://example.com/foo",
+					Method:        "POST",
+					Body:          `{"probe": true}`,
+					Status:        []int{204},
+					ResponseMatch: "^OK",
+					ResponseJSON: map[string]string{
+						"status":    "ok",
+						"checks.db": "up",
+					},
+					FollowRedirects: newBool(false),
+				},
+			},
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "Invalid HTTP check method",
+	error:   `plan check "chk-http" method "get" invalid`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
 				http:
 					url: https://example.com/foo
-					headers:
-						Foo: bar
-						Authorization: Basic password
-		
-			chk-tcp:
-				override: merge
-				level: ready
-				startup: enabled
-				tcp:
-					port: 7777
-					host: somehost
-		
-			chk-exec:
+					method: get
+	`},
+}, {
+	summary: "Invalid HTTP check status",
+	error:   `plan check "chk-http" status 1000 invalid`,
+	input: []string{`
+		checks:
+			chk-http:
 				override: replace
-				startup: disabled
-				exec:
-					command: sleep 1
-					environment:
-						FOO: bar
-						BAZ: buzz
-					working-dir: /root
-`},
+				http:
+					url: https://example.com/foo
+					status: [200, 1000]
+	`},
+}, {
+	summary: "Invalid HTTP check response-match",
+	error:   `plan check "chk-http" response-match invalid: .*`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+					response-match: "[a-"
+	`},
+}, {
+	summary: "Invalid HTTP check response-json path",
+	error:   `plan check "chk-http" response-json path "checks..db" invalid`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+					response-json:
+						checks..db: up
+	`},
+}, {
+	summary: "HTTP check TLS options parse and merge correctly",
+	input: []string{`
+		checks:
+			chk-https:
+				override: replace
+				http:
+					url: https://example.com/foo
+					tls:
+						ca-cert: /etc/ssl/private-ca.pem
+						server-name: internal.example.com
+						expiry-warning: 168h
+	`, `
+		checks:
+			chk-https:
+				override: merge
+				http:
+					tls:
+						client-cert: /etc/ssl/client.pem
+						client-key: /etc/ssl/client.key
+						insecure-skip-verify: false
+						expiry-failure: 24h
+	`},
 	result: &plan.Layer{
 		Services: map[string]*plan.Service{},
 		Checks: map[string]*plan.Check{
-			"chk-http": {
-				Name:      "chk-http",
+			"chk-https": {
+				Name:      "chk-https",
 				Override:  plan.ReplaceOverride,
-				Level:     plan.AliveLevel,
-				Period:    plan.OptionalDuration{Value: 20 * time.Second, IsSet: true},
-				Timeout:   plan.OptionalDuration{Value: 500 * time.Millisecond, IsSet: true},
-				Threshold: 7,
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
 				HTTP: &plan.HTTPCheck{
 					URL: "https://example.com/foo",
-					Headers: map[string]string{
-						"Foo":           "bar",
-						"Authorization": "Basic password",
+					TLS: &plan.CheckTLS{
+						TLSOptions: plan.TLSOptions{
+							CACert:             "/etc/ssl/private-ca.pem",
+							ClientCert:         "/etc/ssl/client.pem",
+							ClientKey:          "/etc/ssl/client.key",
+							ServerName:         "internal.example.com",
+							InsecureSkipVerify: newBool(false),
+						},
+						ExpiryWarning: plan.OptionalDuration{Value: 168 * time.Hour, IsSet: true},
+						ExpiryFailure: plan.OptionalDuration{Value: 24 * time.Hour, IsSet: true},
 					},
 				},
 			},
-			"chk-tcp": {
-				Name:      "chk-tcp",
-				Override:  plan.MergeOverride,
-				Level:     plan.ReadyLevel,
-				Startup:   plan.CheckStartupEnabled,
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "Invalid HTTP check TLS path",
+	error:   `plan check "chk-https" tls ca-cert path "ca.pem" must be absolute`,
+	input: []string{`
+		checks:
+			chk-https:
+				override: replace
+				http:
+					url: https://example.com/foo
+					tls:
+						ca-cert: ca.pem
+	`},
+}, {
+	summary: "Invalid HTTP check TLS expiry-warning",
+	error:   `plan check "chk-https" tls expiry-warning must be greater than zero`,
+	input: []string{`
+		checks:
+			chk-https:
+				override: replace
+				http:
+					url: https://example.com/foo
+					tls:
+						expiry-warning: 0s
+	`},
+}, {
+	summary: "HTTP check TLS client-cert without client-key",
+	error:   `plan must set both "client-cert" and "client-key" for http check "chk-https"`,
+	input: []string{`
+		checks:
+			chk-https:
+				override: replace
+				http:
+					url: https://example.com/foo
+					tls:
+						client-cert: /etc/ssl/client.pem
+	`},
+}, {
+	summary: "gRPC check parses and merges correctly",
+	input: []string{`
+		checks:
+			chk-grpc:
+				override: replace
+				grpc:
+					address: localhost:50051
+	`, `
+		checks:
+			chk-grpc:
+				override: merge
+				grpc:
+					service: orders.v1.Orders
+					tls:
+						ca-cert: /etc/ssl/private-ca.pem
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks: map[string]*plan.Check{
+			"chk-grpc": {
+				Name:      "chk-grpc",
+				Override:  plan.ReplaceOverride,
 				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
 				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
 				Threshold: defaultCheckThreshold,
-				TCP: &plan.TCPCheck{
-					Port: 7777,
-					Host: "somehost",
+				GRPC: &plan.GRPCCheck{
+					Address: "localhost:50051",
+					Service: "orders.v1.Orders",
+					TLS: &plan.CheckTLS{
+						TLSOptions: plan.TLSOptions{CACert: "/etc/ssl/private-ca.pem"},
+					},
 				},
 			},
-			"chk-exec": {
-				Name:      "chk-exec",
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "Log check parses and merges correctly",
+	input: []string{`
+		services:
+			svc1:
+				override: replace
+				command: cmd
+		checks:
+			chk-log:
+				override: replace
+				log:
+					service: svc1
+					pattern: "panic:"
+	`, `
+		checks:
+			chk-log:
+				override: merge
+				log:
+					pattern: "(panic|fatal):"
+					window: 5m
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:          "svc1",
+				Override:      plan.ReplaceOverride,
+				Command:       "cmd",
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks: map[string]*plan.Check{
+			"chk-log": {
+				Name:      "chk-log",
 				Override:  plan.ReplaceOverride,
-				Level:     plan.UnsetLevel,
-				Startup:   plan.CheckStartupDisabled,
 				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
 				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
 				Threshold: defaultCheckThreshold,
-				Exec: &plan.ExecCheck{
-					Command: "sleep 1",
-					Environment: map[string]string{
-						"FOO": "bar",
-						"BAZ": "buzz",
+				Log: &plan.LogCheck{
+					Service: "svc1",
+					Pattern: "(panic|fatal):",
+					Window:  plan.OptionalDuration{Value: 5 * time.Minute, IsSet: true},
+				},
+			},
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "Invalid log check pattern",
+	error:   `plan check "chk-log" log pattern invalid: .*`,
+	input: []string{`
+		checks:
+			chk-log:
+				override: replace
+				log:
+					service: svc1
+					pattern: "[a-"
+	`},
+}, {
+	summary: "Invalid log check window",
+	error:   `plan check "chk-log" log window must be greater than zero`,
+	input: []string{`
+		checks:
+			chk-log:
+				override: replace
+				log:
+					service: svc1
+					pattern: "panic:"
+					window: 0s
+	`},
+}, {
+	summary: "Log check without pattern",
+	error:   `plan must set "pattern" for log check "chk-log"`,
+	input: []string{`
+		services:
+			svc1:
+				override: replace
+				command: cmd
+		checks:
+			chk-log:
+				override: replace
+				log:
+					service: svc1
+	`},
+}, {
+	summary: "Log check for non-existent service",
+	error:   `plan check "chk-log" log service specifies non-existent service "svc2"`,
+	input: []string{`
+		checks:
+			chk-log:
+				override: replace
+				log:
+					service: svc2
+					pattern: "panic:"
+	`},
+}, {
+	summary: "Check on-status-change parses and merges correctly",
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+				on-status-change:
+					webhook: https://alerts.example.com/pebble
+					headers:
+						X-Source: pebble
+	`, `
+		checks:
+			chk-http:
+				override: merge
+				on-status-change:
+					headers:
+						Authorization: Bearer token
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks: map[string]*plan.Check{
+			"chk-http": {
+				Name:      "chk-http",
+				Override:  plan.ReplaceOverride,
+				Period:    plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:   plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold: defaultCheckThreshold,
+				OnStatusChange: &plan.CheckStatusAction{
+					Webhook: "https://alerts.example.com/pebble",
+					Headers: map[string]string{
+						"X-Source":      "pebble",
+						"Authorization": "Bearer token",
 					},
-					WorkingDir: "/root",
+				},
+				HTTP: &plan.HTTPCheck{
+					URL: "https://example.com/foo",
+				},
+			},
+		},
+		LogTargets: map[string]*plan.LogTarget{},
+		Sections:   map[string]plan.Section{},
+	},
+}, {
+	summary: "Invalid check on-status-change webhook",
+	error:   `plan check "chk-http" on-status-change webhook "ftp://example.com" invalid, must be an http or https URL`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+				on-status-change:
+					webhook: ftp://example.com
+	`},
+}, {
+	summary: "Check on-status-change without webhook",
+	error:   `plan must set "webhook" for check "chk-http" on-status-change`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				http:
+					url: https://example.com/foo
+				on-status-change:
+					headers:
+						X-Source: pebble
+	`},
+}, {
+	summary: "Check service and start-period parse and merge correctly",
+	input: []string{`
+		services:
+			svc1:
+				override: replace
+				command: cmd
+		checks:
+			chk-http:
+				override: replace
+				service: svc1
+				http:
+					url: https://example.com/foo
+	`, `
+		checks:
+			chk-http:
+				override: merge
+				start-period: 30s
+	`},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{
+			"svc1": {
+				Name:          "svc1",
+				Override:      plan.ReplaceOverride,
+				Command:       "cmd",
+				BackoffDelay:  plan.OptionalDuration{Value: defaultBackoffDelay},
+				BackoffFactor: plan.OptionalFloat{Value: defaultBackoffFactor},
+				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
+			},
+		},
+		Checks: map[string]*plan.Check{
+			"chk-http": {
+				Name:        "chk-http",
+				Override:    plan.ReplaceOverride,
+				Period:      plan.OptionalDuration{Value: defaultCheckPeriod},
+				Timeout:     plan.OptionalDuration{Value: defaultCheckTimeout},
+				Threshold:   defaultCheckThreshold,
+				Service:     "svc1",
+				StartPeriod: plan.OptionalDuration{Value: 30 * time.Second, IsSet: true},
+				HTTP: &plan.HTTPCheck{
+					URL: "https://example.com/foo",
 				},
 			},
 		},
 		LogTargets: map[string]*plan.LogTarget{},
 		Sections:   map[string]plan.Section{},
 	},
+}, {
+	summary: "Check for non-existent service",
+	error:   `plan check "chk-http" specifies non-existent service "svc2"`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				service: svc2
+				http:
+					url: https://example.com/foo
+	`},
+}, {
+	summary: "Negative check start-period",
+	error:   `plan check "chk-http" start-period must not be negative`,
+	input: []string{`
+		checks:
+			chk-http:
+				override: replace
+				start-period: -1s
+				http:
+					url: https://example.com/foo
+	`},
+}, {
+	summary: "Invalid gRPC check address",
+	error:   `plan check "chk-grpc" grpc address "localhost" invalid, must be host:port`,
+	input: []string{`
+		checks:
+			chk-grpc:
+				override: replace
+				grpc:
+					address: localhost
+	`},
+}, {
+	summary: "gRPC check without address",
+	error:   `plan must set "address" for grpc check "chk-grpc"`,
+	input: []string{`
+		checks:
+			chk-grpc:
+				override: replace
+				grpc:
+					service: orders.v1.Orders
+	`},
 }, {
 	summary: "Checks override replace works correctly",
 	input: []string{`
@@ -890,7 +1843,7 @@ var planTests = []planTest{{
 	},
 }, {
 	summary: "One of http, tcp, or exec must be present for check",
-	error:   `plan must specify one of "http", "tcp", or "exec" for check "chk1"`,
+	error:   `plan must specify one of "http", "tcp", "exec", "grpc", or "log" for check "chk1"`,
 	input: []string{`
 		checks:
 			chk1:
@@ -1165,105 +2118,393 @@ var planTests = []planTest{{
 				BackoffLimit:  plan.OptionalDuration{Value: defaultBackoffLimit},
 			},
 		},
-		Checks: map[string]*plan.Check{},
+		Checks: map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:     "tgt1",
+				Type:     plan.LokiTarget,
+				Location: "http://10.1.77.196:3100/loki/api/v1/push",
+				Services: []string{"all", "-all", "svc1"},
+				Override: plan.MergeOverride,
+			},
+			"tgt2": {
+				Name:     "tgt2",
+				Type:     plan.LokiTarget,
+				Location: "udp://0.0.0.0:514",
+				Services: []string{"all", "-svc1"},
+				Override: plan.MergeOverride,
+			},
+		},
+		Sections: map[string]plan.Section{},
+	},
+}, {
+	summary: "Log target requires type field",
+	error:   `plan must define "type" \("loki", "opentelemetry", "syslog" or "http-json"\) for log target "tgt1"`,
+	input: []string{`
+		log-targets:
+			tgt1:
+				location: http://10.1.77.196:3100/loki/api/v1/push
+				override: merge
+`},
+}, {
+	summary: "Log target location must be specified",
+	error:   `plan must define "location" for log target "tgt1"`,
+	input: []string{`
+		log-targets:
+			tgt1:
+				type: loki
+				services: [all]
+				override: merge
+`}}, {
+	summary: "Unsupported log target type",
+	error:   `log target "tgt1" has unsupported type "foobar", must be "loki", "opentelemetry", "syslog" or "http-json"`,
+	input: []string{`
+		log-targets:
+			tgt1:
+				type: foobar
+				location: http://10.1.77.196:3100/loki/api/v1/push
+				override: merge
+`},
+}, {
+	summary: "Log target specifies invalid service",
+	error:   `log target "tgt1" specifies unknown service "nonexistent"`,
+	input: []string{`
+		log-targets:
+			tgt1:
+				type: loki
+				location: http://10.1.77.196:3100/loki/api/v1/push
+				services: [nonexistent]
+				override: merge
+`},
+}, {
+	summary: `Service name can't start with "-"`,
+	error:   `cannot use service name "-svc1": starting with "-" not allowed`,
+	input: []string{`
+		services:
+			-svc1:
+				command: foo
+				override: merge
+`},
+}, {
+	summary: "Log forwarding labels override",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: loki
+				location: https://my.loki.server/loki/api/v1/push
+				labels:
+					label1: foo11
+					label2: foo12
+			tgt2:
+				override: merge
+				type: loki
+				location: https://my.loki.server/loki/api/v1/push
+				labels:
+					label1: foo21
+					label2: foo22
+`, `
+		log-targets:
+			tgt1:
+				override: merge
+				labels:
+					label2: bar12
+					label3: bar13
+			tgt2:
+				override: replace
+				type: loki
+				location: https://new.loki.server/loki/api/v1/push
+				labels:
+					label2: bar22
+					label3: bar23
+`},
+	layers: []*plan.Layer{{
+		Order:    0,
+		Label:    "layer-0",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:     "tgt1",
+				Override: plan.MergeOverride,
+				Type:     plan.LokiTarget,
+				Location: "https://my.loki.server/loki/api/v1/push",
+				Labels: map[string]string{
+					"label1": "foo11",
+					"label2": "foo12",
+				},
+			},
+			"tgt2": {
+				Name:     "tgt2",
+				Override: plan.MergeOverride,
+				Type:     plan.LokiTarget,
+				Location: "https://my.loki.server/loki/api/v1/push",
+				Labels: map[string]string{
+					"label1": "foo21",
+					"label2": "foo22",
+				},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	}, {
+		Order:    1,
+		Label:    "layer-1",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:     "tgt1",
+				Override: plan.MergeOverride,
+				Labels: map[string]string{
+					"label2": "bar12",
+					"label3": "bar13",
+				},
+			},
+			"tgt2": {
+				Name:     "tgt2",
+				Override: plan.ReplaceOverride,
+				Type:     plan.LokiTarget,
+				Location: "https://new.loki.server/loki/api/v1/push",
+				Labels: map[string]string{
+					"label2": "bar22",
+					"label3": "bar23",
+				},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	}},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:     "tgt1",
+				Override: plan.MergeOverride,
+				Type:     plan.LokiTarget,
+				Location: "https://my.loki.server/loki/api/v1/push",
+				Labels: map[string]string{
+					"label1": "foo11",
+					"label2": "bar12",
+					"label3": "bar13",
+				},
+			},
+			"tgt2": {
+				Name:     "tgt2",
+				Override: plan.ReplaceOverride,
+				Type:     plan.LokiTarget,
+				Location: "https://new.loki.server/loki/api/v1/push",
+				Labels: map[string]string{
+					"label2": "bar22",
+					"label3": "bar23",
+				},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	},
+}, {
+	summary: "Reserved log target labels",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: loki
+				location: https://my.loki.server/loki/api/v1/push
+				labels:
+					pebble_service: illegal
+`},
+	error: `log target "tgt1": label "pebble_service" uses reserved prefix "pebble_"`,
+}, {
+	summary: "HTTP JSON log target",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: http-json
+				location: https://collector.example.com/ingest
+				headers:
+					X-Tenant: foo
+				basic-auth:
+					username: user
+					password: pass
+				batch-size: 50
+`, `
+		log-targets:
+			tgt1:
+				override: merge
+				headers:
+					X-Source: pebble
+				gzip: true
+`},
+	layers: []*plan.Layer{{
+		Order:    0,
+		Label:    "layer-0",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:      "tgt1",
+				Override:  plan.MergeOverride,
+				Type:      plan.HTTPJSONTarget,
+				Location:  "https://collector.example.com/ingest",
+				Headers:   map[string]string{"X-Tenant": "foo"},
+				BasicAuth: &plan.LogTargetBasicAuth{Username: "user", Password: "pass"},
+				BatchSize: 50,
+			},
+		},
+		Sections: map[string]plan.Section{},
+	}, {
+		Order:    1,
+		Label:    "layer-1",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
 		LogTargets: map[string]*plan.LogTarget{
 			"tgt1": {
 				Name:     "tgt1",
-				Type:     plan.LokiTarget,
-				Location: "http://10.1.77.196:3100/loki/api/v1/push",
-				Services: []string{"all", "-all", "svc1"},
 				Override: plan.MergeOverride,
+				Headers:  map[string]string{"X-Source": "pebble"},
+				Gzip:     newBool(true),
 			},
-			"tgt2": {
-				Name:     "tgt2",
-				Type:     plan.LokiTarget,
-				Location: "udp://0.0.0.0:514",
-				Services: []string{"all", "-svc1"},
-				Override: plan.MergeOverride,
+		},
+		Sections: map[string]plan.Section{},
+	}},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:      "tgt1",
+				Override:  plan.MergeOverride,
+				Type:      plan.HTTPJSONTarget,
+				Location:  "https://collector.example.com/ingest",
+				Headers:   map[string]string{"X-Tenant": "foo", "X-Source": "pebble"},
+				BasicAuth: &plan.LogTargetBasicAuth{Username: "user", Password: "pass"},
+				Gzip:      newBool(true),
+				BatchSize: 50,
 			},
 		},
 		Sections: map[string]plan.Section{},
 	},
 }, {
-	summary: "Log target requires type field",
-	error:   `plan must define "type" \("loki", "opentelemetry" or "syslog"\) for log target "tgt1"`,
+	summary: "HTTP JSON options on other log target types",
 	input: []string{`
 		log-targets:
 			tgt1:
-				location: http://10.1.77.196:3100/loki/api/v1/push
 				override: merge
+				type: loki
+				location: https://my.loki.server/loki/api/v1/push
+				batch-size: 10
 `},
+	error: `log target "tgt1" of type "loki" cannot set "batch-size" \(only supported by "http-json" targets\)`,
 }, {
-	summary: "Log target location must be specified",
-	error:   `plan must define "location" for log target "tgt1"`,
+	summary: "Log target TLS",
 	input: []string{`
 		log-targets:
 			tgt1:
-				type: loki
-				services: [all]
 				override: merge
-`}}, {
-	summary: "Unsupported log target type",
-	error:   `log target "tgt1" has unsupported type "foobar", must be "loki", "opentelemetry" or "syslog"`,
-	input: []string{`
+				type: loki
+				location: https://my.loki.server/loki/api/v1/push
+				bearer-token-file: /etc/loki-token
+				tls:
+					ca-cert: /etc/ca.pem
+					server-name: loki
+`, `
 		log-targets:
 			tgt1:
-				type: foobar
-				location: http://10.1.77.196:3100/loki/api/v1/push
 				override: merge
+				tls:
+					client-cert: /etc/client.pem
+					client-key: /etc/client.key
 `},
+	layers: []*plan.Layer{{
+		Order:    0,
+		Label:    "layer-0",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:            "tgt1",
+				Override:        plan.MergeOverride,
+				Type:            plan.LokiTarget,
+				Location:        "https://my.loki.server/loki/api/v1/push",
+				BearerTokenFile: "/etc/loki-token",
+				TLS:             &plan.TLSOptions{CACert: "/etc/ca.pem", ServerName: "loki"},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	}, {
+		Order:    1,
+		Label:    "layer-1",
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:     "tgt1",
+				Override: plan.MergeOverride,
+				TLS:      &plan.TLSOptions{ClientCert: "/etc/client.pem", ClientKey: "/etc/client.key"},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	}},
+	result: &plan.Layer{
+		Services: map[string]*plan.Service{},
+		Checks:   map[string]*plan.Check{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:            "tgt1",
+				Override:        plan.MergeOverride,
+				Type:            plan.LokiTarget,
+				Location:        "https://my.loki.server/loki/api/v1/push",
+				BearerTokenFile: "/etc/loki-token",
+				TLS: &plan.TLSOptions{
+					CACert:     "/etc/ca.pem",
+					ClientCert: "/etc/client.pem",
+					ClientKey:  "/etc/client.key",
+					ServerName: "loki",
+				},
+			},
+		},
+		Sections: map[string]plan.Section{},
+	},
 }, {
-	summary: "Log target specifies invalid service",
-	error:   `log target "tgt1" specifies unknown service "nonexistent"`,
+	summary: "Log target TLS paths must be absolute",
 	input: []string{`
 		log-targets:
 			tgt1:
-				type: loki
-				location: http://10.1.77.196:3100/loki/api/v1/push
-				services: [nonexistent]
 				override: merge
+				type: opentelemetry
+				location: https://otel:4318
+				tls:
+					ca-cert: ca.pem
 `},
+	error: `log target "tgt1" tls ca-cert path "ca.pem" must be absolute`,
 }, {
-	summary: `Service name can't start with "-"`,
-	error:   `cannot use service name "-svc1": starting with "-" not allowed`,
+	summary: "Log target TLS client certificate requires key",
 	input: []string{`
-		services:
-			-svc1:
-				command: foo
+		log-targets:
+			tgt1:
 				override: merge
+				type: opentelemetry
+				location: https://otel:4318
+				tls:
+					client-cert: /etc/client.pem
 `},
+	error: `plan must set both "client-cert" and "client-key" for log target "tgt1"`,
 }, {
-	summary: "Log forwarding labels override",
+	summary: "Log target spool",
 	input: []string{`
 		log-targets:
 			tgt1:
 				override: merge
 				type: loki
-				location: https://my.loki.server/loki/api/v1/push
-				labels:
-					label1: foo11
-					label2: foo12
-			tgt2:
-				override: merge
-				type: loki
-				location: https://my.loki.server/loki/api/v1/push
-				labels:
-					label1: foo21
-					label2: foo22
+				location: http://my.loki.server/loki/api/v1/push
+				spool:
+					max-size: 1G
 `, `
 		log-targets:
 			tgt1:
 				override: merge
-				labels:
-					label2: bar12
-					label3: bar13
-			tgt2:
-				override: replace
-				type: loki
-				location: https://new.loki.server/loki/api/v1/push
-				labels:
-					label2: bar22
-					label3: bar23
+				spool:
+					backoff-limit: 1m
 `},
 	layers: []*plan.Layer{{
 		Order:    0,
@@ -1275,21 +2516,8 @@ var planTests = []planTest{{
 				Name:     "tgt1",
 				Override: plan.MergeOverride,
 				Type:     plan.LokiTarget,
-				Location: "https://my.loki.server/loki/api/v1/push",
-				Labels: map[string]string{
-					"label1": "foo11",
-					"label2": "foo12",
-				},
-			},
-			"tgt2": {
-				Name:     "tgt2",
-				Override: plan.MergeOverride,
-				Type:     plan.LokiTarget,
-				Location: "https://my.loki.server/loki/api/v1/push",
-				Labels: map[string]string{
-					"label1": "foo21",
-					"label2": "foo22",
-				},
+				Location: "http://my.loki.server/loki/api/v1/push",
+				Spool:    &plan.LogTargetSpool{MaxSize: "1G"},
 			},
 		},
 		Sections: map[string]plan.Section{},
@@ -1302,20 +2530,7 @@ var planTests = []planTest{{
 			"tgt1": {
 				Name:     "tgt1",
 				Override: plan.MergeOverride,
-				Labels: map[string]string{
-					"label2": "bar12",
-					"label3": "bar13",
-				},
-			},
-			"tgt2": {
-				Name:     "tgt2",
-				Override: plan.ReplaceOverride,
-				Type:     plan.LokiTarget,
-				Location: "https://new.loki.server/loki/api/v1/push",
-				Labels: map[string]string{
-					"label2": "bar22",
-					"label3": "bar23",
-				},
+				Spool:    &plan.LogTargetSpool{BackoffLimit: plan.OptionalDuration{Value: time.Minute, IsSet: true}},
 			},
 		},
 		Sections: map[string]plan.Section{},
@@ -1328,38 +2543,88 @@ var planTests = []planTest{{
 				Name:     "tgt1",
 				Override: plan.MergeOverride,
 				Type:     plan.LokiTarget,
-				Location: "https://my.loki.server/loki/api/v1/push",
-				Labels: map[string]string{
-					"label1": "foo11",
-					"label2": "bar12",
-					"label3": "bar13",
-				},
-			},
-			"tgt2": {
-				Name:     "tgt2",
-				Override: plan.ReplaceOverride,
-				Type:     plan.LokiTarget,
-				Location: "https://new.loki.server/loki/api/v1/push",
-				Labels: map[string]string{
-					"label2": "bar22",
-					"label3": "bar23",
+				Location: "http://my.loki.server/loki/api/v1/push",
+				Spool: &plan.LogTargetSpool{
+					MaxSize:      "1G",
+					BackoffLimit: plan.OptionalDuration{Value: time.Minute, IsSet: true},
 				},
 			},
 		},
 		Sections: map[string]plan.Section{},
 	},
 }, {
-	summary: "Reserved log target labels",
+	summary: "Log target spool max-size invalid",
 	input: []string{`
 		log-targets:
 			tgt1:
 				override: merge
 				type: loki
-				location: https://my.loki.server/loki/api/v1/push
-				labels:
-					pebble_service: illegal
+				location: http://my.loki.server/loki/api/v1/push
+				spool:
+					max-size: lots
 `},
-	error: `log target "tgt1": label "pebble_service" uses reserved prefix "pebble_"`,
+	error: `log target "tgt1" spool max-size "lots" invalid`,
+}, {
+	summary: "Log target spool backoff-limit invalid",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: loki
+				location: http://my.loki.server/loki/api/v1/push
+				spool:
+					backoff-limit: 0s
+`},
+	error: `log target "tgt1" spool backoff-limit must be greater than zero`,
+}, {
+	summary: "Syslog log target with headers",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: syslog
+				location: tcp://syslog:514
+				headers:
+					X-Foo: bar
+`},
+	error: `log target "tgt1" of type "syslog" cannot set "headers"`,
+}, {
+	summary: "Syslog log target with TLS over UDP",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: syslog
+				location: udp://syslog:514
+				tls:
+					ca-cert: /etc/ca.pem
+`},
+	error: `log target "tgt1" cannot set "tls" for a UDP syslog location`,
+}, {
+	summary: "Log target basic-auth requires username",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: http-json
+				location: https://collector.example.com/ingest
+				basic-auth:
+					password: pass
+`},
+	error: `log target "tgt1" basic-auth must specify "username"`,
+}, {
+	summary: "Log target with basic and bearer auth",
+	input: []string{`
+		log-targets:
+			tgt1:
+				override: merge
+				type: http-json
+				location: https://collector.example.com/ingest
+				basic-auth:
+					username: user
+				bearer-token-file: /etc/token
+`},
+	error: `log target "tgt1" cannot set both "basic-auth" and "bearer-token-file"`,
 }, {
 	summary: "Required field two layers deep",
 	input: []string{`
@@ -1897,6 +3162,115 @@ func (s *S) TestLogsTo(c *C) {
 	}
 }
 
+func (s *S) TestLogsFromSource(c *C) {
+	tests := []struct {
+		services []string
+		logsFrom map[string]bool
+	}{{
+		services: []string{"all"},
+		logsFrom: map[string]bool{
+			"pebble:daemon":   false,
+			"pebble:security": false,
+			"pebble:changes":  false,
+		},
+	}, {
+		services: []string{"all", "pebble:daemon", "pebble:changes"},
+		logsFrom: map[string]bool{
+			"pebble:daemon":   true,
+			"pebble:security": false,
+			"pebble:changes":  true,
+		},
+	}, {
+		services: []string{"pebble:daemon", "pebble:security", "-pebble:daemon"},
+		logsFrom: map[string]bool{
+			"pebble:daemon":   false,
+			"pebble:security": true,
+			"pebble:changes":  false,
+		},
+	}, {
+		services: []string{"pebble:security", "-all", "pebble:changes"},
+		logsFrom: map[string]bool{
+			"pebble:daemon":   false,
+			"pebble:security": false,
+			"pebble:changes":  true,
+		},
+	}}
+
+	for _, test := range tests {
+		target := &plan.LogTarget{
+			Services: test.services,
+		}
+		for source, shouldLogFrom := range test.logsFrom {
+			c.Check(target.LogsFromSource(source), Equals, shouldLogFrom,
+				Commentf("matching source %q against 'services: %v'", source, test.services))
+		}
+	}
+
+	// Pebble's log sources aren't reported as unknown services.
+	layer, err := plan.ParseLayer(1, "label1", []byte(`
+log-targets:
+    tgt1:
+        override: merge
+        type: loki
+        location: http://10.1.77.196:3100/loki/api/v1/push
+        services: [pebble:daemon, pebble:security, -pebble:changes]
+`))
+	c.Assert(err, IsNil)
+	combined, err := plan.CombineLayers(layer)
+	c.Assert(err, IsNil)
+	p := &plan.Plan{
+		Layers:     []*plan.Layer{layer},
+		Services:   combined.Services,
+		Checks:     combined.Checks,
+		LogTargets: combined.LogTargets,
+		Sections:   combined.Sections,
+	}
+	err = p.Validate()
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestPlanRedacted(c *C) {
+	p := &plan.Plan{
+		Services: map[string]*plan.Service{},
+		LogTargets: map[string]*plan.LogTarget{
+			"tgt1": {
+				Name:      "tgt1",
+				Type:      plan.HTTPJSONTarget,
+				Location:  "https://collector.example.com/ingest",
+				Headers:   map[string]string{"X-Api-Key": "secret"},
+				BasicAuth: &plan.LogTargetBasicAuth{Username: "user", Password: "pass"},
+			},
+			"tgt2": {
+				Name:            "tgt2",
+				Type:            plan.LokiTarget,
+				Location:        "https://my.loki.server/loki/api/v1/push",
+				BearerTokenFile: "/etc/loki-token",
+			},
+		},
+	}
+
+	redacted := p.Redacted()
+	c.Assert(redacted.LogTargets, DeepEquals, map[string]*plan.LogTarget{
+		"tgt1": {
+			Name:      "tgt1",
+			Type:      plan.HTTPJSONTarget,
+			Location:  "https://collector.example.com/ingest",
+			Headers:   map[string]string{"X-Api-Key": "*****"},
+			BasicAuth: &plan.LogTargetBasicAuth{Username: "user", Password: "*****"},
+		},
+		"tgt2": {
+			Name:            "tgt2",
+			Type:            plan.LokiTarget,
+			Location:        "https://my.loki.server/loki/api/v1/push",
+			BearerTokenFile: "/etc/loki-token",
+		},
+	})
+
+	// The original plan isn't modified.
+	c.Assert(p.LogTargets["tgt1"].Headers["X-Api-Key"], Equals, "secret")
+	c.Assert(p.LogTargets["tgt1"].BasicAuth.Password, Equals, "pass")
+}
+
 func (s *S) TestMergeServiceContextNoContext(c *C) {
 	userID, groupID := 10, 20
 	overrides := plan.ContextOptions{
@@ -2358,3 +3732,40 @@ func (s *S) TestReadLayersDir(c *C) {
 		}
 	}
 }
+
+func (s *S) TestResourcesMemoryMaxBytes(c *C) {
+	tests := []struct {
+		value string
+		bytes int64
+		error string
+	}{
+		{value: "1024", bytes: 1024},
+		{value: "4K", bytes: 4096},
+		{value: "512M", bytes: 512 << 20},
+		{value: "2G", bytes: 2 << 30},
+		{value: "1T", bytes: 1 << 40},
+		{value: "", error: `memory-max "" invalid`},
+		{value: "M", error: `memory-max "M" invalid`},
+		{value: "-1M", error: `memory-max "-1M" invalid`},
+		{value: "1.5G", error: `memory-max "1.5G" invalid`},
+		{value: "100000000T", error: `memory-max "100000000T" invalid`},
+	}
+	for _, test := range tests {
+		r := &plan.Resources{MemoryMax: test.value}
+		bytes, err := r.MemoryMaxBytes()
+		if test.error != "" {
+			c.Check(err, ErrorMatches, test.error, Commentf("value %q", test.value))
+			continue
+		}
+		c.Check(err, IsNil, Commentf("value %q", test.value))
+		c.Check(bytes, Equals, test.bytes, Commentf("value %q", test.value))
+	}
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+func newInt(n int) *int {
+	return &n
+}
@@ -257,3 +257,13 @@ func CommandCombinedOutput(cmd *exec.Cmd) ([]byte, error) {
 	}
 	return b.Bytes(), err
 }
+
+// GroupRunning reports whether any processes in the process group pgid are
+// still running (or have exited but are yet to be reaped). Once a service's
+// main process has been reaped, this confirms whether its descendants have
+// also exited.
+func GroupRunning(pgid int) bool {
+	err := unix.Kill(-pgid, 0)
+	// EPERM means there are processes, just ones we can't signal.
+	return err == nil || err == unix.EPERM
+}
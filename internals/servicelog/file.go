@@ -0,0 +1,540 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servicelog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/pebble/internals/logger"
+)
+
+var timeNow = time.Now
+
+// FileOptions configures the rotation of a log file.
+type FileOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated, or 0
+	// for no size limit.
+	MaxSize int64
+
+	// MaxAge is the age of the oldest log in the file after which the file
+	// is rotated, or 0 for no age limit.
+	MaxAge time.Duration
+
+	// Compress enables gzip compression of rotated files.
+	Compress bool
+
+	// Retain is the number of rotated files to keep.
+	Retain int
+}
+
+// File writes service output to a ring buffer and to a log file on disk,
+// so that logs survive the ring buffer wrapping and daemon restarts.
+//
+// Rotated files are named with the suffix ".1" (the most recent), ".2", and
+// so on, plus ".gz" if they're compressed. The file is only rotated at the
+// start of a line, so each file contains whole log lines.
+type File struct {
+	mu        sync.Mutex
+	path      string
+	opts      FileOptions
+	rb        *RingBuffer
+	file      *os.File
+	size      int64
+	oldest    time.Time
+	lineStart bool
+	failed    bool
+
+	// Rotated files are compressed in the background, so that compressing
+	// a large file doesn't block the service's output.
+	compressing sync.WaitGroup
+}
+
+// OpenFile opens (or creates) the log file at path, appending to it if it
+// already exists. Data written to the returned File is written to rb, and
+// then to the log file.
+func OpenFile(path string, rb *RingBuffer, opts FileOptions) (*File, error) {
+	f := &File{
+		path:      path,
+		opts:      opts,
+		rb:        rb,
+		lineStart: true,
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create log directory: %w", err)
+	}
+	err = f.open()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("cannot open log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.oldest = time.Time{}
+	if f.size > 0 {
+		f.oldest = firstLogTime(f.path, info.ModTime())
+	}
+	return nil
+}
+
+// firstLogTime returns the time of the first log in the file at path, or
+// fallback if it can't be parsed.
+func firstLogTime(path string, fallback time.Time) time.Time {
+	file, err := os.Open(path)
+	if err != nil {
+		return fallback
+	}
+	defer file.Close()
+	line, _ := bufio.NewReaderSize(file, 256).ReadSlice(' ')
+	t, err := time.Parse(parseTimeFormat, strings.TrimSuffix(string(line), " "))
+	if err != nil {
+		return fallback
+	}
+	return t
+}
+
+// Write writes p to the ring buffer and the log file. Errors writing to the
+// log file are logged rather than returned, so that they don't stop the
+// service's output going to the ring buffer.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, err := f.rb.Write(p)
+	if n > 0 {
+		f.writeFile(p[:n])
+	}
+	return n, err
+}
+
+func (f *File) writeFile(p []byte) {
+	if f.file == nil {
+		return
+	}
+	if f.lineStart && f.needsRotate() {
+		err := f.rotate()
+		if err != nil {
+			f.logError(err)
+			if f.file == nil {
+				return
+			}
+		}
+	}
+	if f.size == 0 {
+		f.oldest = timeNow()
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.logError(err)
+	} else if !f.needsRotate() {
+		// Only reset once rotation has also succeeded, so that a failing
+		// rotation isn't logged for every line.
+		f.failed = false
+	}
+	f.lineStart = p[len(p)-1] == '\n'
+}
+
+// logError logs the first of a sequence of errors, to avoid flooding the
+// daemon's log when the disk is full.
+func (f *File) logError(err error) {
+	if !f.failed {
+		logger.Noticef("Cannot write to log file %q: %v", f.path, err)
+	}
+	f.failed = true
+}
+
+func (f *File) needsRotate() bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.MaxSize > 0 && f.size >= f.opts.MaxSize {
+		return true
+	}
+	if f.opts.MaxAge > 0 && timeNow().Sub(f.oldest) >= f.opts.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate renames the current log file to path.1 (compressing it in the
+// background if required), shifting any older files up by one and removing
+// those beyond the retention count, and then opens a new, empty log file.
+// If rotation fails, writing continues to the current file.
+func (f *File) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("cannot close log file: %w", err)
+	}
+	err = f.shift()
+	return errors.Join(err, f.open())
+}
+
+func (f *File) shift() error {
+	// Don't rename the previous rotated file while it's being compressed.
+	f.compressing.Wait()
+
+	rotated, err := rotatedFiles(f.path)
+	if err != nil {
+		return fmt.Errorf("cannot rotate log file: %w", err)
+	}
+	// Shift the oldest first so that renames don't overwrite other files.
+	for i := len(rotated) - 1; i >= 0; i-- {
+		r := rotated[i]
+		if r.index >= f.opts.Retain {
+			err = os.Remove(r.name)
+		} else {
+			err = os.Rename(r.name, rotatedName(f.path, r.index+1, r.compressed))
+		}
+		if err != nil {
+			return fmt.Errorf("cannot rotate log file: %w", err)
+		}
+	}
+
+	if f.opts.Retain > 0 {
+		err = os.Rename(f.path, rotatedName(f.path, 1, false))
+		if err == nil && f.opts.Compress {
+			f.compressing.Add(1)
+			go func() {
+				defer f.compressing.Done()
+				src := rotatedName(f.path, 1, false)
+				err := compressFile(src, rotatedName(f.path, 1, true))
+				if err != nil {
+					logger.Noticef("Cannot compress log file %q: %v", src, err)
+				}
+			}()
+		}
+	} else {
+		err = os.Remove(f.path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot rotate log file: %w", err)
+	}
+	return nil
+}
+
+// compressFile compresses src to dst and removes src. The data is written to
+// a temporary file first, so that readers never see a partial dst.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = out.Close()
+	} else {
+		out.Close()
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Keep the modification time, which is used to skip files when
+	// reading logs since a given time.
+	info, err := in.Stat()
+	if err == nil {
+		_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	err = os.Rename(tmp, dst)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+type rotatedFile struct {
+	name       string
+	index      int
+	compressed bool
+	modTime    time.Time
+}
+
+func rotatedName(path string, index int, compressed bool) string {
+	name := path + "." + strconv.Itoa(index)
+	if compressed {
+		name += ".gz"
+	}
+	return name
+}
+
+// rotatedFiles returns the rotated files for the log file at path, most
+// recent first.
+func rotatedFiles(path string) ([]rotatedFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []rotatedFile
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), base+".")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		suffix, compressed := strings.CutSuffix(suffix, ".gz")
+		index, err := strconv.Atoi(suffix)
+		if err != nil || index < 1 || strconv.Itoa(index) != suffix {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, rotatedFile{
+			name:       filepath.Join(dir, entry.Name()),
+			index:      index,
+			compressed: compressed,
+			modTime:    info.ModTime(),
+		})
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		if rotated[i].index == rotated[j].index {
+			return !rotated[i].compressed && rotated[j].compressed
+		}
+		return rotated[i].index < rotated[j].index
+	})
+	return rotated, nil
+}
+
+// Close closes the log file, waiting for any rotated file to be compressed.
+// Data written after Close is only written to the ring buffer.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.compressing.Wait()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// Iterator returns an iterator that reads the logs in the log file and then
+// continues with data written to the ring buffer after this call, so that
+// no logs are missed or repeated. If rotated is true, the logs in rotated
+// files last modified at or after since are read first.
+func (f *File) Iterator(rotated bool, since time.Time) Iterator {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	readers := openHistory(f.path, rotated, since)
+	if n := len(readers); f.file != nil && n > 0 && readers[n-1].name == f.path {
+		// Don't read past the data written so far, as later data will be
+		// read from the ring buffer.
+		readers[n-1].Reader = io.LimitReader(readers[n-1].Reader, f.size)
+	}
+	return &historyIterator{
+		readers: readers,
+		live:    f.rb.HeadIterator(0),
+	}
+}
+
+// FileIterator returns an iterator that reads the logs in the log file at
+// path, as for File.Iterator, for a service that isn't currently writing
+// to its log file.
+func FileIterator(path string, rotated bool, since time.Time) Iterator {
+	return &historyIterator{
+		readers: openHistory(path, rotated, since),
+	}
+}
+
+type historyReader struct {
+	io.Reader
+	name    string
+	closers []io.Closer
+}
+
+func (r *historyReader) Close() error {
+	var err error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		err = errors.Join(err, r.closers[i].Close())
+	}
+	return err
+}
+
+// openHistory opens the log file at path and (if requested) its rotated
+// files, returning readers in the order the logs were written. Files that
+// can't be opened are skipped.
+func openHistory(path string, rotated bool, since time.Time) []*historyReader {
+	var readers []*historyReader
+	if rotated {
+		files, err := rotatedFiles(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Noticef("Cannot read rotated log files for %q: %v", path, err)
+		}
+		for i := len(files) - 1; i >= 0; i-- {
+			if files[i].modTime.Before(since) {
+				continue
+			}
+			if files[i].compressed && i > 0 && files[i-1].index == files[i].index {
+				// The file has just been compressed, but the uncompressed
+				// file hasn't been removed yet.
+				continue
+			}
+			r, err := openHistoryFile(files[i].name, files[i].compressed)
+			if err != nil {
+				logger.Noticef("Cannot read log file: %v", err)
+				continue
+			}
+			readers = append(readers, r)
+		}
+	}
+	r, err := openHistoryFile(path, false)
+	if err == nil {
+		readers = append(readers, r)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logger.Noticef("Cannot read log file: %v", err)
+	}
+	return readers
+}
+
+func openHistoryFile(name string, compressed bool) (*historyReader, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if !compressed {
+		return &historyReader{Reader: file, name: name, closers: []io.Closer{file}}, nil
+	}
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot decompress %q: %w", name, err)
+	}
+	return &historyReader{Reader: zr, name: name, closers: []io.Closer{file, zr}}, nil
+}
+
+// historyIterator reads from a sequence of log files, and then from a live
+// ring buffer iterator (if any).
+type historyIterator struct {
+	readers []*historyReader
+	live    Iterator
+}
+
+var _ Iterator = (*historyIterator)(nil)
+
+func (it *historyIterator) Close() error {
+	var err error
+	for _, r := range it.readers {
+		err = errors.Join(err, r.Close())
+	}
+	it.readers = nil
+	if it.live != nil {
+		err = errors.Join(err, it.live.Close())
+	}
+	return err
+}
+
+func (it *historyIterator) Next(cancel <-chan struct{}) bool {
+	if len(it.readers) > 0 {
+		return true
+	}
+	if it.live == nil {
+		return false
+	}
+	return it.live.Next(cancel)
+}
+
+func (it *historyIterator) Notify(ch chan bool) {
+	if it.live != nil {
+		it.live.Notify(ch)
+	}
+}
+
+func (it *historyIterator) Buffered() int {
+	if it.live == nil {
+		return 0
+	}
+	return it.live.Buffered()
+}
+
+// Read implements io.Reader
+func (it *historyIterator) Read(dest []byte) (int, error) {
+	for len(it.readers) > 0 {
+		n, err := it.readers[0].Read(dest)
+		if err == io.EOF {
+			_ = it.readers[0].Close()
+			it.readers = it.readers[1:]
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+	if it.live == nil {
+		return 0, io.EOF
+	}
+	return it.live.Read(dest)
+}
+
+// WriteTo implements io.WriterTo
+func (it *historyIterator) WriteTo(writer io.Writer) (int64, error) {
+	var written int64
+	for len(it.readers) > 0 {
+		n, err := io.Copy(writer, it.readers[0])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		_ = it.readers[0].Close()
+		it.readers = it.readers[1:]
+	}
+	if it.live == nil {
+		return written, nil
+	}
+	n, err := it.live.WriteTo(writer)
+	return written + n, err
+}
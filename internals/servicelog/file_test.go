@@ -0,0 +1,279 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servicelog_test
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+type fileSuite struct {
+	dir  string
+	path string
+}
+
+var _ = Suite(&fileSuite{})
+
+func (s *fileSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.path = filepath.Join(s.dir, "logs", "svc.log")
+}
+
+func (s *fileSuite) readFile(c *C, name string) string {
+	data, err := os.ReadFile(filepath.Join(s.dir, "logs", name))
+	c.Assert(err, IsNil)
+	return string(data)
+}
+
+func (s *fileSuite) readGzip(c *C, name string) string {
+	file, err := os.Open(filepath.Join(s.dir, "logs", name))
+	c.Assert(err, IsNil)
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	c.Assert(err, IsNil)
+	data, err := io.ReadAll(zr)
+	c.Assert(err, IsNil)
+	return string(data)
+}
+
+func (s *fileSuite) logNames(c *C) []string {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "logs"))
+	c.Assert(err, IsNil)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func logLine(n int) string {
+	return fmt.Sprintf("2026-01-02T03:04:%02d.000Z [svc] line %d\n", n, n)
+}
+
+func (s *fileSuite) TestWrite(c *C) {
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{})
+	c.Assert(err, IsNil)
+	_, err = io.WriteString(f, logLine(1)+logLine(2))
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(1)+logLine(2))
+	data, err := io.ReadAll(rb.TailIterator())
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(1)+logLine(2))
+
+	// Writes after Close only go to the ring buffer.
+	_, err = io.WriteString(f, logLine(3))
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(1)+logLine(2))
+
+	// Reopening appends to the file.
+	f, err = servicelog.OpenFile(s.path, rb, servicelog.FileOptions{})
+	c.Assert(err, IsNil)
+	_, err = io.WriteString(f, logLine(4))
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(1)+logLine(2)+logLine(4))
+}
+
+func (s *fileSuite) TestRotateSize(c *C) {
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{
+		MaxSize: int64(len(logLine(1))) * 2,
+		Retain:  2,
+	})
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	for i := 1; i <= 7; i++ {
+		// Write each line in two parts, as the format writer does, to
+		// check that files are only rotated at the start of a line.
+		line := logLine(i)
+		_, err = io.WriteString(f, line[:10])
+		c.Assert(err, IsNil)
+		_, err = io.WriteString(f, line[10:])
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(s.logNames(c), DeepEquals, []string{"svc.log", "svc.log.1", "svc.log.2"})
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(7))
+	c.Assert(s.readFile(c, "svc.log.1"), Equals, logLine(5)+logLine(6))
+	c.Assert(s.readFile(c, "svc.log.2"), Equals, logLine(3)+logLine(4))
+}
+
+func (s *fileSuite) TestRotateCompress(c *C) {
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{
+		MaxSize:  1,
+		Compress: true,
+		Retain:   5,
+	})
+	c.Assert(err, IsNil)
+
+	for i := 1; i <= 3; i++ {
+		_, err = io.WriteString(f, logLine(i))
+		c.Assert(err, IsNil)
+	}
+
+	// Rotated files are compressed in the background, and Close waits for
+	// that to finish.
+	c.Assert(f.Close(), IsNil)
+	c.Assert(s.logNames(c), DeepEquals, []string{"svc.log", "svc.log.1.gz", "svc.log.2.gz"})
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(3))
+	c.Assert(s.readGzip(c, "svc.log.1.gz"), Equals, logLine(2))
+	c.Assert(s.readGzip(c, "svc.log.2.gz"), Equals, logLine(1))
+}
+
+func (s *fileSuite) TestRotateAge(c *C) {
+	now := time.Date(2026, 1, 2, 3, 4, 1, 0, time.UTC)
+	restore := servicelog.FakeTimeNow(func() time.Time { return now })
+	defer restore()
+
+	// The age of an existing file is taken from its first log.
+	err := os.MkdirAll(filepath.Dir(s.path), 0o755)
+	c.Assert(err, IsNil)
+	err = os.WriteFile(s.path, []byte(logLine(1)), 0o600)
+	c.Assert(err, IsNil)
+
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{
+		MaxAge: time.Hour,
+		Retain: 1,
+	})
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	now = now.Add(59 * time.Minute)
+	_, err = io.WriteString(f, logLine(2))
+	c.Assert(err, IsNil)
+	c.Assert(s.logNames(c), DeepEquals, []string{"svc.log"})
+
+	now = now.Add(time.Minute)
+	_, err = io.WriteString(f, logLine(3))
+	c.Assert(err, IsNil)
+	c.Assert(s.logNames(c), DeepEquals, []string{"svc.log", "svc.log.1"})
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(3))
+	c.Assert(s.readFile(c, "svc.log.1"), Equals, logLine(1)+logLine(2))
+
+	// The age of a new file is taken from its first write.
+	now = now.Add(59 * time.Minute)
+	_, err = io.WriteString(f, logLine(4))
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(c, "svc.log"), Equals, logLine(3)+logLine(4))
+}
+
+func (s *fileSuite) TestIterator(c *C) {
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{
+		MaxSize: int64(len(logLine(1))) * 2,
+		Retain:  5,
+	})
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	for i := 1; i <= 5; i++ {
+		_, err = io.WriteString(f, logLine(i))
+		c.Assert(err, IsNil)
+	}
+
+	// Only the current file, then anything written afterwards.
+	it := f.Iterator(false, time.Time{})
+	defer it.Close()
+	_, err = io.WriteString(f, logLine(6))
+	c.Assert(err, IsNil)
+	c.Assert(it.Next(nil), Equals, true)
+	data, err := io.ReadAll(it)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(5)+logLine(6))
+
+	// Including the rotated files.
+	it = f.Iterator(true, time.Time{})
+	defer it.Close()
+	data, err = io.ReadAll(it)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(1)+logLine(2)+logLine(3)+logLine(4)+logLine(5)+logLine(6))
+	c.Assert(it.Next(nil), Equals, false)
+
+	// Rotated files last written before since are skipped.
+	old := time.Now().Add(-time.Hour)
+	err = os.Chtimes(filepath.Join(s.dir, "logs", "svc.log.2"), old, old)
+	c.Assert(err, IsNil)
+	it = f.Iterator(true, time.Now().Add(-time.Minute))
+	defer it.Close()
+	data, err = io.ReadAll(it)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(3)+logLine(4)+logLine(5)+logLine(6))
+}
+
+func (s *fileSuite) TestFileIterator(c *C) {
+	rb := servicelog.NewRingBuffer(1024)
+	f, err := servicelog.OpenFile(s.path, rb, servicelog.FileOptions{
+		MaxSize:  1,
+		Compress: true,
+		Retain:   5,
+	})
+	c.Assert(err, IsNil)
+	for i := 1; i <= 3; i++ {
+		_, err = io.WriteString(f, logLine(i))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(f.Close(), IsNil)
+
+	it := servicelog.FileIterator(s.path, true, time.Time{})
+	defer it.Close()
+	c.Assert(it.Next(nil), Equals, true)
+	data, err := io.ReadAll(it)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(1)+logLine(2)+logLine(3))
+	c.Assert(it.Next(nil), Equals, false)
+
+	it = servicelog.FileIterator(filepath.Join(s.dir, "missing.log"), true, time.Time{})
+	defer it.Close()
+	c.Assert(it.Next(nil), Equals, false)
+}
+
+func (s *fileSuite) TestFileIteratorCompressing(c *C) {
+	// Simulate reading while a rotated file has just been compressed, but
+	// the uncompressed file hasn't been removed yet.
+	err := os.MkdirAll(filepath.Dir(s.path), 0o755)
+	c.Assert(err, IsNil)
+	err = os.WriteFile(s.path+".1", []byte(logLine(1)), 0o600)
+	c.Assert(err, IsNil)
+	file, err := os.Create(s.path + ".1.gz")
+	c.Assert(err, IsNil)
+	zw := gzip.NewWriter(file)
+	_, err = io.WriteString(zw, logLine(1))
+	c.Assert(err, IsNil)
+	c.Assert(zw.Close(), IsNil)
+	c.Assert(file.Close(), IsNil)
+	err = os.WriteFile(s.path, []byte(logLine(2)), 0o600)
+	c.Assert(err, IsNil)
+
+	it := servicelog.FileIterator(s.path, true, time.Time{})
+	defer it.Close()
+	c.Assert(it.Next(nil), Equals, true)
+	data, err := io.ReadAll(it)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, logLine(1)+logLine(2))
+}
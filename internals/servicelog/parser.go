@@ -39,6 +39,11 @@ type Entry struct {
 	Time    time.Time
 	Service string
 	Message string
+
+	// Level and Attributes are only set for services with a structured
+	// log-format (see ParseStructured).
+	Level      string
+	Attributes map[string]string
 }
 
 // Parser parses and iterates over logs from a Reader until EOF (or another
@@ -126,5 +131,5 @@ func Parse(line []byte) (Entry, error) {
 	}
 	service := string(fields[1][1 : len(fields[1])-1]) // Trim [ and ] from "[service]"
 	message := string(fields[2])
-	return Entry{timestamp, service, message}, nil
+	return Entry{Time: timestamp, Service: service, Message: message}, nil
 }
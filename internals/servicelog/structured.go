@@ -0,0 +1,210 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servicelog
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Format specifies how a service's log messages are structured.
+type Format string
+
+const (
+	FormatPlain  Format = "plain"
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+)
+
+// Normalized log levels, from least to most severe.
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+var (
+	messageKeys = []string{"msg", "message"}
+	levelKeys   = []string{"level", "lvl", "severity"}
+)
+
+// ParseStructured parses the entry's message according to format, returning
+// an entry with the level, message and remaining attributes extracted. If
+// the format is plain or the message can't be parsed (for example, a
+// truncated line), the entry is returned unchanged.
+func ParseStructured(entry Entry, format Format) Entry {
+	var fields map[string]string
+	var err error
+	line, newline := strings.CutSuffix(entry.Message, "\n")
+	switch format {
+	case FormatJSON:
+		fields, err = parseJSONFields(line)
+	case FormatLogfmt:
+		fields, err = parseLogfmtFields(line)
+	default:
+		return entry
+	}
+	if err != nil || len(fields) == 0 {
+		return entry
+	}
+
+	entry.Message = ""
+	for _, key := range messageKeys {
+		if value, ok := fields[key]; ok {
+			entry.Message = value
+			delete(fields, key)
+			break
+		}
+	}
+	if newline {
+		entry.Message += "\n"
+	}
+	for _, key := range levelKeys {
+		if value, ok := fields[key]; ok {
+			entry.Level = normalizeLevel(value)
+			delete(fields, key)
+			break
+		}
+	}
+	if len(fields) > 0 {
+		entry.Attributes = fields
+	}
+	return entry
+}
+
+// normalizeLevel maps common level names onto the levels above. Unknown
+// levels are returned in lower case.
+func normalizeLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch level {
+	case "warning":
+		return LevelWarn
+	case "err":
+		return LevelError
+	case "notice", "informational":
+		return LevelInfo
+	case "critical", "crit", "alert", "emerg", "emergency", "panic":
+		return LevelFatal
+	}
+	return level
+}
+
+// parseJSONFields parses a JSON object, converting non-string values to
+// their JSON encoding.
+func parseJSONFields(line string) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal([]byte(line), &raw)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			fields[key] = s
+		} else {
+			fields[key] = string(value)
+		}
+	}
+	return fields, nil
+}
+
+// parseLogfmtFields parses a line of space-separated key=value pairs, where
+// values may be double-quoted. A key without a value is given the value
+// "true", but the line must have at least one key=value pair, so that plain
+// text isn't parsed as a list of keys.
+func parseLogfmtFields(line string) (map[string]string, error) {
+	fields := make(map[string]string)
+	hasPair := false
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			if !hasPair {
+				return nil, errors.New("no logfmt key=value pairs")
+			}
+			return fields, nil
+		}
+		end := strings.IndexAny(line, "= \t")
+		if end < 0 {
+			end = len(line)
+		}
+		key := line[:end]
+		if key == "" || strings.ContainsRune(key, '"') {
+			return nil, errors.New("invalid logfmt key")
+		}
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			fields[key] = "true"
+			continue
+		}
+		line = line[1:]
+		hasPair = true
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = value
+			line = line[len(quoted):]
+			continue
+		}
+		end = strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		fields[key] = line[:end]
+		line = line[end:]
+	}
+}
+
+// FormatAttributes formats attributes as logfmt key=value pairs sorted by
+// key, quoting values where needed.
+func FormatAttributes(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var sb strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		value := attrs[key]
+		if value == "" || strings.ContainsFunc(value, needsQuoting) {
+			value = strconv.Quote(value)
+		}
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
+
+func needsQuoting(r rune) bool {
+	return r == '"' || r == '=' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
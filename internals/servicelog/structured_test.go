@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package servicelog_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/servicelog"
+)
+
+type structuredSuite struct{}
+
+var _ = Suite(&structuredSuite{})
+
+var parseStructuredTests = []struct {
+	format     servicelog.Format
+	message    string
+	result     string
+	level      string
+	attributes map[string]string
+}{{
+	format:  servicelog.FormatPlain,
+	message: `{"msg": "hello", "level": "info"}` + "\n",
+	result:  `{"msg": "hello", "level": "info"}` + "\n",
+}, {
+	format:     servicelog.FormatJSON,
+	message:    `{"msg": "hello", "level": "INFO", "port": 8080, "ok": true, "user": "bob"}` + "\n",
+	result:     "hello\n",
+	level:      "info",
+	attributes: map[string]string{"port": "8080", "ok": "true", "user": "bob"},
+}, {
+	format:  servicelog.FormatJSON,
+	message: `{"message": "disk full", "severity": "critical"}`,
+	result:  "disk full",
+	level:   "fatal",
+}, {
+	format:  servicelog.FormatJSON,
+	message: `{"msg": "trunc`,
+	result:  `{"msg": "trunc`,
+}, {
+	format:  servicelog.FormatJSON,
+	message: "not json\n",
+	result:  "not json\n",
+}, {
+	format:     servicelog.FormatLogfmt,
+	message:    `level=warning msg="retrying request" attempt=3 path=/foo debug` + "\n",
+	result:     "retrying request\n",
+	level:      "warn",
+	attributes: map[string]string{"attempt": "3", "path": "/foo", "debug": "true"},
+}, {
+	format:     servicelog.FormatLogfmt,
+	message:    `lvl=err msg="a \"quoted\" value" empty=""`,
+	result:     `a "quoted" value`,
+	level:      "error",
+	attributes: map[string]string{"empty": ""},
+}, {
+	format:  servicelog.FormatLogfmt,
+	message: `msg="unterminated` + "\n",
+	result:  `msg="unterminated` + "\n",
+}, {
+	format:  servicelog.FormatLogfmt,
+	message: "=foo\n",
+	result:  "=foo\n",
+}, {
+	format:  servicelog.FormatLogfmt,
+	message: "Starting server on port 8080\n",
+	result:  "Starting server on port 8080\n",
+}, {
+	format:     servicelog.FormatLogfmt,
+	message:    "Starting server on port=8080\n",
+	result:     "\n",
+	attributes: map[string]string{"Starting": "true", "server": "true", "on": "true", "port": "8080"},
+}}
+
+func (s *structuredSuite) TestParseStructured(c *C) {
+	for _, test := range parseStructuredTests {
+		c.Logf("format %q, message %q", test.format, test.message)
+		entry := servicelog.ParseStructured(servicelog.Entry{Service: "svc1", Message: test.message}, test.format)
+		c.Check(entry.Service, Equals, "svc1")
+		c.Check(entry.Message, Equals, test.result)
+		c.Check(entry.Level, Equals, test.level)
+		c.Check(entry.Attributes, DeepEquals, test.attributes)
+	}
+}
+
+func (s *structuredSuite) TestFormatAttributes(c *C) {
+	c.Check(servicelog.FormatAttributes(nil), Equals, "")
+	c.Check(servicelog.FormatAttributes(map[string]string{
+		"port":  "8080",
+		"empty": "",
+		"path":  "/foo bar",
+		"query": "a=b",
+	}), Equals, `empty="" path="/foo bar" port=8080 query="a=b"`)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Canonical Ltd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License version 3 as
+// published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package tlsutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/canonical/pebble/internals/plan"
+	"github.com/canonical/pebble/internals/tlsutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type tlsSuite struct{}
+
+var _ = Suite(&tlsSuite{})
+
+func (*tlsSuite) TestClientConfig(c *C) {
+	insecureSkipVerify := true
+	config, err := tlsutil.ClientConfig(&plan.TLSOptions{
+		ServerName:         "example.com",
+		InsecureSkipVerify: &insecureSkipVerify,
+	})
+	c.Assert(err, IsNil)
+	c.Check(config.ServerName, Equals, "example.com")
+	c.Check(config.InsecureSkipVerify, Equals, true)
+	c.Check(config.RootCAs, IsNil)
+	c.Check(config.Certificates, HasLen, 0)
+}
+
+func (*tlsSuite) TestClientConfigInvalidFiles(c *C) {
+	dir := c.MkDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644)
+	c.Assert(err, IsNil)
+
+	_, err = tlsutil.ClientConfig(&plan.TLSOptions{CACert: filepath.Join(dir, "missing.pem")})
+	c.Check(err, ErrorMatches, "cannot read CA certificate: .*")
+	_, err = tlsutil.ClientConfig(&plan.TLSOptions{CACert: notPEM})
+	c.Check(err, ErrorMatches, `cannot parse CA certificate ".*": no PEM certificates found`)
+	_, err = tlsutil.ClientConfig(&plan.TLSOptions{ClientCert: notPEM, ClientKey: notPEM})
+	c.Check(err, ErrorMatches, "cannot load client certificate: .*")
+}
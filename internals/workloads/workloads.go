@@ -37,6 +37,10 @@ type Workload struct {
 	User        string            `yaml:"user,omitempty"`
 	GroupID     *int              `yaml:"group-id,omitempty"`
 	Group       string            `yaml:"group,omitempty"`
+
+	// Resource limits, which apply to each of the workload's services (but
+	// not to commands run with exec)
+	Resources *plan.Resources `yaml:"resources,omitempty"`
 }
 
 func (w *Workload) validate() error {
@@ -44,6 +48,11 @@ func (w *Workload) validate() error {
 		return errors.New("cannot have an empty name")
 	}
 	// Value of Override is checked in the (*WorkloadSection).combine() method
+	if w.Resources != nil {
+		if err := w.Resources.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -52,6 +61,7 @@ func (w *Workload) copy() *Workload {
 	copied.Environment = maps.Clone(w.Environment)
 	copied.UserID = copyPtr(w.UserID)
 	copied.GroupID = copyPtr(w.GroupID)
+	copied.Resources = w.Resources.Copy()
 	return &copied
 }
 
@@ -72,6 +82,12 @@ func (w *Workload) merge(other *Workload) {
 	if other.Group != "" {
 		w.Group = other.Group
 	}
+	if other.Resources != nil {
+		if w.Resources == nil {
+			w.Resources = &plan.Resources{}
+		}
+		w.Resources.Merge(other.Resources)
+	}
 }
 
 func (w *Workload) Equal(other *Workload) bool {
@@ -250,6 +250,55 @@ workloads:
         group-id: 1002
         group: users
     `,
+}, {
+	summary: "merge resources",
+	layers: []string{`
+workloads:
+    default:
+        override: merge
+        resources:
+            memory-max: 1G
+            cpu-max: 2
+    `, `
+workloads:
+    default:
+        override: merge
+        resources:
+            cpu-max: 0.5
+            pids-max: 50
+    `},
+	combinedSection: &workloads.WorkloadsSection{
+		Entries: map[string]*workloads.Workload{
+			"default": {
+				Name:     "default",
+				Override: plan.MergeOverride,
+				Resources: &plan.Resources{
+					MemoryMax: "1G",
+					CPUMax:    plan.OptionalFloat{Value: 0.5, IsSet: true},
+					PidsMax:   50,
+				},
+			},
+		},
+	},
+	combinedYAML: `
+workloads:
+    default:
+        override: merge
+        resources:
+            memory-max: 1G
+            cpu-max: 0.5
+            pids-max: 50
+    `,
+}, {
+	summary: "invalid resources",
+	layers: []string{`
+workloads:
+    default:
+        override: replace
+        resources:
+            io-weight: -1
+    `},
+	error: `workload "default": io-weight must be between 1 and 10000`,
 }}
 
 func (s *workloadsSuite) TestWorkloadsSectionExtensionSchema(c *C) {